@@ -1,56 +1,461 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// NoExpiration is returned by TTL for a key that exists but will not expire
+const NoExpiration time.Duration = -1
+
+const (
+	// ListPositionBefore inserts an element before the pivot element of a list
+	ListPositionBefore = "before"
+
+	// ListPositionAfter inserts an element after the pivot element of a list
+	ListPositionAfter = "after"
 
-	"github.com/xo/dburl"
+	// ListDirectionLeft refers to the head of a list
+	ListDirectionLeft = "left"
+
+	// ListDirectionRight refers to the tail of a list
+	ListDirectionRight = "right"
 )
 
+// Backend is implemented by every store that can hold properties. The context
+// passed to each method governs cancellation and deadlines for that call.
 type Backend interface {
-	BackendExport() (PropertyCollection, error)
-	BackendImport(p PropertyCollection, clear bool) (bool, error)
-	BackendReset() (bool, error)
-	Del(key string) (bool, error)
-	Exists(key string) (bool, error)
-	NamespaceExists(namespace string) (bool, error)
-	NamespaceClear(namespace string) (bool, error)
-	Get(key string, defaultValue string) (string, error)
-	GetAll() (map[string]string, error)
-	GetAllByPrefix(prefix string) (map[string]string, error)
-	Set(key string, value string) (bool, error)
-	Lindex(key string, index int) (string, error)
-	Lismember(key string, element string) (bool, error)
-	Llen(key string) (int, error)
-	Lrange(key string) ([]string, error)
-	Lrangefrom(key string, start int) ([]string, error)
-	Lrangefromto(key string, start int, stop int) ([]string, error)
-	Lrem(key string, countToRemove int, element string) (int, error)
-	Lset(key string, index int, element string) (bool, error)
-	Rpush(key string, newElements ...string) (int, error)
-	Sadd(key string, newMembers ...string) (int, error)
-	Sismember(key string, member string) (bool, error)
-	Smembers(key string) (map[string]bool, error)
-	Srem(key string, membersToRemove ...string) (int, error)
-}
-
-func ConstructBackend(url string, namespace string) (Backend, error) {
-	u, err := dburl.Parse(url)
+	BackendExport(ctx context.Context) (PropertyCollection, error)
+	BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error)
+	BackendReset(ctx context.Context) (bool, error)
+	Del(ctx context.Context, key string) (bool, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	NamespaceExists(ctx context.Context, namespace string) (bool, error)
+	NamespaceClear(ctx context.Context, namespace string) (bool, error)
+	Get(ctx context.Context, key string, defaultValue string) (string, error)
+	GetWithRevision(ctx context.Context, key string) (string, int64, error)
+	GetAll(ctx context.Context) (map[string]string, error)
+	GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error)
+	Set(ctx context.Context, key string, value string) (bool, error)
+	SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error)
+	SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error)
+	GetSet(ctx context.Context, key string, value string) (string, error)
+	Incr(ctx context.Context, key string) (int64, error)
+	IncrBy(ctx context.Context, key string, increment int64) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Persist(ctx context.Context, key string) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Blpop(ctx context.Context, key string, timeout time.Duration) (string, error)
+	Brpop(ctx context.Context, key string, timeout time.Duration) (string, error)
+	Lindex(ctx context.Context, key string, index int) (string, error)
+	Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error)
+	Lismember(ctx context.Context, key string, element string) (bool, error)
+	Llen(ctx context.Context, key string) (int, error)
+	Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error)
+	Lpop(ctx context.Context, key string, count int) ([]string, error)
+	Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error)
+	Lpush(ctx context.Context, key string, newElements ...string) (int, error)
+	Lrange(ctx context.Context, key string) ([]string, error)
+	Lrangefrom(ctx context.Context, key string, start int) ([]string, error)
+	Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error)
+	Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error)
+	Lset(ctx context.Context, key string, index int, element string) (bool, error)
+	Ltrim(ctx context.Context, key string, start int, stop int) (bool, error)
+	Rpop(ctx context.Context, key string, count int) ([]string, error)
+	Rpush(ctx context.Context, key string, newElements ...string) (int, error)
+	Sadd(ctx context.Context, key string, newMembers ...string) (int, error)
+	Scard(ctx context.Context, key string) (int, error)
+	Sdiff(ctx context.Context, keys ...string) (map[string]bool, error)
+	Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error)
+	Sinter(ctx context.Context, keys ...string) (map[string]bool, error)
+	Sinterstore(ctx context.Context, destination string, keys ...string) (int, error)
+	Sismember(ctx context.Context, key string, member string) (bool, error)
+	Smembers(ctx context.Context, key string) (map[string]bool, error)
+	Smove(ctx context.Context, source string, destination string, member string) (bool, error)
+	Spop(ctx context.Context, key string, count int) ([]string, error)
+	Srandmember(ctx context.Context, key string, count int) ([]string, error)
+	Srem(ctx context.Context, key string, membersToRemove ...string) (int, error)
+	Sunion(ctx context.Context, keys ...string) (map[string]bool, error)
+	Sunionstore(ctx context.Context, destination string, keys ...string) (int, error)
+	Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error)
+	Hexists(ctx context.Context, key string, field string) (bool, error)
+	Hget(ctx context.Context, key string, field string) (string, error)
+	Hgetall(ctx context.Context, key string) (map[string]string, error)
+	Hkeys(ctx context.Context, key string) ([]string, error)
+	Hlen(ctx context.Context, key string) (int, error)
+	Hset(ctx context.Context, key string, fields map[string]string) (int, error)
+	Zadd(ctx context.Context, key string, scores map[string]float64) (int, error)
+	Zcard(ctx context.Context, key string) (int, error)
+	Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error)
+	Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error)
+	Zrank(ctx context.Context, key string, member string) (int, error)
+	Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error)
+	Zscore(ctx context.Context, key string, member string) (float64, error)
+}
+
+// TransactionalBackend is implemented by backends that can apply several
+// operations atomically. Calls made with the context passed to fn are
+// committed together if fn returns nil, and rolled back otherwise.
+type TransactionalBackend interface {
+	Backend
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// SupportsTransactions returns true if b, or the backend wrapped by a
+// ValidatingBackend or HistoryBackend, implements TransactionalBackend
+func SupportsTransactions(b Backend) bool {
+	_, ok := unwrapBackend(b).(TransactionalBackend)
+	return ok
+}
+
+// unwrapBackend returns the backend wrapped by any ValidatingBackend or
+// HistoryBackend
+func unwrapBackend(b Backend) Backend {
+	for {
+		wrapper, ok := b.(interface{ Unwrap() Backend })
+		if !ok {
+			return b
+		}
+		b = wrapper.Unwrap()
+	}
+}
+
+// ConstructBackend returns the registered backend matching the url scheme,
+// wrapped to validate the namespaces, keys and values passed to it
+func ConstructBackend(ctx context.Context, url string, namespace string) (Backend, error) {
+	u, err := ParseURL(url)
 	if err != nil {
-		return NewUnimplementedBackend()
+		return nil, err
 	}
 
 	if namespace == "" {
 		namespace = u.Query().Get("namespace")
 	}
 
-	if u.Scheme == "file" {
-		return NewUnstructuredFileBackend(namespace, u)
+	if err := ValidateNamespace(namespace); err != nil {
+		return nil, err
 	}
 
-	return NewUnimplementedBackend()
+	factory, ok := lookupFactory(u)
+	if !ok {
+		return nil, unknownSchemeError(u)
+	}
+
+	history := false
+	if value := u.Query().Get("history"); value != "" {
+		history, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid history %s, expected true or false", value)
+		}
+
+		query := u.Query()
+		query.Del("history")
+		u.RawQuery = query.Encode()
+	}
+
+	historyLimit := 0
+	if value := u.Query().Get("history-limit"); value != "" {
+		historyLimit, err = strconv.Atoi(value)
+		if err != nil || historyLimit < 1 {
+			return nil, fmt.Errorf("Invalid history-limit %s, expected a positive number", value)
+		}
+
+		query := u.Query()
+		query.Del("history-limit")
+		u.RawQuery = query.Encode()
+	}
+
+	b, err := factory(ctx, namespace, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if history {
+		b, err = newHistoryBackend(namespace, b, historyLimit, func() (Backend, error) {
+			return factory(ctx, HistoryNamespace, u)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewValidatingBackend(namespace, b)
 }
 
 func prettyPrint(i interface{}) string {
 	s, _ := json.MarshalIndent(i, "", "\t")
 	return string(s)
 }
+
+// incrementValue adds increment to the integer held by a key, which is zero if
+// the key does not exist, returning an ErrNotInteger error if the value is not
+// a base 10 integer or the result would overflow an int64
+func incrementValue(namespace string, key string, value string, exists bool, increment int64) (int64, error) {
+	current := int64(0)
+	if exists {
+		var err error
+		current, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, newKeyError(namespace, key, ErrNotInteger)
+		}
+	}
+
+	if (increment > 0 && current > math.MaxInt64-increment) || (increment < 0 && current < math.MinInt64-increment) {
+		return 0, newKeyError(namespace, key, ErrNotInteger)
+	}
+
+	return current + increment, nil
+}
+
+// normalizeListRange converts an inclusive start and stop range, where negative
+// indexes count from the end of the list, into an offset and limit
+func normalizeListRange(length int, start int, stop int) (int, int, bool) {
+	if start < 0 {
+		start = length + start
+		if start < 0 {
+			start = 0
+		}
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start, stop - start + 1, true
+}
+
+// prependElements inserts each new element at the head of a list in turn, so
+// that the last new element becomes the first element of the list
+func prependElements(elements []string, newElements []string) []string {
+	prepended := make([]string, 0, len(elements)+len(newElements))
+	for i := len(newElements) - 1; i >= 0; i-- {
+		prepended = append(prepended, newElements[i])
+	}
+
+	return append(prepended, elements...)
+}
+
+// popElements removes up to count elements from the head of a list, or from
+// the tail when fromTail is set, returning the removed elements in the order
+// they were removed along with the remaining elements
+func popElements(elements []string, count int, fromTail bool) ([]string, []string) {
+	if count > len(elements) {
+		count = len(elements)
+	}
+
+	if fromTail {
+		popped := append([]string{}, elements[len(elements)-count:]...)
+		reverse(popped)
+		return popped, elements[:len(elements)-count]
+	}
+
+	return append([]string{}, elements[:count]...), elements[count:]
+}
+
+// validatePopCount returns an ErrInvalidValue error unless count is positive
+func validatePopCount(namespace string, key string, count int) error {
+	if count < 1 {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// insertElement inserts an element before or after the first occurrence of
+// pivot, returning an ErrElementNotFound error if pivot is not in the list
+func insertElement(namespace string, key string, elements []string, position string, pivot string, element string) ([]string, error) {
+	for i, e := range elements {
+		if e != pivot {
+			continue
+		}
+
+		if position == ListPositionAfter {
+			i++
+		}
+
+		inserted := make([]string, 0, len(elements)+1)
+		inserted = append(inserted, elements[:i]...)
+		inserted = append(inserted, element)
+		return append(inserted, elements[i:]...), nil
+	}
+
+	return nil, newKeyError(namespace, key, ErrElementNotFound)
+}
+
+// validateListPosition returns an ErrInvalidValue error unless position is
+// ListPositionBefore or ListPositionAfter
+func validateListPosition(namespace string, key string, position string) error {
+	if position != ListPositionBefore && position != ListPositionAfter {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// listPositions returns the indexes of up to count occurrences of an element,
+// or all of them when count is zero. The search starts at the rank-th
+// occurrence from the head of the list, or from the tail when rank is negative.
+func listPositions(elements []string, element string, rank int, count int) []int {
+	positions := []int{}
+	skip := abs(rank) - 1
+	for i := range elements {
+		index := i
+		if rank < 0 {
+			index = len(elements) - 1 - i
+		}
+		if elements[index] != element {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		positions = append(positions, index)
+		if count > 0 && len(positions) == count {
+			break
+		}
+	}
+
+	return positions
+}
+
+// validateListPositionOptions returns an ErrInvalidValue error if rank is zero
+// or count is negative
+func validateListPositionOptions(namespace string, key string, rank int, count int) error {
+	if rank == 0 || count < 0 {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// moveElement removes an element from one end of the source list and adds it
+// to an end of the destination list, returning the element along with the
+// new elements of both lists. When both lists are the same key, the returned
+// destination holds the elements of that key.
+func moveElement(source []string, destination []string, sameKey bool, from string, to string) (string, []string, []string) {
+	var element string
+	if from == ListDirectionLeft {
+		element, source = source[0], source[1:]
+	} else {
+		element, source = source[len(source)-1], source[:len(source)-1]
+	}
+
+	if sameKey {
+		destination = source
+	}
+
+	if to == ListDirectionLeft {
+		destination = prependElements(destination, []string{element})
+	} else {
+		destination = append(append([]string{}, destination...), element)
+	}
+
+	return element, source, destination
+}
+
+// validateListDirections returns an ErrInvalidValue error unless both from
+// and to are ListDirectionLeft or ListDirectionRight
+func validateListDirections(namespace string, key string, from string, to string) error {
+	for _, direction := range []string{from, to} {
+		if direction != ListDirectionLeft && direction != ListDirectionRight {
+			return newKeyError(namespace, key, ErrInvalidValue)
+		}
+	}
+
+	return nil
+}
+
+// setOperation combines the members of several sets into a new set
+type setOperation func(sets []map[string]bool) map[string]bool
+
+// diffMembers returns the members of the first set that are not members of
+// any of the other sets
+func diffMembers(sets []map[string]bool) map[string]bool {
+	members := map[string]bool{}
+	if len(sets) == 0 {
+		return members
+	}
+
+	for member := range sets[0] {
+		members[member] = true
+	}
+	for _, set := range sets[1:] {
+		for member := range set {
+			delete(members, member)
+		}
+	}
+
+	return members
+}
+
+// interMembers returns the members found in every set
+func interMembers(sets []map[string]bool) map[string]bool {
+	members := map[string]bool{}
+	if len(sets) == 0 {
+		return members
+	}
+
+	for member := range sets[0] {
+		members[member] = true
+	}
+	for _, set := range sets[1:] {
+		for member := range members {
+			if !set[member] {
+				delete(members, member)
+			}
+		}
+	}
+
+	return members
+}
+
+// unionMembers returns the members found in any of the sets
+func unionMembers(sets []map[string]bool) map[string]bool {
+	members := map[string]bool{}
+	for _, set := range sets {
+		for member := range set {
+			members[member] = true
+		}
+	}
+
+	return members
+}
+
+// randomMembers returns up to count distinct members of a set in a random
+// order. A negative count returns exactly -count members, which may repeat,
+// as with the Redis SRANDMEMBER command.
+func randomMembers(members map[string]bool, count int) []string {
+	sorted := sortedMembers(members)
+	if len(sorted) == 0 {
+		return []string{}
+	}
+
+	if count < 0 {
+		chosen := make([]string, -count)
+		for i := range chosen {
+			chosen[i] = sorted[rand.IntN(len(sorted))]
+		}
+		return chosen
+	}
+
+	rand.Shuffle(len(sorted), func(i, j int) {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	})
+	return sorted[:min(count, len(sorted))]
+}
@@ -0,0 +1,152 @@
+package backend_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dokku/prop/backend"
+	"github.com/dokku/prop/backend/backendtest"
+	"github.com/dokku/prop/plugin"
+	"github.com/xo/dburl"
+)
+
+// urlFactory returns a factory constructing backends from the url returned
+// by newURL, which is called once per test
+func urlFactory(newURL func(t *testing.T) string) backendtest.Factory {
+	return func(t *testing.T) backendtest.NewBackend {
+		u := newURL(t)
+		return func(namespace string) (backend.Backend, error) {
+			return backend.ConstructBackend(t.Context(), u, namespace)
+		}
+	}
+}
+
+// pluginFactory returns a factory constructing plugin backends that speak
+// the plugin protocol over a net.Pipe to a plugin.ServeConn serving the
+// backend at the url returned by newURL
+func pluginFactory(newURL func(t *testing.T) string) backendtest.Factory {
+	return func(t *testing.T) backendtest.NewBackend {
+		u, err := backend.ParseURL(newURL(t))
+		if err != nil {
+			t.Fatalf("ParseURL: %s", err)
+		}
+
+		return func(namespace string) (backend.Backend, error) {
+			client, server := net.Pipe()
+			go plugin.ServeConn(t.Context(), server, server, func(ctx context.Context, namespace string, u *dburl.URL) (backend.Backend, error) {
+				return backend.ConstructBackend(ctx, u.String(), namespace)
+			})
+
+			b, err := backend.NewPluginBackendWithConn(t.Context(), namespace, u, client)
+			if err != nil {
+				return nil, err
+			}
+			t.Cleanup(func() { b.Close() })
+			return b, nil
+		}
+	}
+}
+
+// postgresFactory returns a factory constructing backends against the
+// database at PROP_TEST_POSTGRES_URL, skipping the tests if it is not set.
+// The database is reset before and after each test.
+func postgresFactory(t *testing.T) backendtest.NewBackend {
+	u := os.Getenv("PROP_TEST_POSTGRES_URL")
+	if u == "" {
+		t.Skip("PROP_TEST_POSTGRES_URL is not set")
+	}
+
+	reset := func() {
+		b, err := backend.ConstructBackend(context.Background(), u, "default")
+		if err != nil {
+			t.Fatalf("ConstructBackend: %s", err)
+		}
+		if _, err := b.BackendReset(context.Background()); err != nil {
+			t.Fatalf("BackendReset: %s", err)
+		}
+	}
+	reset()
+	t.Cleanup(reset)
+
+	return func(namespace string) (backend.Backend, error) {
+		return backend.ConstructBackend(t.Context(), u, namespace)
+	}
+}
+
+// unwatchableBackend hides the Watch method of a backend, as miniredis does
+// not publish keyspace notifications
+type unwatchableBackend struct {
+	backend.Backend
+}
+
+func TestConformance(t *testing.T) {
+	var server *miniredis.Miniredis
+	tests := []struct {
+		name    string
+		factory backendtest.Factory
+		options backendtest.Options
+	}{
+		{
+			name: "mem",
+			factory: urlFactory(func(t *testing.T) string {
+				return "mem:" + t.Name()
+			}),
+		},
+		{
+			name: "file",
+			factory: urlFactory(func(t *testing.T) string {
+				return "file:" + t.TempDir()
+			}),
+			options: backendtest.Options{Untyped: true},
+		},
+		{
+			name: "file+json",
+			factory: urlFactory(func(t *testing.T) string {
+				return "file+json:" + t.TempDir()
+			}),
+		},
+		{
+			name: "sqlite",
+			factory: urlFactory(func(t *testing.T) string {
+				return "sqlite:" + filepath.Join(t.TempDir(), "prop.db")
+			}),
+		},
+		{
+			name:    "postgres",
+			factory: postgresFactory,
+		},
+		{
+			name: "plugin",
+			factory: pluginFactory(func(t *testing.T) string {
+				return "mem:" + t.Name()
+			}),
+		},
+		{
+			name: "redis",
+			factory: func(t *testing.T) backendtest.NewBackend {
+				server = miniredis.RunT(t)
+				u := "redis://" + server.Addr()
+				return func(namespace string) (backend.Backend, error) {
+					b, err := backend.ConstructBackend(t.Context(), u, namespace)
+					return unwatchableBackend{b}, err
+				}
+			},
+			options: backendtest.Options{
+				Advance: func(t *testing.T, d time.Duration) {
+					server.FastForward(d)
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backendtest.Run(t, tt.factory, tt.options)
+		})
+	}
+}
@@ -0,0 +1,2354 @@
+// Package backendtest implements a conformance suite that pins down the
+// semantics every backend.Backend implementation is expected to follow.
+//
+// A backend package runs the suite from one of its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		backendtest.Run(t, func(t *testing.T) backendtest.NewBackend {
+//			return func(namespace string) (backend.Backend, error) {
+//				return backend.NewMemoryBackend(namespace), nil
+//			}
+//		}, backendtest.Options{})
+//	}
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dokku/prop/backend"
+)
+
+const (
+	// namespace is the namespace most tests are run against
+	namespace = "default"
+
+	// otherNamespace is used to verify namespace isolation
+	otherNamespace = "other"
+)
+
+// NewBackend constructs a backend bound to a namespace
+type NewBackend func(namespace string) (backend.Backend, error)
+
+// Factory is called once per test, and returns a NewBackend whose backends
+// all share the same, initially empty, storage.
+type Factory func(t *testing.T) NewBackend
+
+// Options configures the behaviors verified by Run
+type Options struct {
+	// Untyped should be set for backends that do not record the data type of
+	// each key. Type mismatch and export checks are skipped for such backends.
+	Untyped bool
+
+	// Advance moves the clock used for key expiration forward. Backends
+	// backed by a fake clock, such as miniredis, should set it; otherwise
+	// the test sleeps for the duration.
+	Advance func(t *testing.T, d time.Duration)
+}
+
+// advance moves the clock used for key expiration forward by d
+func (options Options) advance(t *testing.T, d time.Duration) {
+	if options.Advance != nil {
+		options.Advance(t, d)
+		return
+	}
+
+	time.Sleep(d)
+}
+
+type testCase struct {
+	name  string
+	typed bool
+	run   func(t *testing.T, newBackend NewBackend)
+}
+
+// Run runs every conformance test against backends created by factory
+func Run(t *testing.T, factory Factory, options Options) {
+	for _, tc := range testCases(options) {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.typed && options.Untyped {
+				t.Skip("backend does not record data types")
+			}
+
+			tc.run(t, factory(t))
+		})
+	}
+}
+
+func testCases(options Options) []testCase {
+	return []testCase{
+		{name: "Exists", run: testExists},
+		{name: "Del", run: testDel},
+		{name: "Get", run: testGet},
+		{name: "GetWithRevision", run: testGetWithRevision},
+		{name: "GetAll", run: testGetAll},
+		{name: "GetAllByPrefix", run: testGetAllByPrefix},
+		{name: "Set", run: testSet},
+		{name: "SetWithTTL", run: testSetWithTTL},
+		{name: "SetIfNotExists", run: testSetIfNotExists},
+		{name: "SetIfValue", run: testSetIfValue},
+		{name: "SetIfRevision", run: testSetIfRevision},
+		{name: "GetSet", run: testGetSet},
+		{name: "Incr", run: testIncr},
+		{name: "IncrBy", run: testIncrBy},
+		{name: "Decr", run: testDecr},
+		{name: "Expire", run: func(t *testing.T, newBackend NewBackend) { testExpire(t, newBackend, options) }},
+		{name: "Persist", run: testPersist},
+		{name: "TTL", run: testTTL},
+		{name: "Blpop", run: testBlpop},
+		{name: "Brpop", run: testBrpop},
+		{name: "Lindex", run: testLindex},
+		{name: "Linsert", run: testLinsert},
+		{name: "Lismember", run: testLismember},
+		{name: "Llen", run: testLlen},
+		{name: "Lmove", run: testLmove},
+		{name: "Lpop", run: testLpop},
+		{name: "Lpos", run: testLpos},
+		{name: "Lpush", run: testLpush},
+		{name: "Lrange", run: testLrange},
+		{name: "Lrem", run: testLrem},
+		{name: "Lset", run: testLset},
+		{name: "Ltrim", run: testLtrim},
+		{name: "Rpop", run: testRpop},
+		{name: "Rpush", run: testRpush},
+		{name: "Sadd", run: testSadd},
+		{name: "Scard", run: testScard},
+		{name: "Sdiff", run: testSdiff},
+		{name: "Sdiffstore", run: testSdiffstore},
+		{name: "Sinter", run: testSinter},
+		{name: "Sinterstore", run: testSinterstore},
+		{name: "Sismember", run: testSismember},
+		{name: "Smembers", run: testSmembers},
+		{name: "Smove", run: testSmove},
+		{name: "Spop", run: testSpop},
+		{name: "Srandmember", run: testSrandmember},
+		{name: "Srem", run: testSrem},
+		{name: "Sunion", run: testSunion},
+		{name: "Sunionstore", run: testSunionstore},
+		{name: "Hdel", run: testHdel},
+		{name: "Hexists", run: testHexists},
+		{name: "Hget", run: testHget},
+		{name: "Hgetall", run: testHgetall},
+		{name: "Hkeys", run: testHkeys},
+		{name: "Hlen", run: testHlen},
+		{name: "Hset", run: testHset},
+		{name: "Zadd", run: testZadd},
+		{name: "Zcard", run: testZcard},
+		{name: "Zrange", run: testZrange},
+		{name: "Zrangebyscore", run: testZrangebyscore},
+		{name: "Zrank", run: testZrank},
+		{name: "Zrem", run: testZrem},
+		{name: "Zscore", run: testZscore},
+		{name: "NamespaceExists", run: testNamespaceExists},
+		{name: "NamespaceClear", run: testNamespaceClear},
+		{name: "BackendReset", run: testBackendReset},
+		{name: "BackendImport", run: testBackendImport},
+		{name: "Watch", run: testWatch},
+		{name: "BackendExport", typed: true, run: testBackendExport},
+		{name: "WrongType", typed: true, run: testWrongType},
+	}
+}
+
+func testExists(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	exists, err := b.Exists(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Exists on a missing key", exists, false)
+
+	for _, key := range []string{"key-value", "list", "set", "hash", "sorted-set"} {
+		seed(t, b, key)
+		exists, err = b.Exists(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "Exists on "+key, exists, true)
+	}
+
+	mustSet(t, b, "nested/key", "value")
+	exists, err = b.Exists(t.Context(), "nested")
+	assertNoError(t, err)
+	assertEqual(t, "Exists on the parent of a nested key", exists, false)
+}
+
+func testDel(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.Del(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Del on a missing key", ok, true)
+
+	for _, key := range []string{"key-value", "list", "set", "hash", "sorted-set"} {
+		seed(t, b, key)
+		ok, err = b.Del(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "Del on "+key, ok, true)
+
+		exists, err := b.Exists(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "Exists after Del on "+key, exists, false)
+	}
+}
+
+func testGet(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Get(t.Context(), "missing", "")
+	assertError(t, "Get on a missing key without a default", err, backend.ErrKeyNotFound)
+
+	value, err := b.Get(t.Context(), "missing", "fallback")
+	assertNoError(t, err)
+	assertEqual(t, "Get on a missing key with a default", value, "fallback")
+
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"simple", "value"},
+		{"empty", ""},
+		{"spaces", "  padded value  "},
+		{"format", "100% %s %d"},
+		{"nested/key", "nested value"},
+	}
+	for _, tt := range tests {
+		mustSet(t, b, tt.key, tt.value)
+		value, err := b.Get(t.Context(), tt.key, "fallback")
+		assertNoError(t, err)
+		assertEqual(t, "Get on "+tt.key, value, tt.value)
+	}
+}
+
+func testGetWithRevision(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, _, err := b.GetWithRevision(t.Context(), "missing")
+	assertError(t, "GetWithRevision on a missing key", err, backend.ErrKeyNotFound)
+
+	mustSet(t, b, "key", "value")
+	value, revision, err := b.GetWithRevision(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "GetWithRevision", value, "value")
+	if revision <= 0 {
+		t.Fatalf("GetWithRevision returned revision %d, expected a positive revision", revision)
+	}
+
+	_, again, err := b.GetWithRevision(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "GetWithRevision without a write in between", again, revision)
+
+	// every write, including one of the same value or after deleting the
+	// key, must result in a revision the key has not held before
+	seen := map[int64]string{revision: "Set"}
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{"Set of the same value", func() error { _, err := b.Set(t.Context(), "key", "value"); return err }},
+		{"SetWithTTL", func() error { _, err := b.SetWithTTL(t.Context(), "key", "value", time.Hour); return err }},
+		{"SetIfValue", func() error { _, err := b.SetIfValue(t.Context(), "key", "value", "1", 0); return err }},
+		{"GetSet", func() error { _, err := b.GetSet(t.Context(), "key", "1"); return err }},
+		{"Incr", func() error { _, err := b.Incr(t.Context(), "key"); return err }},
+		{"IncrBy", func() error { _, err := b.IncrBy(t.Context(), "key", 10); return err }},
+		{"Decr", func() error { _, err := b.Decr(t.Context(), "key"); return err }},
+		{"Set after Del", func() error {
+			if _, err := b.Del(t.Context(), "key"); err != nil {
+				return err
+			}
+			_, err := b.Set(t.Context(), "key", "value")
+			return err
+		}},
+	}
+	for _, w := range writes {
+		assertNoError(t, w.write())
+		_, revision, err := b.GetWithRevision(t.Context(), "key")
+		assertNoError(t, err)
+		if previous, ok := seen[revision]; ok {
+			t.Errorf("GetWithRevision after %s returned revision %d, which the key held after %s", w.name, revision, previous)
+		}
+		seen[revision] = w.name
+	}
+}
+
+func testGetAll(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	keyValuePairs, err := b.GetAll(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "GetAll on an empty namespace", keyValuePairs, map[string]string{})
+
+	mustSet(t, b, "first", "1")
+	mustSet(t, b, "second", "2")
+	mustSet(t, b, "nested/third", "3")
+	mustSet(t, mustBackend(t, newBackend, otherNamespace), "fourth", "4")
+
+	keyValuePairs, err = b.GetAll(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "GetAll", keyValuePairs, map[string]string{
+		"first":        "1",
+		"second":       "2",
+		"nested/third": "3",
+	})
+}
+
+func testGetAllByPrefix(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustSet(t, b, "app-one", "1")
+	mustSet(t, b, "app-two", "2")
+	mustSet(t, b, "application", "3")
+	mustSet(t, b, "app/nested", "4")
+	mustSet(t, b, "other", "5")
+	mustSet(t, b, "App-upper", "6")
+
+	tests := []struct {
+		prefix string
+		want   map[string]string
+	}{
+		{"", map[string]string{"app-one": "1", "app-two": "2", "application": "3", "app/nested": "4", "other": "5", "App-upper": "6"}},
+		{"app", map[string]string{"app-one": "1", "app-two": "2", "application": "3", "app/nested": "4"}},
+		{"app-", map[string]string{"app-one": "1", "app-two": "2"}},
+		{"app/", map[string]string{"app/nested": "4"}},
+		{"app_", map[string]string{}},
+		{"app%", map[string]string{}},
+		{"missing", map[string]string{}},
+	}
+	for _, tt := range tests {
+		keyValuePairs, err := b.GetAllByPrefix(t.Context(), tt.prefix)
+		assertNoError(t, err)
+		assertEqual(t, "GetAllByPrefix with prefix "+tt.prefix, keyValuePairs, tt.want)
+	}
+}
+
+func testSet(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.Set(t.Context(), "key", "first")
+	assertNoError(t, err)
+	assertEqual(t, "Set on a missing key", ok, true)
+
+	ok, err = b.Set(t.Context(), "key", "second")
+	assertNoError(t, err)
+	assertEqual(t, "Set on an existing key", ok, true)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after overwriting a key", value, "second")
+
+	other := mustBackend(t, newBackend, otherNamespace)
+	exists, err := other.Exists(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "Exists in another namespace", exists, false)
+}
+
+func testSetWithTTL(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	assertTTL := func(name string, key string, want time.Duration) {
+		t.Helper()
+		ttl, err := b.TTL(t.Context(), key)
+		assertNoError(t, err)
+		if want == backend.NoExpiration {
+			assertEqual(t, name, ttl, backend.NoExpiration)
+		} else if ttl <= want-time.Minute || ttl > want {
+			t.Errorf("%s: got %s, want about %s", name, ttl, want)
+		}
+	}
+
+	ok, err := b.SetWithTTL(t.Context(), "key", "first", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "SetWithTTL on a missing key", ok, true)
+	assertTTL("TTL after SetWithTTL", "key", time.Hour)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after SetWithTTL", value, "first")
+
+	_, err = b.SetWithTTL(t.Context(), "key", "second", 2*time.Hour)
+	assertNoError(t, err)
+	assertTTL("TTL after overwriting with SetWithTTL", "key", 2*time.Hour)
+
+	_, err = b.SetWithTTL(t.Context(), "key", "third", 0)
+	assertNoError(t, err)
+	assertTTL("TTL after SetWithTTL with a zero ttl", "key", backend.NoExpiration)
+
+	ok, err = b.SetIfNotExists(t.Context(), "new", "value", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfNotExists with a ttl", ok, true)
+	assertTTL("TTL after SetIfNotExists with a ttl", "new", time.Hour)
+
+	_, err = b.SetIfValue(t.Context(), "key", "stale", "fourth", time.Hour)
+	assertError(t, "SetIfValue with a ttl and a stale value", err, backend.ErrConditionFailed)
+	assertTTL("TTL after a failed SetIfValue", "key", backend.NoExpiration)
+
+	ok, err = b.SetIfValue(t.Context(), "key", "third", "fourth", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfValue with a ttl", ok, true)
+	assertTTL("TTL after SetIfValue with a ttl", "key", time.Hour)
+
+	// a key rewritten with a ttl must never be observed without an expiry
+	if _, err := b.SetWithTTL(t.Context(), "concurrent", "0", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL concurrent: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		for i := 1; i <= 50; i++ {
+			if _, err := b.SetWithTTL(t.Context(), "concurrent", strconv.Itoa(i), time.Hour); err != nil {
+				done <- err
+				return
+			}
+			if _, err := b.SetIfValue(t.Context(), "concurrent", strconv.Itoa(i), strconv.Itoa(-i), time.Hour); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	persisted := false
+	for {
+		select {
+		case err := <-done:
+			assertNoError(t, err)
+			assertEqual(t, "TTL during concurrent writes with a ttl left the key without an expiry", persisted, false)
+			assertTTL("TTL after concurrent writes with a ttl", "concurrent", time.Hour)
+			return
+		default:
+		}
+
+		if ttl, err := b.TTL(t.Context(), "concurrent"); err == nil && ttl == backend.NoExpiration {
+			persisted = true
+		}
+	}
+}
+
+func testSetIfNotExists(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.SetIfNotExists(t.Context(), "key", "first", 0)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfNotExists on a missing key", ok, true)
+
+	ok, err = b.SetIfNotExists(t.Context(), "key", "second", 0)
+	assertError(t, "SetIfNotExists on an existing key", err, backend.ErrConditionFailed)
+	assertEqual(t, "SetIfNotExists on an existing key", ok, false)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after a failed SetIfNotExists", value, "first")
+
+	seed(t, b, "list")
+	_, err = b.SetIfNotExists(t.Context(), "list", "value", 0)
+	assertError(t, "SetIfNotExists on a list", err, backend.ErrConditionFailed)
+
+	other := mustBackend(t, newBackend, otherNamespace)
+	ok, err = other.SetIfNotExists(t.Context(), "key", "other", 0)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfNotExists in another namespace", ok, true)
+}
+
+func testSetIfValue(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.SetIfValue(t.Context(), "missing", "", "value", 0)
+	assertError(t, "SetIfValue on a missing key", err, backend.ErrConditionFailed)
+
+	exists, err := b.Exists(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after a failed SetIfValue", exists, false)
+
+	mustSet(t, b, "key", "first")
+	_, err = b.SetIfValue(t.Context(), "key", "other", "second", 0)
+	assertError(t, "SetIfValue with a stale value", err, backend.ErrConditionFailed)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after a failed SetIfValue", value, "first")
+
+	ok, err := b.SetIfValue(t.Context(), "key", "first", "second", 0)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfValue with the current value", ok, true)
+
+	value, err = b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after SetIfValue", value, "second")
+}
+
+func testSetIfRevision(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.SetIfRevision(t.Context(), "key", 0, "first", 0)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfRevision on a missing key with revision 0", ok, true)
+
+	_, err = b.SetIfRevision(t.Context(), "key", 0, "other", 0)
+	assertError(t, "SetIfRevision on an existing key with revision 0", err, backend.ErrConditionFailed)
+
+	_, revision, err := b.GetWithRevision(t.Context(), "key")
+	assertNoError(t, err)
+
+	_, err = b.SetIfRevision(t.Context(), "missing", revision, "value", 0)
+	assertError(t, "SetIfRevision on a missing key", err, backend.ErrConditionFailed)
+
+	exists, err := b.Exists(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after a failed SetIfRevision", exists, false)
+
+	// two writers read the same revision, and only the first may write
+	ok, err = b.SetIfRevision(t.Context(), "key", revision, "second", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "SetIfRevision with the current revision", ok, true)
+
+	_, err = b.SetIfRevision(t.Context(), "key", revision, "third", 0)
+	assertError(t, "SetIfRevision with a stale revision", err, backend.ErrConditionFailed)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after a failed SetIfRevision", value, "second")
+
+	ttl, err := b.TTL(t.Context(), "key")
+	assertNoError(t, err)
+	if ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL after SetIfRevision with a ttl returned %s, expected at most 1h", ttl)
+	}
+}
+
+func testGetSet(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	previous, err := b.GetSet(t.Context(), "key", "first")
+	assertNoError(t, err)
+	assertEqual(t, "GetSet on a missing key", previous, "")
+
+	previous, err = b.GetSet(t.Context(), "key", "second")
+	assertNoError(t, err)
+	assertEqual(t, "GetSet on an existing key", previous, "first")
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after GetSet", value, "second")
+}
+
+func testIncr(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	value, err := b.Incr(t.Context(), "counter")
+	assertNoError(t, err)
+	assertEqual(t, "Incr on a missing key", value, int64(1))
+
+	value, err = b.Incr(t.Context(), "counter")
+	assertNoError(t, err)
+	assertEqual(t, "Incr on an existing key", value, int64(2))
+
+	stored, err := b.Get(t.Context(), "counter", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after Incr", stored, "2")
+
+	mustSet(t, b, "key", "value")
+	_, err = b.Incr(t.Context(), "key")
+	assertError(t, "Incr on a value that is not an integer", err, backend.ErrNotInteger)
+
+	stored, err = b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after a failed Incr", stored, "value")
+}
+
+func testIncrBy(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	value, err := b.IncrBy(t.Context(), "counter", 10)
+	assertNoError(t, err)
+	assertEqual(t, "IncrBy on a missing key", value, int64(10))
+
+	value, err = b.IncrBy(t.Context(), "counter", -15)
+	assertNoError(t, err)
+	assertEqual(t, "IncrBy with a negative increment", value, int64(-5))
+
+	mustSet(t, b, "large", strconv.FormatInt(math.MaxInt64, 10))
+	_, err = b.IncrBy(t.Context(), "large", 1)
+	assertError(t, "IncrBy past the largest integer", err, backend.ErrNotInteger)
+
+	mustSet(t, b, "float", "1.5")
+	_, err = b.IncrBy(t.Context(), "float", 1)
+	assertError(t, "IncrBy on a float", err, backend.ErrNotInteger)
+
+	mustSet(t, b, "expiring", "1")
+	_, err = b.Expire(t.Context(), "expiring", time.Hour)
+	assertNoError(t, err)
+	_, err = b.IncrBy(t.Context(), "expiring", 1)
+	assertNoError(t, err)
+	ttl, err := b.TTL(t.Context(), "expiring")
+	assertNoError(t, err)
+	if ttl <= time.Hour-time.Minute || ttl > time.Hour {
+		t.Errorf("TTL after IncrBy: got %s, want about 1h", ttl)
+	}
+}
+
+func testDecr(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	value, err := b.Decr(t.Context(), "counter")
+	assertNoError(t, err)
+	assertEqual(t, "Decr on a missing key", value, int64(-1))
+
+	mustSet(t, b, "counter", "10")
+	value, err = b.Decr(t.Context(), "counter")
+	assertNoError(t, err)
+	assertEqual(t, "Decr on an existing key", value, int64(9))
+
+	mustSet(t, b, "small", strconv.FormatInt(math.MinInt64, 10))
+	_, err = b.Decr(t.Context(), "small")
+	assertError(t, "Decr past the smallest integer", err, backend.ErrNotInteger)
+}
+
+func testExpire(t *testing.T, newBackend NewBackend, options Options) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.Expire(t.Context(), "missing", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "Expire on a missing key", ok, false)
+
+	for _, key := range []string{"key-value", "list", "set"} {
+		seed(t, b, key)
+		ok, err = b.Expire(t.Context(), key, time.Hour)
+		assertNoError(t, err)
+		assertEqual(t, "Expire on "+key, ok, true)
+
+		exists, err := b.Exists(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "Exists on "+key+" before it expires", exists, true)
+	}
+
+	seed(t, b, "key-value")
+	ok, err = b.Expire(t.Context(), "key-value", 0)
+	assertNoError(t, err)
+	assertEqual(t, "Expire with a zero ttl", ok, true)
+	exists, err := b.Exists(t.Context(), "key-value")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after expiring with a zero ttl", exists, false)
+
+	mustSet(t, b, "expiring", "value")
+	mustRpush(t, b, "expiring-list", "a", "b")
+	other := mustBackend(t, newBackend, otherNamespace)
+	mustSet(t, other, "expiring", "value")
+	for _, eb := range []backend.Backend{b, other} {
+		for _, key := range []string{"expiring", "expiring-list"} {
+			if _, err := eb.Expire(t.Context(), key, 100*time.Millisecond); err != nil {
+				t.Fatalf("Expire %s: %s", key, err)
+			}
+		}
+	}
+	options.advance(t, 200*time.Millisecond)
+
+	exists, err = b.NamespaceExists(t.Context(), otherNamespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists after every key expires", exists, false)
+
+	exists, err = b.Exists(t.Context(), "expiring")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after the ttl elapses", exists, false)
+
+	_, err = b.Get(t.Context(), "expiring", "")
+	assertError(t, "Get after the ttl elapses", err, backend.ErrKeyNotFound)
+
+	_, err = b.TTL(t.Context(), "expiring")
+	assertError(t, "TTL after the ttl elapses", err, backend.ErrKeyNotFound)
+
+	keyValuePairs, err := b.GetAll(t.Context())
+	assertNoError(t, err)
+	if _, ok := keyValuePairs["expiring"]; ok {
+		t.Errorf("GetAll after the ttl elapses: got key expiring")
+	}
+
+	ok, err = b.Expire(t.Context(), "expiring", time.Hour)
+	assertNoError(t, err)
+	assertEqual(t, "Expire after the ttl elapses", ok, false)
+
+	length, err := b.Rpush(t.Context(), "expiring-list", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Rpush after the ttl elapses", length, 1)
+
+	ttl, err := b.TTL(t.Context(), "expiring-list")
+	assertNoError(t, err)
+	assertEqual(t, "TTL of a key recreated after the ttl elapses", ttl, backend.NoExpiration)
+}
+
+func testPersist(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.Persist(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Persist on a missing key", ok, false)
+
+	mustSet(t, b, "key", "value")
+	ok, err = b.Persist(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "Persist on a key without a ttl", ok, false)
+
+	if _, err := b.Expire(t.Context(), "key", time.Hour); err != nil {
+		t.Fatalf("Expire key: %s", err)
+	}
+	ok, err = b.Persist(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "Persist on a key with a ttl", ok, true)
+
+	ttl, err := b.TTL(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "TTL after Persist", ttl, backend.NoExpiration)
+
+	value, err := b.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after Persist", value, "value")
+}
+
+func testTTL(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.TTL(t.Context(), "missing")
+	assertError(t, "TTL on a missing key", err, backend.ErrKeyNotFound)
+
+	for _, key := range []string{"key-value", "list", "set"} {
+		seed(t, b, key)
+		ttl, err := b.TTL(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "TTL on "+key+" without a ttl", ttl, backend.NoExpiration)
+
+		if _, err := b.Expire(t.Context(), key, time.Hour); err != nil {
+			t.Fatalf("Expire %s: %s", key, err)
+		}
+		ttl, err = b.TTL(t.Context(), key)
+		assertNoError(t, err)
+		if ttl <= time.Hour-time.Minute || ttl > time.Hour {
+			t.Errorf("TTL on %s with a ttl: got %s, want about 1h", key, ttl)
+		}
+	}
+
+	mustRpush(t, b, "list", "c")
+	ttl, err := b.TTL(t.Context(), "list")
+	assertNoError(t, err)
+	if ttl == backend.NoExpiration {
+		t.Errorf("TTL after Rpush: the ttl was removed")
+	}
+
+	mustSet(t, b, "key-value", "other")
+	ttl, err = b.TTL(t.Context(), "key-value")
+	assertNoError(t, err)
+	assertEqual(t, "TTL after Set", ttl, backend.NoExpiration)
+
+	if _, err := b.Del(t.Context(), "set"); err != nil {
+		t.Fatalf("Del set: %s", err)
+	}
+	seed(t, b, "set")
+	ttl, err = b.TTL(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "TTL of a key recreated after Del", ttl, backend.NoExpiration)
+}
+
+func testBlpop(t *testing.T, newBackend NewBackend) {
+	testBlockingPop(t, newBackend, "Blpop", backend.Backend.Blpop, []string{"a", "b"})
+}
+
+func testBrpop(t *testing.T, newBackend NewBackend) {
+	testBlockingPop(t, newBackend, "Brpop", backend.Backend.Brpop, []string{"b", "a"})
+}
+
+// testBlockingPop verifies a blocking pop, which should return the elements
+// of a list holding a then b in the order given by want
+func testBlockingPop(t *testing.T, newBackend NewBackend, name string, pop func(backend.Backend, context.Context, string, time.Duration) (string, error), want []string) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := pop(b, t.Context(), "list", -time.Second)
+	assertError(t, name+" with a negative timeout", err, backend.ErrInvalidValue)
+
+	_, err = pop(b, t.Context(), "missing", 50*time.Millisecond)
+	assertError(t, name+" on a missing key once the timeout elapses", err, backend.ErrKeyNotFound)
+
+	mustRpush(t, b, "list", "a", "b")
+
+	for _, expected := range want {
+		element, err := pop(b, t.Context(), "list", time.Second)
+		assertNoError(t, err)
+		assertEqual(t, name+" on a non-empty list", element, expected)
+	}
+
+	exists, err := b.Exists(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after popping every element", exists, false)
+
+	// a push from another backend wakes up the blocked pop
+	producer := mustBackend(t, newBackend, namespace)
+	pushed := make(chan error, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, err := producer.Rpush(context.Background(), "list", "c")
+		pushed <- err
+	}()
+
+	element, err := pop(b, t.Context(), "list", 10*time.Second)
+	assertNoError(t, err)
+	assertEqual(t, name+" after a push", element, "c")
+	assertNoError(t, <-pushed)
+}
+
+func testLindex(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustRpush(t, b, "list", "a", "b", "c")
+
+	_, err := b.Lindex(t.Context(), "missing", 0)
+	assertError(t, "Lindex on a missing key", err, backend.ErrKeyNotFound)
+
+	tests := []struct {
+		index   int
+		want    string
+		wantErr bool
+	}{
+		{0, "a", false},
+		{1, "b", false},
+		{2, "c", false},
+		{3, "", true},
+		{-1, "c", false},
+		{-3, "a", false},
+		{-4, "", true},
+	}
+	for _, tt := range tests {
+		element, err := b.Lindex(t.Context(), "list", tt.index)
+		if tt.wantErr {
+			assertError(t, "Lindex out of range", err, backend.ErrIndexOutOfRange)
+			continue
+		}
+		assertNoError(t, err)
+		assertEqual(t, "Lindex", element, tt.want)
+	}
+}
+
+func testLinsert(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Linsert(t.Context(), "missing", backend.ListPositionBefore, "a", "x")
+	assertError(t, "Linsert on a missing key", err, backend.ErrKeyNotFound)
+
+	mustRpush(t, b, "list", "a", "b", "a")
+
+	length, err := b.Linsert(t.Context(), "list", backend.ListPositionBefore, "a", "x")
+	assertNoError(t, err)
+	assertEqual(t, "Linsert before", length, 4)
+
+	length, err = b.Linsert(t.Context(), "list", backend.ListPositionAfter, "b", "y")
+	assertNoError(t, err)
+	assertEqual(t, "Linsert after", length, 5)
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after Linsert", elements, []string{"x", "a", "b", "y", "a"})
+
+	_, err = b.Linsert(t.Context(), "list", backend.ListPositionAfter, "missing", "z")
+	assertError(t, "Linsert with a missing pivot", err, backend.ErrElementNotFound)
+
+	_, err = b.Linsert(t.Context(), "list", "beside", "a", "z")
+	assertError(t, "Linsert with an invalid position", err, backend.ErrInvalidValue)
+
+	length, err = b.Llen(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Llen after failed Linsert", length, 5)
+}
+
+func testLismember(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustRpush(t, b, "list", "a", "b", "a")
+
+	tests := []struct {
+		key     string
+		element string
+		want    bool
+	}{
+		{"list", "a", true},
+		{"list", "b", true},
+		{"list", "c", false},
+		{"list", "A", false},
+		{"missing", "a", false},
+	}
+	for _, tt := range tests {
+		isMember, err := b.Lismember(t.Context(), tt.key, tt.element)
+		assertNoError(t, err)
+		assertEqual(t, "Lismember "+tt.key+" "+tt.element, isMember, tt.want)
+	}
+}
+
+func testLlen(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	length, err := b.Llen(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Llen on a missing key", length, 0)
+
+	mustRpush(t, b, "list", "a", "b", "a")
+	length, err = b.Llen(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Llen", length, 3)
+}
+
+func testLmove(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	left, right := backend.ListDirectionLeft, backend.ListDirectionRight
+
+	_, err := b.Lmove(t.Context(), "missing", "destination", left, right)
+	assertError(t, "Lmove from a missing key", err, backend.ErrKeyNotFound)
+
+	exists, err := b.Exists(t.Context(), "destination")
+	assertNoError(t, err)
+	assertEqual(t, "Exists on the destination of a failed Lmove", exists, false)
+
+	mustRpush(t, b, "source", "a", "b", "c")
+
+	_, err = b.Lmove(t.Context(), "source", "destination", "up", right)
+	assertError(t, "Lmove with an invalid direction", err, backend.ErrInvalidValue)
+
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		element     string
+		source      []string
+		destination []string
+	}{
+		{"from the head to the tail", left, right, "a", []string{"b", "c"}, []string{"a"}},
+		{"from the tail to the tail", right, right, "c", []string{"b"}, []string{"a", "c"}},
+		{"from the head to the head", left, left, "b", []string{}, []string{"b", "a", "c"}},
+	}
+	for _, tt := range tests {
+		element, err := b.Lmove(t.Context(), "source", "destination", tt.from, tt.to)
+		assertNoError(t, err)
+		assertEqual(t, "Lmove "+tt.name, element, tt.element)
+		assertEqual(t, "Lrange on the source after Lmove "+tt.name, mustLrange(t, b, "source"), tt.source)
+		assertEqual(t, "Lrange on the destination after Lmove "+tt.name, mustLrange(t, b, "destination"), tt.destination)
+	}
+
+	exists, err = b.Exists(t.Context(), "source")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after moving every element", exists, false)
+
+	element, err := b.Lmove(t.Context(), "destination", "destination", left, right)
+	assertNoError(t, err)
+	assertEqual(t, "Lmove rotating a list", element, "b")
+	assertEqual(t, "Lrange after rotating a list", mustLrange(t, b, "destination"), []string{"a", "c", "b"})
+
+	element, err = b.Lmove(t.Context(), "destination", "destination", right, left)
+	assertNoError(t, err)
+	assertEqual(t, "Lmove rotating a list backwards", element, "b")
+	assertEqual(t, "Lrange after rotating a list backwards", mustLrange(t, b, "destination"), []string{"b", "a", "c"})
+}
+
+func testLpop(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Lpop(t.Context(), "missing", 1)
+	assertError(t, "Lpop on a missing key", err, backend.ErrKeyNotFound)
+
+	mustRpush(t, b, "list", "a", "b", "c", "d")
+
+	_, err = b.Lpop(t.Context(), "list", 0)
+	assertError(t, "Lpop with a zero count", err, backend.ErrInvalidValue)
+
+	elements, err := b.Lpop(t.Context(), "list", 1)
+	assertNoError(t, err)
+	assertEqual(t, "Lpop", elements, []string{"a"})
+
+	elements, err = b.Lpop(t.Context(), "list", 2)
+	assertNoError(t, err)
+	assertEqual(t, "Lpop with a count", elements, []string{"b", "c"})
+
+	elements, err = b.Lpop(t.Context(), "list", 5)
+	assertNoError(t, err)
+	assertEqual(t, "Lpop with a count past the end of the list", elements, []string{"d"})
+
+	exists, err := b.Exists(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after popping every element", exists, false)
+}
+
+func testLpos(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	positions, err := b.Lpos(t.Context(), "missing", "a", 1, 1)
+	assertNoError(t, err)
+	assertEqual(t, "Lpos on a missing key", positions, []int{})
+
+	mustRpush(t, b, "list", "a", "b", "c", "a", "b", "a")
+
+	tests := []struct {
+		name    string
+		element string
+		rank    int
+		count   int
+		want    []int
+	}{
+		{"first match", "b", 1, 1, []int{1}},
+		{"all matches", "a", 1, 0, []int{0, 3, 5}},
+		{"limited matches", "a", 1, 2, []int{0, 3}},
+		{"second match", "a", 2, 1, []int{3}},
+		{"matches from the tail", "a", -1, 2, []int{5, 3}},
+		{"second match from the tail", "b", -2, 0, []int{1}},
+		{"rank past the last match", "a", 4, 1, []int{}},
+		{"missing element", "z", 1, 0, []int{}},
+	}
+	for _, tt := range tests {
+		positions, err := b.Lpos(t.Context(), "list", tt.element, tt.rank, tt.count)
+		assertNoError(t, err)
+		assertEqual(t, "Lpos "+tt.name, positions, tt.want)
+	}
+
+	_, err = b.Lpos(t.Context(), "list", "a", 0, 1)
+	assertError(t, "Lpos with a zero rank", err, backend.ErrInvalidValue)
+
+	_, err = b.Lpos(t.Context(), "list", "a", 1, -1)
+	assertError(t, "Lpos with a negative count", err, backend.ErrInvalidValue)
+}
+
+func testLpush(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	length, err := b.Lpush(t.Context(), "list", "a", "b")
+	assertNoError(t, err)
+	assertEqual(t, "Lpush on a missing key", length, 2)
+
+	length, err = b.Lpush(t.Context(), "list", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Lpush on an existing key", length, 3)
+
+	mustRpush(t, b, "list", "d")
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after Lpush", elements, []string{"c", "b", "a", "d"})
+}
+
+func testLrange(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustRpush(t, b, "list", "a", "b", "c", "d", "e")
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange", elements, []string{"a", "b", "c", "d", "e"})
+
+	elements, err = b.Lrange(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange on a missing key", elements, []string{})
+
+	fromTests := []struct {
+		start int
+		want  []string
+	}{
+		{0, []string{"a", "b", "c", "d", "e"}},
+		{3, []string{"d", "e"}},
+		{5, []string{}},
+		{10, []string{}},
+		{-2, []string{"d", "e"}},
+		{-10, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, tt := range fromTests {
+		elements, err := b.Lrangefrom(t.Context(), "list", tt.start)
+		assertNoError(t, err)
+		assertEqual(t, "Lrangefrom", elements, tt.want)
+	}
+
+	fromToTests := []struct {
+		start int
+		stop  int
+		want  []string
+	}{
+		{0, 0, []string{"a"}},
+		{0, 2, []string{"a", "b", "c"}},
+		{1, -1, []string{"b", "c", "d", "e"}},
+		{-3, -2, []string{"c", "d"}},
+		{-10, 1, []string{"a", "b"}},
+		{2, 10, []string{"c", "d", "e"}},
+		{3, 1, []string{}},
+		{5, 10, []string{}},
+		{-1, -3, []string{}},
+	}
+	for _, tt := range fromToTests {
+		elements, err := b.Lrangefromto(t.Context(), "list", tt.start, tt.stop)
+		assertNoError(t, err)
+		assertEqual(t, "Lrangefromto", elements, tt.want)
+	}
+
+	elements, err = b.Lrangefromto(t.Context(), "missing", 0, -1)
+	assertNoError(t, err)
+	assertEqual(t, "Lrangefromto on a missing key", elements, []string{})
+}
+
+func testLrem(t *testing.T, newBackend NewBackend) {
+	tests := []struct {
+		name    string
+		count   int
+		element string
+		removed int
+		want    []string
+	}{
+		{"all", 0, "a", 3, []string{"b", "c"}},
+		{"from-head", 2, "a", 2, []string{"b", "c", "a"}},
+		{"from-tail", -2, "a", 2, []string{"a", "b", "c"}},
+		{"more-than-present", 10, "a", 3, []string{"b", "c"}},
+		{"more-than-present-from-tail", -10, "a", 3, []string{"b", "c"}},
+		{"missing-element", 0, "z", 0, []string{"a", "b", "a", "c", "a"}},
+	}
+	for _, tt := range tests {
+		b := mustBackend(t, newBackend, namespace)
+		mustRpush(t, b, tt.name, "a", "b", "a", "c", "a")
+
+		removed, err := b.Lrem(t.Context(), tt.name, tt.count, tt.element)
+		assertNoError(t, err)
+		assertEqual(t, "Lrem "+tt.name+" count", removed, tt.removed)
+
+		elements, err := b.Lrange(t.Context(), tt.name)
+		assertNoError(t, err)
+		assertEqual(t, "Lrem "+tt.name+" elements", elements, tt.want)
+	}
+
+	b := mustBackend(t, newBackend, namespace)
+	removed, err := b.Lrem(t.Context(), "missing", 0, "a")
+	assertNoError(t, err)
+	assertEqual(t, "Lrem on a missing key", removed, 0)
+
+	mustRpush(t, b, "emptied", "a", "a")
+	removed, err = b.Lrem(t.Context(), "emptied", 0, "a")
+	assertNoError(t, err)
+	assertEqual(t, "Lrem removing every element", removed, 2)
+
+	exists, err := b.Exists(t.Context(), "emptied")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after removing every element", exists, false)
+}
+
+func testLset(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Lset(t.Context(), "missing", 0, "a")
+	assertError(t, "Lset on a missing key", err, backend.ErrKeyNotFound)
+
+	exists, err := b.Exists(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after Lset on a missing key", exists, false)
+
+	tests := []struct {
+		index   int
+		element string
+		want    []string
+		wantErr bool
+	}{
+		{0, "x", []string{"x", "b", "c"}, false},
+		{2, "y", []string{"x", "b", "y"}, false},
+		{-2, "z", []string{"x", "z", "y"}, false},
+		{-3, " padded ", []string{" padded ", "z", "y"}, false},
+		{3, "out", []string{" padded ", "z", "y"}, true},
+		{-4, "out", []string{" padded ", "z", "y"}, true},
+	}
+	mustRpush(t, b, "list", "a", "b", "c")
+	for _, tt := range tests {
+		ok, err := b.Lset(t.Context(), "list", tt.index, tt.element)
+		if tt.wantErr {
+			assertError(t, "Lset out of range", err, backend.ErrIndexOutOfRange)
+		} else {
+			assertNoError(t, err)
+			assertEqual(t, "Lset", ok, true)
+		}
+
+		elements, err := b.Lrange(t.Context(), "list")
+		assertNoError(t, err)
+		assertEqual(t, "Lrange after Lset", elements, tt.want)
+	}
+}
+
+func testLtrim(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.Ltrim(t.Context(), "missing", 0, 1)
+	assertNoError(t, err)
+	assertEqual(t, "Ltrim on a missing key", ok, true)
+
+	tests := []struct {
+		start int
+		stop  int
+		want  []string
+	}{
+		{0, 1, []string{"a", "b"}},
+		{1, -1, []string{"b", "c", "d"}},
+		{-2, -1, []string{"c", "d"}},
+		{-10, 10, []string{"a", "b", "c", "d"}},
+		{2, 1, []string{}},
+		{5, 10, []string{}},
+	}
+	for _, tt := range tests {
+		_, err := b.Del(t.Context(), "list")
+		assertNoError(t, err)
+		mustRpush(t, b, "list", "a", "b", "c", "d")
+
+		ok, err := b.Ltrim(t.Context(), "list", tt.start, tt.stop)
+		assertNoError(t, err)
+		assertEqual(t, "Ltrim", ok, true)
+
+		elements, err := b.Lrange(t.Context(), "list")
+		assertNoError(t, err)
+		assertEqual(t, "Lrange after Ltrim", elements, tt.want)
+	}
+
+	exists, err := b.Exists(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after trimming every element", exists, false)
+}
+
+func testRpop(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Rpop(t.Context(), "missing", 1)
+	assertError(t, "Rpop on a missing key", err, backend.ErrKeyNotFound)
+
+	mustRpush(t, b, "list", "a", "b", "c", "d")
+
+	_, err = b.Rpop(t.Context(), "list", -1)
+	assertError(t, "Rpop with a negative count", err, backend.ErrInvalidValue)
+
+	elements, err := b.Rpop(t.Context(), "list", 1)
+	assertNoError(t, err)
+	assertEqual(t, "Rpop", elements, []string{"d"})
+
+	elements, err = b.Rpop(t.Context(), "list", 2)
+	assertNoError(t, err)
+	assertEqual(t, "Rpop with a count", elements, []string{"c", "b"})
+
+	elements, err = b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after Rpop", elements, []string{"a"})
+}
+
+func testRpush(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	length, err := b.Rpush(t.Context(), "list", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Rpush on a missing key", length, 1)
+
+	length, err = b.Rpush(t.Context(), "list", "b", "a", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Rpush on an existing key", length, 4)
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after Rpush", elements, []string{"a", "b", "a", "c"})
+
+	length, err = b.Rpush(t.Context(), "nested/list", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Rpush on a nested key", length, 1)
+}
+
+func testSadd(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	added, err := b.Sadd(t.Context(), "set", "a", "b", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Sadd on a missing key", added, 2)
+
+	added, err = b.Sadd(t.Context(), "set", "b", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Sadd on an existing key", added, 1)
+
+	added, err = b.Sadd(t.Context(), "set", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Sadd of an existing member", added, 0)
+
+	members, err := b.Smembers(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers after Sadd", members, map[string]bool{"a": true, "b": true, "c": true})
+}
+
+func testScard(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	count, err := b.Scard(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Scard on a missing key", count, 0)
+
+	mustSadd(t, b, "set", "a", "b", "c")
+	count, err = b.Scard(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Scard", count, 3)
+}
+
+func testSdiff(t *testing.T, newBackend NewBackend) {
+	testSetOperation(t, newBackend, "Sdiff", backend.Backend.Sdiff, []setOperationCase{
+		{"a single set", []string{"first"}, []string{"a", "b", "c"}},
+		{"two sets", []string{"first", "second"}, []string{"a"}},
+		{"three sets", []string{"first", "second", "third"}, []string{"a"}},
+		{"a missing key", []string{"first", "missing"}, []string{"a", "b", "c"}},
+		{"a missing first key", []string{"missing", "first"}, []string{}},
+	})
+}
+
+func testSdiffstore(t *testing.T, newBackend NewBackend) {
+	testSetStoreOperation(t, newBackend, "Sdiffstore", backend.Backend.Sdiffstore, []string{"first", "second"}, []string{"a"})
+}
+
+func testSinter(t *testing.T, newBackend NewBackend) {
+	testSetOperation(t, newBackend, "Sinter", backend.Backend.Sinter, []setOperationCase{
+		{"a single set", []string{"first"}, []string{"a", "b", "c"}},
+		{"two sets", []string{"first", "second"}, []string{"b", "c"}},
+		{"three sets", []string{"first", "second", "third"}, []string{"c"}},
+		{"a missing key", []string{"first", "missing"}, []string{}},
+	})
+}
+
+func testSinterstore(t *testing.T, newBackend NewBackend) {
+	testSetStoreOperation(t, newBackend, "Sinterstore", backend.Backend.Sinterstore, []string{"first", "second"}, []string{"b", "c"})
+}
+
+// setOperationCase is the expected result of combining the sets first
+// {a, b, c}, second {b, c, d} and third {c, e}
+type setOperationCase struct {
+	name string
+	keys []string
+	want []string
+}
+
+// seedSetOperation adds the sets combined by setOperationCase
+func seedSetOperation(t *testing.T, b backend.Backend) {
+	mustSadd(t, b, "first", "a", "b", "c")
+	mustSadd(t, b, "second", "b", "c", "d")
+	mustSadd(t, b, "third", "c", "e")
+}
+
+// testSetOperation verifies Sdiff, Sinter or Sunion
+func testSetOperation(t *testing.T, newBackend NewBackend, name string, op func(backend.Backend, context.Context, ...string) (map[string]bool, error), tests []setOperationCase) {
+	b := mustBackend(t, newBackend, namespace)
+	seedSetOperation(t, b)
+
+	for _, tt := range tests {
+		members, err := op(b, t.Context(), tt.keys...)
+		assertNoError(t, err)
+		assertEqual(t, name+" of "+tt.name, members, memberSet(tt.want))
+	}
+
+	members, err := op(b, t.Context())
+	assertNoError(t, err)
+	assertEqual(t, name+" without any keys", members, map[string]bool{})
+}
+
+// testSetStoreOperation verifies Sdiffstore, Sinterstore or Sunionstore,
+// which should store want when combining keys
+func testSetStoreOperation(t *testing.T, newBackend NewBackend, name string, op func(backend.Backend, context.Context, string, ...string) (int, error), keys []string, want []string) {
+	b := mustBackend(t, newBackend, namespace)
+	seedSetOperation(t, b)
+
+	// the destination is replaced along with its timeout
+	mustSet(t, b, "destination", "value")
+	_, err := b.Expire(t.Context(), "destination", time.Hour)
+	assertNoError(t, err)
+
+	count, err := op(b, t.Context(), "destination", keys...)
+	assertNoError(t, err)
+	assertEqual(t, name, count, len(want))
+
+	members, err := b.Smembers(t.Context(), "destination")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers after "+name, members, memberSet(want))
+
+	ttl, err := b.TTL(t.Context(), "destination")
+	assertNoError(t, err)
+	assertEqual(t, "TTL after "+name, ttl, backend.NoExpiration)
+
+	count, err = op(b, t.Context(), "first", append([]string{"first"}, keys[1:]...)...)
+	assertNoError(t, err)
+	assertEqual(t, name+" into one of its keys", count, len(want))
+	assertEqual(t, "Smembers after "+name+" into one of its keys", mustSmembers(t, b, "first"), memberSet(want))
+
+	count, err = op(b, t.Context(), "destination", "missing")
+	assertNoError(t, err)
+	assertEqual(t, name+" of an empty result", count, 0)
+
+	exists, err := b.Exists(t.Context(), "destination")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after "+name+" of an empty result", exists, false)
+}
+
+func testSismember(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustSadd(t, b, "set", "a", "b")
+
+	tests := []struct {
+		key    string
+		member string
+		want   bool
+	}{
+		{"set", "a", true},
+		{"set", "b", true},
+		{"set", "c", false},
+		{"missing", "a", false},
+	}
+	for _, tt := range tests {
+		isMember, err := b.Sismember(t.Context(), tt.key, tt.member)
+		assertNoError(t, err)
+		assertEqual(t, "Sismember "+tt.key+" "+tt.member, isMember, tt.want)
+	}
+}
+
+func testSmembers(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	members, err := b.Smembers(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers on a missing key", members, map[string]bool{})
+
+	mustSadd(t, b, "set", "c", "a", "b")
+	members, err = b.Smembers(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers", members, map[string]bool{"a": true, "b": true, "c": true})
+}
+
+func testSmove(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	moved, err := b.Smove(t.Context(), "missing", "destination", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Smove from a missing key", moved, false)
+
+	mustSadd(t, b, "source", "a", "b")
+	mustSadd(t, b, "destination", "b", "c")
+
+	moved, err = b.Smove(t.Context(), "source", "destination", "z")
+	assertNoError(t, err)
+	assertEqual(t, "Smove of a missing member", moved, false)
+
+	moved, err = b.Smove(t.Context(), "source", "destination", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Smove", moved, true)
+	assertEqual(t, "Smembers on the source after Smove", mustSmembers(t, b, "source"), memberSet([]string{"b"}))
+	assertEqual(t, "Smembers on the destination after Smove", mustSmembers(t, b, "destination"), memberSet([]string{"a", "b", "c"}))
+
+	moved, err = b.Smove(t.Context(), "destination", "destination", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Smove within a set", moved, true)
+	assertEqual(t, "Smembers after Smove within a set", mustSmembers(t, b, "destination"), memberSet([]string{"a", "b", "c"}))
+
+	moved, err = b.Smove(t.Context(), "source", "destination", "b")
+	assertNoError(t, err)
+	assertEqual(t, "Smove of a member of both sets", moved, true)
+	assertEqual(t, "Smembers on the destination after Smove of a member of both sets", mustSmembers(t, b, "destination"), memberSet([]string{"a", "b", "c"}))
+
+	exists, err := b.Exists(t.Context(), "source")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after moving every member", exists, false)
+
+	moved, err = b.Smove(t.Context(), "destination", "new", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Smove to a missing key", moved, true)
+	assertEqual(t, "Smembers after Smove to a missing key", mustSmembers(t, b, "new"), memberSet([]string{"c"}))
+}
+
+func testSpop(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Spop(t.Context(), "missing", 1)
+	assertError(t, "Spop on a missing key", err, backend.ErrKeyNotFound)
+
+	mustSadd(t, b, "set", "a", "b", "c")
+
+	_, err = b.Spop(t.Context(), "set", 0)
+	assertError(t, "Spop with a zero count", err, backend.ErrInvalidValue)
+
+	popped, err := b.Spop(t.Context(), "set", 1)
+	assertNoError(t, err)
+	assertEqual(t, "Spop count", len(popped), 1)
+
+	remaining := mustSmembers(t, b, "set")
+	assertEqual(t, "Smembers after Spop", len(remaining), 2)
+	if remaining[popped[0]] {
+		t.Errorf("Spop: popped member %s is still a member", popped[0])
+	}
+
+	popped, err = b.Spop(t.Context(), "set", 5)
+	assertNoError(t, err)
+	assertEqual(t, "Spop with a count past the size of the set", memberSet(popped), remaining)
+
+	exists, err := b.Exists(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after popping every member", exists, false)
+}
+
+func testSrandmember(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	members, err := b.Srandmember(t.Context(), "missing", 1)
+	assertNoError(t, err)
+	assertEqual(t, "Srandmember on a missing key", members, []string{})
+
+	all := memberSet([]string{"a", "b", "c"})
+	mustSadd(t, b, "set", "a", "b", "c")
+
+	tests := []struct {
+		name     string
+		count    int
+		length   int
+		distinct bool
+	}{
+		{"a zero count", 0, 0, true},
+		{"a count", 2, 2, true},
+		{"a count past the size of the set", 5, 3, true},
+		{"a negative count", -5, 5, false},
+	}
+	for _, tt := range tests {
+		members, err := b.Srandmember(t.Context(), "set", tt.count)
+		assertNoError(t, err)
+		assertEqual(t, "Srandmember length with "+tt.name, len(members), tt.length)
+		for _, member := range members {
+			if !all[member] {
+				t.Errorf("Srandmember with %s: %s is not a member", tt.name, member)
+			}
+		}
+		if tt.distinct {
+			assertEqual(t, "Srandmember distinct members with "+tt.name, len(memberSet(members)), tt.length)
+		}
+	}
+
+	assertEqual(t, "Smembers after Srandmember", mustSmembers(t, b, "set"), all)
+}
+
+func testSrem(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	removed, err := b.Srem(t.Context(), "missing", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Srem on a missing key", removed, 0)
+
+	mustSadd(t, b, "set", "a", "b", "c")
+	removed, err = b.Srem(t.Context(), "set", "a", "z", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Srem", removed, 1)
+
+	members, err := b.Smembers(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers after Srem", members, map[string]bool{"b": true, "c": true})
+
+	removed, err = b.Srem(t.Context(), "set", "b", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Srem removing every member", removed, 2)
+
+	exists, err := b.Exists(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after removing every member", exists, false)
+}
+
+func testSunion(t *testing.T, newBackend NewBackend) {
+	testSetOperation(t, newBackend, "Sunion", backend.Backend.Sunion, []setOperationCase{
+		{"a single set", []string{"first"}, []string{"a", "b", "c"}},
+		{"two sets", []string{"first", "second"}, []string{"a", "b", "c", "d"}},
+		{"three sets", []string{"first", "second", "third"}, []string{"a", "b", "c", "d", "e"}},
+		{"a missing key", []string{"missing", "third"}, []string{"c", "e"}},
+	})
+}
+
+func testSunionstore(t *testing.T, newBackend NewBackend) {
+	testSetStoreOperation(t, newBackend, "Sunionstore", backend.Backend.Sunionstore, []string{"first", "third"}, []string{"a", "b", "c", "e"})
+}
+
+func testHdel(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	removed, err := b.Hdel(t.Context(), "missing", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Hdel on a missing key", removed, 0)
+
+	mustHset(t, b, "hash", map[string]string{"a": "1", "b": "2", "c": "3"})
+	removed, err = b.Hdel(t.Context(), "hash", "a", "z", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Hdel", removed, 1)
+
+	fields, err := b.Hgetall(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hgetall after Hdel", fields, map[string]string{"b": "2", "c": "3"})
+
+	removed, err = b.Hdel(t.Context(), "hash", "b", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Hdel removing every field", removed, 2)
+
+	exists, err := b.Exists(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after removing every field", exists, false)
+}
+
+func testHexists(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	mustHset(t, b, "hash", map[string]string{"a": "1", "b": ""})
+
+	tests := []struct {
+		key   string
+		field string
+		want  bool
+	}{
+		{"hash", "a", true},
+		{"hash", "b", true},
+		{"hash", "c", false},
+		{"missing", "a", false},
+	}
+	for _, tt := range tests {
+		exists, err := b.Hexists(t.Context(), tt.key, tt.field)
+		assertNoError(t, err)
+		assertEqual(t, "Hexists "+tt.key+" "+tt.field, exists, tt.want)
+	}
+}
+
+func testHget(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Hget(t.Context(), "missing", "a")
+	assertError(t, "Hget on a missing key", err, backend.ErrKeyNotFound)
+
+	mustHset(t, b, "hash", map[string]string{"a": "1", "b": "two words"})
+	value, err := b.Hget(t.Context(), "hash", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Hget", value, "1")
+
+	value, err = b.Hget(t.Context(), "hash", "b")
+	assertNoError(t, err)
+	assertEqual(t, "Hget of a value with spaces", value, "two words")
+
+	_, err = b.Hget(t.Context(), "hash", "c")
+	assertError(t, "Hget on a missing field", err, backend.ErrFieldNotFound)
+}
+
+func testHgetall(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	fields, err := b.Hgetall(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Hgetall on a missing key", fields, map[string]string{})
+
+	mustHset(t, b, "hash", map[string]string{"c": "3", "a": "1", "b": ""})
+	fields, err = b.Hgetall(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hgetall", fields, map[string]string{"a": "1", "b": "", "c": "3"})
+}
+
+func testHkeys(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	fields, err := b.Hkeys(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Hkeys on a missing key", fields, []string{})
+
+	mustHset(t, b, "hash", map[string]string{"c": "3", "a": "1", "b": "2"})
+	fields, err = b.Hkeys(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hkeys", fields, []string{"a", "b", "c"})
+}
+
+func testHlen(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	length, err := b.Hlen(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Hlen on a missing key", length, 0)
+
+	mustHset(t, b, "hash", map[string]string{"a": "1", "b": "2"})
+	length, err = b.Hlen(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hlen", length, 2)
+}
+
+func testHset(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	added, err := b.Hset(t.Context(), "hash", map[string]string{"a": "1", "b": "2"})
+	assertNoError(t, err)
+	assertEqual(t, "Hset on a missing key", added, 2)
+
+	added, err = b.Hset(t.Context(), "hash", map[string]string{"b": "3", "c": "4"})
+	assertNoError(t, err)
+	assertEqual(t, "Hset on an existing key", added, 1)
+
+	added, err = b.Hset(t.Context(), "hash", map[string]string{"a": "5"})
+	assertNoError(t, err)
+	assertEqual(t, "Hset of an existing field", added, 0)
+
+	fields, err := b.Hgetall(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hgetall after Hset", fields, map[string]string{"a": "5", "b": "3", "c": "4"})
+
+	added, err = b.Hset(t.Context(), "nested/hash", map[string]string{"a": "1"})
+	assertNoError(t, err)
+	assertEqual(t, "Hset on a nested key", added, 1)
+}
+
+func testZadd(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	added, err := b.Zadd(t.Context(), "sorted-set", map[string]float64{"a": 1, "b": 2})
+	assertNoError(t, err)
+	assertEqual(t, "Zadd on a missing key", added, 2)
+
+	added, err = b.Zadd(t.Context(), "sorted-set", map[string]float64{"b": 0.5, "c": -1})
+	assertNoError(t, err)
+	assertEqual(t, "Zadd on an existing key", added, 1)
+
+	added, err = b.Zadd(t.Context(), "sorted-set", map[string]float64{"a": 3})
+	assertNoError(t, err)
+	assertEqual(t, "Zadd of an existing member", added, 0)
+
+	members, err := b.Zrange(t.Context(), "sorted-set", 0, -1)
+	assertNoError(t, err)
+	assertEqual(t, "Zrange after Zadd", members, []backend.SortedSetMember{
+		{Member: "c", Score: -1},
+		{Member: "b", Score: 0.5},
+		{Member: "a", Score: 3},
+	})
+
+	added, err = b.Zadd(t.Context(), "nested/sorted-set", map[string]float64{"a": 1})
+	assertNoError(t, err)
+	assertEqual(t, "Zadd on a nested key", added, 1)
+}
+
+func testZcard(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	length, err := b.Zcard(t.Context(), "missing")
+	assertNoError(t, err)
+	assertEqual(t, "Zcard on a missing key", length, 0)
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"a": 1, "b": 1, "c": 2})
+	length, err = b.Zcard(t.Context(), "sorted-set")
+	assertNoError(t, err)
+	assertEqual(t, "Zcard", length, 3)
+}
+
+func testZrange(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	members, err := b.Zrange(t.Context(), "missing", 0, -1)
+	assertNoError(t, err)
+	assertEqual(t, "Zrange on a missing key", members, []backend.SortedSetMember{})
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"d": 3, "b": 1, "a": 1, "c": 2.5})
+	tests := []struct {
+		start int
+		stop  int
+		want  []string
+	}{
+		{0, -1, []string{"a", "b", "c", "d"}},
+		{1, 2, []string{"b", "c"}},
+		{-2, -1, []string{"c", "d"}},
+		{2, 100, []string{"c", "d"}},
+		{-100, 0, []string{"a"}},
+		{3, 1, []string{}},
+		{4, -1, []string{}},
+	}
+	for _, tt := range tests {
+		members, err := b.Zrange(t.Context(), "sorted-set", tt.start, tt.stop)
+		assertNoError(t, err)
+		assertEqual(t, "Zrange", sortedSetMemberNames(members), tt.want)
+	}
+
+	members, err = b.Zrange(t.Context(), "sorted-set", 2, 2)
+	assertNoError(t, err)
+	assertEqual(t, "Zrange returns scores", members, []backend.SortedSetMember{{Member: "c", Score: 2.5}})
+}
+
+func testZrangebyscore(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	members, err := b.Zrangebyscore(t.Context(), "missing", 0, 1)
+	assertNoError(t, err)
+	assertEqual(t, "Zrangebyscore on a missing key", members, []backend.SortedSetMember{})
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"d": 3, "b": 1, "a": 1, "c": 2.5, "e": -2})
+	tests := []struct {
+		min  float64
+		max  float64
+		want []string
+	}{
+		{math.Inf(-1), math.Inf(1), []string{"e", "a", "b", "c", "d"}},
+		{1, 2.5, []string{"a", "b", "c"}},
+		{1.5, 2, []string{}},
+		{-5, 0, []string{"e"}},
+		{3, 1, []string{}},
+	}
+	for _, tt := range tests {
+		members, err := b.Zrangebyscore(t.Context(), "sorted-set", tt.min, tt.max)
+		assertNoError(t, err)
+		assertEqual(t, "Zrangebyscore", sortedSetMemberNames(members), tt.want)
+	}
+}
+
+func testZrank(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Zrank(t.Context(), "missing", "a")
+	assertError(t, "Zrank on a missing key", err, backend.ErrKeyNotFound)
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"c": 2, "b": 1, "a": 1})
+	for want, member := range []string{"a", "b", "c"} {
+		rank, err := b.Zrank(t.Context(), "sorted-set", member)
+		assertNoError(t, err)
+		assertEqual(t, "Zrank "+member, rank, want)
+	}
+
+	_, err = b.Zrank(t.Context(), "sorted-set", "z")
+	assertError(t, "Zrank on a missing member", err, backend.ErrMemberNotFound)
+}
+
+func testZrem(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	removed, err := b.Zrem(t.Context(), "missing", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Zrem on a missing key", removed, 0)
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"a": 1, "b": 2, "c": 3})
+	removed, err = b.Zrem(t.Context(), "sorted-set", "a", "z", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Zrem", removed, 1)
+
+	members, err := b.Zrange(t.Context(), "sorted-set", 0, -1)
+	assertNoError(t, err)
+	assertEqual(t, "Zrange after Zrem", sortedSetMemberNames(members), []string{"b", "c"})
+
+	removed, err = b.Zrem(t.Context(), "sorted-set", "b", "c")
+	assertNoError(t, err)
+	assertEqual(t, "Zrem removing every member", removed, 2)
+
+	exists, err := b.Exists(t.Context(), "sorted-set")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after removing every member", exists, false)
+}
+
+func testZscore(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	_, err := b.Zscore(t.Context(), "missing", "a")
+	assertError(t, "Zscore on a missing key", err, backend.ErrKeyNotFound)
+
+	mustZadd(t, b, "sorted-set", map[string]float64{"a": 1.25, "b": -3})
+	score, err := b.Zscore(t.Context(), "sorted-set", "a")
+	assertNoError(t, err)
+	assertEqual(t, "Zscore", score, 1.25)
+
+	score, err = b.Zscore(t.Context(), "sorted-set", "b")
+	assertNoError(t, err)
+	assertEqual(t, "Zscore of a negative score", score, float64(-3))
+
+	_, err = b.Zscore(t.Context(), "sorted-set", "z")
+	assertError(t, "Zscore on a missing member", err, backend.ErrMemberNotFound)
+}
+
+func testNamespaceExists(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	exists, err := b.NamespaceExists(t.Context(), namespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists on an empty namespace", exists, false)
+
+	mustSet(t, mustBackend(t, newBackend, otherNamespace), "nested/key", "value")
+	exists, err = b.NamespaceExists(t.Context(), otherNamespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists on another namespace", exists, true)
+
+	exists, err = b.NamespaceExists(t.Context(), namespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists after writing to another namespace", exists, false)
+
+	seed(t, b, "list")
+	exists, err = b.NamespaceExists(t.Context(), namespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists", exists, true)
+}
+
+func testNamespaceClear(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	other := mustBackend(t, newBackend, otherNamespace)
+
+	ok, err := b.NamespaceClear(t.Context(), otherNamespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceClear on an empty namespace", ok, true)
+
+	seed(t, b, "key-value")
+	seed(t, b, "list")
+	seed(t, b, "set")
+	mustSet(t, b, "nested/key", "value")
+	mustSet(t, other, "key", "value")
+
+	ok, err = b.NamespaceClear(t.Context(), namespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceClear", ok, true)
+
+	exists, err := b.NamespaceExists(t.Context(), namespace)
+	assertNoError(t, err)
+	assertEqual(t, "NamespaceExists after NamespaceClear", exists, false)
+
+	for _, key := range []string{"key-value", "list", "set", "nested/key"} {
+		exists, err := b.Exists(t.Context(), key)
+		assertNoError(t, err)
+		assertEqual(t, "Exists after NamespaceClear on "+key, exists, false)
+	}
+
+	exists, err = other.Exists(t.Context(), "key")
+	assertNoError(t, err)
+	assertEqual(t, "Exists in another namespace after NamespaceClear", exists, true)
+}
+
+func testBackendReset(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	ok, err := b.BackendReset(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "BackendReset on an empty backend", ok, true)
+
+	seed(t, b, "key-value")
+	seed(t, b, "list")
+	seed(t, b, "set")
+	mustSet(t, mustBackend(t, newBackend, otherNamespace), "key", "value")
+
+	ok, err = b.BackendReset(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "BackendReset", ok, true)
+
+	for _, ns := range []string{namespace, otherNamespace} {
+		exists, err := b.NamespaceExists(t.Context(), ns)
+		assertNoError(t, err)
+		assertEqual(t, "NamespaceExists after BackendReset on "+ns, exists, false)
+	}
+}
+
+func testBackendImport(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	other := mustBackend(t, newBackend, otherNamespace)
+	mustSet(t, b, "kept", "value")
+	mustSet(t, b, "replaced", "old")
+
+	ok, err := b.BackendImport(t.Context(), fixture(), false)
+	assertNoError(t, err)
+	assertEqual(t, "BackendImport", ok, true)
+	assertFixture(t, b, other)
+
+	value, err := b.Get(t.Context(), "kept", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get on a key kept by a merging BackendImport", value, "value")
+
+	ok, err = b.BackendImport(t.Context(), fixture(), true)
+	assertNoError(t, err)
+	assertEqual(t, "BackendImport with clear", ok, true)
+	assertFixture(t, b, other)
+
+	exists, err := b.Exists(t.Context(), "kept")
+	assertNoError(t, err)
+	assertEqual(t, "Exists after a clearing BackendImport", exists, false)
+
+	decoded := backend.PropertyCollection{Properties: []backend.Property{
+		{DataType: backend.DataTypeList, Namespace: namespace, Key: "decoded", Value: []interface{}{"a", "b"}},
+	}}
+	ok, err = b.BackendImport(t.Context(), decoded, false)
+	assertNoError(t, err)
+	assertEqual(t, "BackendImport of json decoded values", ok, true)
+
+	elements, err := b.Lrange(t.Context(), "decoded")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after BackendImport of json decoded values", elements, []string{"a", "b"})
+
+	invalid := backend.PropertyCollection{Properties: []backend.Property{
+		{DataType: "invalid", Namespace: namespace, Key: "invalid", Value: "value"},
+	}}
+	_, err = b.BackendImport(t.Context(), invalid, false)
+	assertError(t, "BackendImport of an invalid data type", err, nil)
+}
+
+func testWatch(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	other := mustBackend(t, newBackend, otherNamespace)
+	w, ok := b.(backend.WatchableBackend)
+	if !ok || !backend.SupportsWatch(b) {
+		t.Skip("backend does not support watching")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	events, err := w.Watch(ctx, "watched")
+	assertNoError(t, err)
+
+	mustSet(t, b, "watched-key", "value")
+	assertEvent(t, events, backend.Event{Type: backend.EventChanged, Namespace: namespace, Key: "watched-key"})
+
+	mustSet(t, b, "unwatched", "value")
+	mustSet(t, other, "watched-key", "value")
+	mustRpush(t, b, "watched-list", "a")
+	assertEvent(t, events, backend.Event{Type: backend.EventChanged, Namespace: namespace, Key: "watched-list"})
+
+	mustSet(t, b, "watched-key", "new")
+	assertEvent(t, events, backend.Event{Type: backend.EventChanged, Namespace: namespace, Key: "watched-key"})
+
+	_, err = b.Del(t.Context(), "watched-key")
+	assertNoError(t, err)
+	assertEvent(t, events, backend.Event{Type: backend.EventDeleted, Namespace: namespace, Key: "watched-key"})
+
+	cancel()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, open := <-events:
+			if !open {
+				return
+			}
+		case <-deadline:
+			t.Errorf("Watch channel not closed after the context is done")
+			return
+		}
+	}
+}
+
+func testBackendExport(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+
+	p, err := b.BackendExport(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "BackendExport of an empty backend", len(p.Properties), 0)
+
+	_, err = b.BackendImport(t.Context(), fixture(), false)
+	assertNoError(t, err)
+
+	p, err = b.BackendExport(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "BackendExport", normalize(p), normalize(fixture()))
+
+	restored := mustBackend(t, newBackend, namespace)
+	_, err = restored.BackendImport(t.Context(), p, true)
+	assertNoError(t, err)
+	assertFixture(t, restored, mustBackend(t, newBackend, otherNamespace))
+}
+
+func testWrongType(t *testing.T, newBackend NewBackend) {
+	b := mustBackend(t, newBackend, namespace)
+	seed(t, b, "key-value")
+	seed(t, b, "list")
+	seed(t, b, "set")
+	seed(t, b, "hash")
+	seed(t, b, "sorted-set")
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Get on a list", func() error { _, err := b.Get(t.Context(), "list", ""); return err }},
+		{"Get on a set", func() error { _, err := b.Get(t.Context(), "set", "default"); return err }},
+		{"Lindex on a key-value", func() error { _, err := b.Lindex(t.Context(), "key-value", 0); return err }},
+		{"Lismember on a set", func() error { _, err := b.Lismember(t.Context(), "set", "a"); return err }},
+		{"Llen on a key-value", func() error { _, err := b.Llen(t.Context(), "key-value"); return err }},
+		{"Lrange on a set", func() error { _, err := b.Lrange(t.Context(), "set"); return err }},
+		{"Lrangefrom on a set", func() error { _, err := b.Lrangefrom(t.Context(), "set", 0); return err }},
+		{"Lrangefromto on a set", func() error { _, err := b.Lrangefromto(t.Context(), "set", 0, -1); return err }},
+		{"Lrem on a key-value", func() error { _, err := b.Lrem(t.Context(), "key-value", 0, "a"); return err }},
+		{"Lset on a set", func() error { _, err := b.Lset(t.Context(), "set", 0, "a"); return err }},
+		{"Rpush on a key-value", func() error { _, err := b.Rpush(t.Context(), "key-value", "a"); return err }},
+		{"Rpush on a set", func() error { _, err := b.Rpush(t.Context(), "set", "a"); return err }},
+		{"Sadd on a list", func() error { _, err := b.Sadd(t.Context(), "list", "a"); return err }},
+		{"Sismember on a list", func() error { _, err := b.Sismember(t.Context(), "list", "a"); return err }},
+		{"Smembers on a key-value", func() error { _, err := b.Smembers(t.Context(), "key-value"); return err }},
+		{"Srem on a list", func() error { _, err := b.Srem(t.Context(), "list", "a"); return err }},
+		{"SetIfValue on a list", func() error { _, err := b.SetIfValue(t.Context(), "list", "a", "b", 0); return err }},
+		{"GetWithRevision on a list", func() error { _, _, err := b.GetWithRevision(t.Context(), "list"); return err }},
+		{"SetIfRevision on a set", func() error { _, err := b.SetIfRevision(t.Context(), "set", 0, "a", 0); return err }},
+		{"GetSet on a set", func() error { _, err := b.GetSet(t.Context(), "set", "a"); return err }},
+		{"Get on a hash", func() error { _, err := b.Get(t.Context(), "hash", ""); return err }},
+		{"Lrange on a hash", func() error { _, err := b.Lrange(t.Context(), "hash"); return err }},
+		{"Smembers on a hash", func() error { _, err := b.Smembers(t.Context(), "hash"); return err }},
+		{"Hdel on a set", func() error { _, err := b.Hdel(t.Context(), "set", "a"); return err }},
+		{"Hexists on a list", func() error { _, err := b.Hexists(t.Context(), "list", "a"); return err }},
+		{"Hget on a key-value", func() error { _, err := b.Hget(t.Context(), "key-value", "a"); return err }},
+		{"Hgetall on a list", func() error { _, err := b.Hgetall(t.Context(), "list"); return err }},
+		{"Hkeys on a set", func() error { _, err := b.Hkeys(t.Context(), "set"); return err }},
+		{"Hlen on a key-value", func() error { _, err := b.Hlen(t.Context(), "key-value"); return err }},
+		{"Hset on a list", func() error { _, err := b.Hset(t.Context(), "list", map[string]string{"a": "1"}); return err }},
+		{"Hgetall on a sorted set", func() error { _, err := b.Hgetall(t.Context(), "sorted-set"); return err }},
+		{"Smembers on a sorted set", func() error { _, err := b.Smembers(t.Context(), "sorted-set"); return err }},
+		{"Zadd on a set", func() error { _, err := b.Zadd(t.Context(), "set", map[string]float64{"a": 1}); return err }},
+		{"Zcard on a hash", func() error { _, err := b.Zcard(t.Context(), "hash"); return err }},
+		{"Zrange on a list", func() error { _, err := b.Zrange(t.Context(), "list", 0, -1); return err }},
+		{"Zrangebyscore on a key-value", func() error { _, err := b.Zrangebyscore(t.Context(), "key-value", 0, 1); return err }},
+		{"Zrank on a set", func() error { _, err := b.Zrank(t.Context(), "set", "a"); return err }},
+		{"Zrem on a hash", func() error { _, err := b.Zrem(t.Context(), "hash", "a"); return err }},
+		{"Zscore on a list", func() error { _, err := b.Zscore(t.Context(), "list", "a"); return err }},
+		{"Linsert on a set", func() error {
+			_, err := b.Linsert(t.Context(), "set", backend.ListPositionBefore, "a", "x")
+			return err
+		}},
+		{"Lpop on a key-value", func() error { _, err := b.Lpop(t.Context(), "key-value", 1); return err }},
+		{"Lpos on a hash", func() error { _, err := b.Lpos(t.Context(), "hash", "a", 1, 0); return err }},
+		{"Lpush on a set", func() error { _, err := b.Lpush(t.Context(), "set", "a"); return err }},
+		{"Ltrim on a sorted set", func() error { _, err := b.Ltrim(t.Context(), "sorted-set", 0, 0); return err }},
+		{"Rpop on a set", func() error { _, err := b.Rpop(t.Context(), "set", 1); return err }},
+		{"Blpop on a key-value", func() error { _, err := b.Blpop(t.Context(), "key-value", time.Second); return err }},
+		{"Brpop on a hash", func() error { _, err := b.Brpop(t.Context(), "hash", time.Second); return err }},
+		{"Lmove from a set", func() error {
+			_, err := b.Lmove(t.Context(), "set", "list", backend.ListDirectionLeft, backend.ListDirectionRight)
+			return err
+		}},
+		{"Lmove to a key-value", func() error {
+			_, err := b.Lmove(t.Context(), "list", "key-value", backend.ListDirectionLeft, backend.ListDirectionRight)
+			return err
+		}},
+		{"Scard on a list", func() error { _, err := b.Scard(t.Context(), "list"); return err }},
+		{"Sdiff with a hash", func() error { _, err := b.Sdiff(t.Context(), "set", "hash"); return err }},
+		{"Sinter with a key-value", func() error { _, err := b.Sinter(t.Context(), "key-value", "set"); return err }},
+		{"Sunion with a sorted set", func() error { _, err := b.Sunion(t.Context(), "set", "sorted-set"); return err }},
+		{"Sinterstore from a list", func() error { _, err := b.Sinterstore(t.Context(), "stored", "set", "list"); return err }},
+		{"Smove from a list", func() error { _, err := b.Smove(t.Context(), "list", "set", "a"); return err }},
+		{"Smove to a key-value", func() error { _, err := b.Smove(t.Context(), "set", "key-value", "a"); return err }},
+		{"Spop on a hash", func() error { _, err := b.Spop(t.Context(), "hash", 1); return err }},
+		{"Srandmember on a list", func() error { _, err := b.Srandmember(t.Context(), "list", 1); return err }},
+		{"Incr on a list", func() error { _, err := b.Incr(t.Context(), "list"); return err }},
+		{"IncrBy on a hash", func() error { _, err := b.IncrBy(t.Context(), "hash", 2); return err }},
+		{"Decr on a set", func() error { _, err := b.Decr(t.Context(), "set"); return err }},
+	}
+	for _, tt := range tests {
+		assertError(t, tt.name, tt.fn(), backend.ErrWrongType)
+	}
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after failed writes", elements, []string{"a", "b"})
+
+	keyValuePairs, err := b.GetAll(t.Context())
+	assertNoError(t, err)
+	assertEqual(t, "GetAll skips other data types", keyValuePairs, map[string]string{"key-value": "value"})
+
+	ok, err := b.Set(t.Context(), "list", "value")
+	assertNoError(t, err)
+	assertEqual(t, "Set overwrites a list", ok, true)
+
+	value, err := b.Get(t.Context(), "list", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after Set overwrites a list", value, "value")
+}
+
+// fixture returns a property collection spanning every data type and two namespaces
+func fixture() backend.PropertyCollection {
+	return backend.PropertyCollection{Properties: []backend.Property{
+		{DataType: backend.DataTypeKeyValue, Namespace: namespace, Key: "replaced", Value: "new"},
+		{DataType: backend.DataTypeList, Namespace: namespace, Key: "list", Value: []string{"b", "a", "b"}},
+		{DataType: backend.DataTypeSet, Namespace: namespace, Key: "set", Value: []string{"a", "b"}},
+		{DataType: backend.DataTypeHash, Namespace: namespace, Key: "hash", Value: map[string]string{"a": "1", "b": "2"}},
+		{DataType: backend.DataTypeSortedSet, Namespace: namespace, Key: "sorted-set", Value: map[string]float64{"a": 2, "b": 1.5}},
+		{DataType: backend.DataTypeKeyValue, Namespace: namespace, Key: "nested/key", Value: "nested"},
+		{DataType: backend.DataTypeKeyValue, Namespace: otherNamespace, Key: "key", Value: "other"},
+	}}
+}
+
+// assertFixture verifies that the fixture was imported into both namespaces
+func assertFixture(t *testing.T, b backend.Backend, other backend.Backend) {
+	t.Helper()
+
+	value, err := b.Get(t.Context(), "replaced", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get after BackendImport", value, "new")
+
+	value, err = b.Get(t.Context(), "nested/key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get on a nested key after BackendImport", value, "nested")
+
+	elements, err := b.Lrange(t.Context(), "list")
+	assertNoError(t, err)
+	assertEqual(t, "Lrange after BackendImport", elements, []string{"b", "a", "b"})
+
+	members, err := b.Smembers(t.Context(), "set")
+	assertNoError(t, err)
+	assertEqual(t, "Smembers after BackendImport", members, map[string]bool{"a": true, "b": true})
+
+	fields, err := b.Hgetall(t.Context(), "hash")
+	assertNoError(t, err)
+	assertEqual(t, "Hgetall after BackendImport", fields, map[string]string{"a": "1", "b": "2"})
+
+	sortedSetMembers, err := b.Zrange(t.Context(), "sorted-set", 0, -1)
+	assertNoError(t, err)
+	assertEqual(t, "Zrange after BackendImport", sortedSetMembers, []backend.SortedSetMember{{Member: "b", Score: 1.5}, {Member: "a", Score: 2}})
+
+	value, err = other.Get(t.Context(), "key", "")
+	assertNoError(t, err)
+	assertEqual(t, "Get in another namespace after BackendImport", value, "other")
+}
+
+// normalize sorts properties and set members so collections can be compared
+func normalize(p backend.PropertyCollection) []backend.Property {
+	properties := []backend.Property{}
+	for _, property := range p.Properties {
+		if property.DataType == backend.DataTypeHash {
+			property.Value, _ = property.HashValue()
+		} else if property.DataType == backend.DataTypeSortedSet {
+			property.Value, _ = property.SortedSetValue()
+		} else if property.DataType != backend.DataTypeKeyValue {
+			elements, _ := property.ListValue()
+			if property.DataType == backend.DataTypeSet {
+				sort.Strings(elements)
+			}
+			property.Value = elements
+		}
+		properties = append(properties, property)
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		if properties[i].Namespace != properties[j].Namespace {
+			return properties[i].Namespace < properties[j].Namespace
+		}
+		return properties[i].Key < properties[j].Key
+	})
+	return properties
+}
+
+// seed populates a key named after its data type
+func seed(t *testing.T, b backend.Backend, key string) {
+	t.Helper()
+
+	switch key {
+	case "key-value":
+		mustSet(t, b, key, "value")
+	case "list":
+		mustRpush(t, b, key, "a", "b")
+	case "set":
+		mustSadd(t, b, key, "a", "b")
+	case "hash":
+		mustHset(t, b, key, map[string]string{"a": "1", "b": "2"})
+	case "sorted-set":
+		mustZadd(t, b, key, map[string]float64{"a": 1, "b": 2})
+	default:
+		t.Fatalf("unknown seed key %s", key)
+	}
+}
+
+func mustBackend(t *testing.T, newBackend NewBackend, namespace string) backend.Backend {
+	t.Helper()
+
+	b, err := newBackend(namespace)
+	if err != nil {
+		t.Fatalf("unable to construct backend for namespace %s: %s", namespace, err)
+	}
+	return b
+}
+
+func mustSet(t *testing.T, b backend.Backend, key string, value string) {
+	t.Helper()
+
+	if _, err := b.Set(t.Context(), key, value); err != nil {
+		t.Fatalf("Set %s: %s", key, err)
+	}
+}
+
+func mustRpush(t *testing.T, b backend.Backend, key string, elements ...string) {
+	t.Helper()
+
+	if _, err := b.Rpush(t.Context(), key, elements...); err != nil {
+		t.Fatalf("Rpush %s: %s", key, err)
+	}
+}
+
+func mustLrange(t *testing.T, b backend.Backend, key string) []string {
+	t.Helper()
+
+	elements, err := b.Lrange(t.Context(), key)
+	if err != nil {
+		t.Fatalf("Lrange %s: %s", key, err)
+	}
+	return elements
+}
+
+func mustSadd(t *testing.T, b backend.Backend, key string, members ...string) {
+	t.Helper()
+
+	if _, err := b.Sadd(t.Context(), key, members...); err != nil {
+		t.Fatalf("Sadd %s: %s", key, err)
+	}
+}
+
+func mustSmembers(t *testing.T, b backend.Backend, key string) map[string]bool {
+	t.Helper()
+
+	members, err := b.Smembers(t.Context(), key)
+	if err != nil {
+		t.Fatalf("Smembers %s: %s", key, err)
+	}
+	return members
+}
+
+// memberSet returns the set holding each of members
+func memberSet(members []string) map[string]bool {
+	set := map[string]bool{}
+	for _, member := range members {
+		set[member] = true
+	}
+	return set
+}
+
+func mustHset(t *testing.T, b backend.Backend, key string, fields map[string]string) {
+	t.Helper()
+
+	if _, err := b.Hset(t.Context(), key, fields); err != nil {
+		t.Fatalf("Hset %s: %s", key, err)
+	}
+}
+
+func mustZadd(t *testing.T, b backend.Backend, key string, scores map[string]float64) {
+	t.Helper()
+
+	if _, err := b.Zadd(t.Context(), key, scores); err != nil {
+		t.Fatalf("Zadd %s: %s", key, err)
+	}
+}
+
+// sortedSetMemberNames returns the members of a sorted set range in order
+func sortedSetMemberNames(members []backend.SortedSetMember) []string {
+	names := []string{}
+	for _, member := range members {
+		names = append(names, member.Member)
+	}
+	return names
+}
+
+// assertEvent verifies that the next event received from a watch is expected
+func assertEvent(t *testing.T, events <-chan backend.Event, expected backend.Event) {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("Watch channel closed while waiting for %v", expected)
+		}
+		assertEqual(t, "Watch event", event, expected)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Watch did not send %v", expected)
+	}
+}
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// assertError verifies that err matches target, or is any error if target is nil
+func assertError(t *testing.T, name string, err error, target error) {
+	t.Helper()
+
+	if err == nil {
+		t.Errorf("%s: expected an error", name)
+		return
+	}
+
+	if target != nil && !errors.Is(err, target) {
+		t.Errorf("%s: got error %q, want %q", name, err, target)
+	}
+}
+
+func assertEqual(t *testing.T, name string, got interface{}, want interface{}) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %#v, want %#v", name, got, want)
+	}
+}
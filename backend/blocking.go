@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// blockingPollInterval is how often a blocking pop is retried by backends
+// without change notifications
+const blockingPollInterval = 100 * time.Millisecond
+
+// keyNotifier starts waiting for changes to a key, returning a channel that
+// is closed when the key may have changed and a function that stops waiting
+type keyNotifier func(key string) (<-chan struct{}, func(), error)
+
+// blockingPop calls pop until it returns an element, retrying whenever notify
+// reports that the key may have changed. A zero timeout waits until ctx is
+// done, while an ErrKeyNotFound error is returned if the list is still empty
+// once the timeout elapses.
+func blockingPop(ctx context.Context, namespace string, key string, timeout time.Duration, notify keyNotifier, pop func() (string, error)) (string, error) {
+	if err := validateBlockingTimeout(namespace, key, timeout); err != nil {
+		return "", err
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		// waiting starts before the attempt, so that an element pushed
+		// between the attempt and the wait is not missed
+		changed, stop, err := notify(key)
+		if err != nil {
+			return "", err
+		}
+
+		element, err := pop()
+		if !errors.Is(err, ErrKeyNotFound) {
+			stop()
+			return element, err
+		}
+
+		select {
+		case <-changed:
+			stop()
+		case <-expired:
+			stop()
+			return "", newKeyError(namespace, key, ErrKeyNotFound)
+		case <-ctx.Done():
+			stop()
+			return "", ctx.Err()
+		}
+	}
+}
+
+// pollNotifier reports that a key may have changed once every
+// blockingPollInterval
+func pollNotifier(key string) (<-chan struct{}, func(), error) {
+	changed := make(chan struct{})
+	timer := time.AfterFunc(blockingPollInterval, func() { close(changed) })
+	return changed, func() { timer.Stop() }, nil
+}
+
+// validateBlockingTimeout returns an ErrInvalidValue error if timeout is negative
+func validateBlockingTimeout(namespace string, key string, timeout time.Duration) error {
+	if timeout < 0 {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// firstElement returns the only element popped by a pop of a single element
+func firstElement(elements []string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	return elements[0], nil
+}
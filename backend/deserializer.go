@@ -1,10 +1,6 @@
 package backend
 
-import (
-	"fmt"
-)
-
 func DeserializePropertyCollection(filename string) (PropertyCollection, error) {
 	var properties PropertyCollection
-	return properties, fmt.Errorf("Not implemented")
+	return properties, ErrNotImplemented
 }
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrKeyNotFound is returned when reading a key that does not exist
+	ErrKeyNotFound = errors.New("Key does not exist in namespace")
+
+	// ErrWrongType is returned when operating on a key holding another data type
+	ErrWrongType = errors.New("Operation against a key holding the wrong kind of value")
+
+	// ErrIndexOutOfRange is returned when accessing a list element past either end of the list
+	ErrIndexOutOfRange = errors.New("Index out of range")
+
+	// ErrNotImplemented is returned for operations a backend does not support
+	ErrNotImplemented = errors.New("Not implemented")
+
+	// ErrInvalidKey is returned for keys that do not match the key specification
+	ErrInvalidKey = errors.New("Invalid key")
+
+	// ErrInvalidNamespace is returned for namespaces that do not match the
+	// namespace specification
+	ErrInvalidNamespace = errors.New("Invalid namespace")
+
+	// ErrInvalidValue is returned for values that are not valid utf8 or exceed
+	// the maximum value length
+	ErrInvalidValue = errors.New("Invalid value")
+
+	// ErrLockTimeout is returned when a lock held by another process is not
+	// released within the lock timeout
+	ErrLockTimeout = errors.New("Timed out waiting for lock")
+
+	// ErrConditionFailed is returned when the condition of a conditional
+	// write does not hold, and the key is left unchanged
+	ErrConditionFailed = errors.New("Condition not met for key")
+
+	// ErrFieldNotFound is returned when reading a field that does not exist
+	// in a hash
+	ErrFieldNotFound = errors.New("Field does not exist in hash")
+
+	// ErrNotInteger is returned when incrementing a key holding a value that
+	// is not an integer, or when the result would overflow
+	ErrNotInteger = errors.New("Value is not an integer or out of range")
+
+	// ErrMemberNotFound is returned when reading the score or rank of a
+	// member that does not exist in a sorted set
+	ErrMemberNotFound = errors.New("Member does not exist in sorted set")
+
+	// ErrElementNotFound is returned when inserting relative to a pivot
+	// element that does not exist in a list
+	ErrElementNotFound = errors.New("Element does not exist in list")
+
+	// ErrRevisionNotFound is returned when rolling back a key to a revision
+	// that is not in its history
+	ErrRevisionNotFound = errors.New("Revision does not exist for key")
+)
+
+// KeyError records the key an error occurred for. It wraps one of the
+// sentinel errors, so callers may match it with errors.Is. Key is empty for
+// errors that apply to the whole namespace.
+type KeyError struct {
+	Namespace string
+	Key       string
+	Err       error
+}
+
+func (e *KeyError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s", e.Err.Error(), e.Namespace)
+	}
+
+	return fmt.Sprintf("%s: %s.%s", e.Err.Error(), e.Namespace, e.Key)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// newKeyError returns a KeyError wrapping err for a key in a namespace
+func newKeyError(namespace string, key string, err error) error {
+	return &KeyError{Namespace: namespace, Key: key, Err: err}
+}
@@ -2,52 +2,224 @@ package backend
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xo/dburl"
 )
 
+func init() {
+	Register("file", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		if u.Transport != "" {
+			return nil, unknownSchemeError(u)
+		}
+
+		return NewUnstructuredFileBackend(namespace, u)
+	})
+}
+
+const (
+	// FileDurabilityNone renames written keys into place without syncing
+	// them, which survives process crashes but not power loss
+	FileDurabilityNone = "none"
+
+	// FileDurabilityFile syncs each key to disk before renaming it into place
+	FileDurabilityFile = "file"
+
+	// FileDurabilityFull additionally syncs the directory holding each key
+	// after renaming it into place, and is the default
+	FileDurabilityFull = "full"
+)
+
+// tempFilePrefix is prepended to the name of temporary files written next to
+// a key. As keys may not contain dots, temporary files never shadow a key.
+const tempFilePrefix = ".tmp-"
+
+// expiryFilePrefix is prepended to the name of the file written next to a key
+// recording the time at which the key expires
+const expiryFilePrefix = ".ttl-"
+
 type UnstructuredFileBackend struct {
+	Root          string
 	NamespaceRoot string
 	Namespace     string
 	SystemUser    string
 	SystemGroup   string
+	Durability    string
+	LockTimeout   time.Duration
 }
 
 // NewUnstructuredFileBackend create new instance of UnstructuredFileBackend
 func NewUnstructuredFileBackend(namespace string, url *dburl.URL) (UnstructuredFileBackend, error) {
+	if err := validateNamespaceName(namespace); err != nil {
+		return UnstructuredFileBackend{}, err
+	}
+
 	systemUser := url.Query().Get("system-user")
 	systemGroup := url.Query().Get("system-group")
+	durability := url.Query().Get("durability")
+	switch durability {
+	case "":
+		durability = FileDurabilityFull
+	case FileDurabilityNone, FileDurabilityFile, FileDurabilityFull:
+	default:
+		return UnstructuredFileBackend{}, fmt.Errorf("Invalid durability %s, expected one of %s, %s or %s", durability, FileDurabilityNone, FileDurabilityFile, FileDurabilityFull)
+	}
+
+	lockTimeout := defaultLockTimeout
+	if value := url.Query().Get("lock-timeout"); value != "" {
+		var err error
+		lockTimeout, err = time.ParseDuration(value)
+		if err != nil || lockTimeout < 0 {
+			return UnstructuredFileBackend{}, fmt.Errorf("Invalid lock-timeout %s, expected a duration such as 10s", value)
+		}
+	}
+
 	backend := UnstructuredFileBackend{}
+	backend.Root = url.Opaque
 	backend.NamespaceRoot = path.Join(url.Opaque, namespace)
 	backend.Namespace = namespace
 	backend.SystemUser = systemUser
 	backend.SystemGroup = systemGroup
+	backend.Durability = durability
+	backend.LockTimeout = lockTimeout
 	return backend, nil
 }
 
-func (backend UnstructuredFileBackend) BackendExport() (PropertyCollection, error) {
-	return PropertyCollection{}, fmt.Errorf("Not implemented")
+// BackendExport is not supported, as the data type of each key is not recorded
+func (backend UnstructuredFileBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	return PropertyCollection{}, ErrNotImplemented
 }
 
-func (backend UnstructuredFileBackend) BackendImport(p PropertyCollection, clear bool) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnstructuredFileBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	if clear {
+		if _, err := backend.BackendReset(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	ctx, unlock, err := backend.lockNamespaces(ctx, propertyNamespaces(p)...)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for _, property := range p.Properties {
+		b := backend.withNamespace(property.Namespace)
+		if _, err := b.Del(ctx, property.Key); err != nil {
+			return false, err
+		}
+
+		switch property.DataType {
+		case DataTypeKeyValue:
+			value, err := property.StringValue()
+			if err != nil {
+				return false, err
+			}
+			if _, err := b.Set(ctx, property.Key, value); err != nil {
+				return false, err
+			}
+		case DataTypeList:
+			elements, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeList(ctx, property.Key, elements); err != nil {
+				return false, err
+			}
+		case DataTypeSet:
+			members, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			memberMap := make(map[string]bool)
+			for _, member := range members {
+				memberMap[member] = true
+			}
+			if err := b.writeSet(ctx, property.Key, memberMap); err != nil {
+				return false, err
+			}
+		case DataTypeHash:
+			fields, err := property.HashValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeHash(ctx, property.Key, fields); err != nil {
+				return false, err
+			}
+		case DataTypeSortedSet:
+			scores, err := property.SortedSetValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeSortedSet(ctx, property.Key, scores); err != nil {
+				return false, err
+			}
+		default:
+			return false, fmt.Errorf("Invalid data type for property %s.%s: %s", property.Namespace, property.Key, property.DataType)
+		}
+	}
+
+	return true, nil
 }
 
-func (backend UnstructuredFileBackend) BackendReset() (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnstructuredFileBackend) BackendReset(ctx context.Context) (bool, error) {
+	files, err := ioutil.ReadDir(backend.Root)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), lockFilePrefix) {
+			continue
+		}
+
+		if file.IsDir() && validateNamespaceName(file.Name()) == nil {
+			if _, err := backend.NamespaceClear(ctx, file.Name()); err != nil {
+				return false, fmt.Errorf("Unable to reset backend: %s", err.Error())
+			}
+			continue
+		}
+
+		if err := os.RemoveAll(path.Join(backend.Root, file.Name())); err != nil {
+			return false, fmt.Errorf("Unable to reset backend: %s", err.Error())
+		}
+	}
+
+	return true, nil
 }
 
-func (backend UnstructuredFileBackend) Del(key string) (bool, error) {
-	keyPath := backend.getKeyPath(key)
+func (backend UnstructuredFileBackend) Del(ctx context.Context, key string) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return false, err
+	}
+
+	if err := backend.removeExpiry(key); err != nil {
+		return false, fmt.Errorf("Unable to remove key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
 	if err := os.Remove(keyPath); err != nil {
-		if exists, _ := backend.Exists(key); !exists {
+		if exists, _ := backend.Exists(ctx, key); !exists {
 			return true, nil
 		}
 
@@ -57,34 +229,76 @@ func (backend UnstructuredFileBackend) Del(key string) (bool, error) {
 	return true, nil
 }
 
-func (backend UnstructuredFileBackend) Exists(key string) (bool, error) {
-	keyPath := backend.getKeyPath(key)
-	_, err := os.Stat(keyPath)
+func (backend UnstructuredFileBackend) Exists(ctx context.Context, key string) (bool, error) {
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(keyPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	expired, err := backend.expired(key)
 	if err != nil {
 		return false, err
 	}
 
-	return !os.IsNotExist(err), nil
+	return !expired, nil
 }
 
-func (backend UnstructuredFileBackend) NamespaceExists(namespace string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnstructuredFileBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	if err := validateNamespaceName(namespace); err != nil {
+		return false, err
+	}
+
+	keys, err := backend.withNamespace(namespace).keys()
+	if err != nil {
+		return false, err
+	}
+
+	return len(keys) > 0, nil
 }
 
-func (backend UnstructuredFileBackend) NamespaceClear(namespace string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnstructuredFileBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	if err := validateNamespaceName(namespace); err != nil {
+		return false, err
+	}
+
+	ctx, unlock, err := backend.lockNamespaces(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if err := os.RemoveAll(backend.withNamespace(namespace).NamespaceRoot); err != nil {
+		return false, fmt.Errorf("Unable to clear namespace %s: %s", namespace, err.Error())
+	}
+
+	return true, nil
 }
 
-func (backend UnstructuredFileBackend) Get(key string, defaultValue string) (string, error) {
-	if exists, _ := backend.Exists(key); !exists {
+func (backend UnstructuredFileBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
 		if defaultValue != "" {
 			return defaultValue, nil
 		}
 
-		return "", fmt.Errorf("Key does not exist in namespace")
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return "", err
 	}
 
-	keyPath := backend.getKeyPath(key)
 	b, err := ioutil.ReadFile(keyPath)
 	if err != nil {
 		return "", fmt.Errorf("Unable to read key %s.%s", backend.Namespace, key)
@@ -93,26 +307,35 @@ func (backend UnstructuredFileBackend) Get(key string, defaultValue string) (str
 	return string(b), nil
 }
 
-func (backend UnstructuredFileBackend) GetAll() (map[string]string, error) {
+func (backend UnstructuredFileBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return "", 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	b, revision, err := backend.readKeyFile(key)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(b), revision, nil
+}
+
+func (backend UnstructuredFileBackend) GetAll(ctx context.Context) (map[string]string, error) {
 	keyValuePairs := make(map[string]string)
-	files, err := ioutil.ReadDir(backend.NamespaceRoot)
+	keys, err := backend.keys()
 	if err != nil {
 		return keyValuePairs, err
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		key := file.Name()
-		keyValuePairs[key], _ = backend.Get(key, "")
+	for _, key := range keys {
+		keyValuePairs[key], _ = backend.Get(ctx, key, "")
 	}
 
 	return keyValuePairs, nil
 }
 
-func (backend UnstructuredFileBackend) GetAllByPrefix(prefix string) (map[string]string, error) {
-	keyValuePairs, err := backend.GetAll()
+func (backend UnstructuredFileBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keyValuePairs, err := backend.GetAll(ctx)
 	if err != nil {
 		return map[string]string{}, err
 	}
@@ -127,386 +350,1468 @@ func (backend UnstructuredFileBackend) GetAllByPrefix(prefix string) (map[string
 	return response, nil
 }
 
-func (backend UnstructuredFileBackend) Set(key string, value string) (bool, error) {
-	if err := backend.touchKey(key); err != nil {
+func (backend UnstructuredFileBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return backend.SetWithTTL(ctx, key, value, 0)
+}
+
+func (backend UnstructuredFileBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
 		return false, err
 	}
+	defer unlock()
 
-	keyPath := backend.getKeyPath(key)
-	file, err := os.Create(keyPath)
+	err = backend.writeWithExpiry(key, ttl, func() error {
+		return backend.writeKeyFile(key, []byte(value))
+	})
 	if err != nil {
-		return false, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+		return false, err
 	}
-	defer file.Close()
-
-	fmt.Fprintf(file, value)
-	file.Chmod(0600)
-	backend.setPermissions(keyPath, 0600)
 
 	return true, nil
 }
 
-func (backend UnstructuredFileBackend) Lindex(key string, index int) (string, error) {
-	lines, err := backend.Lrange(key)
+func (backend UnstructuredFileBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
-		return "", err
-	}
-
-	for i, line := range lines {
-		if i == index {
-			return line, nil
-		}
+		return false, err
 	}
+	defer unlock()
 
-	return "", fmt.Errorf("Index not found in key: %s.%s", backend.Namespace, key)
-}
-
-func (backend UnstructuredFileBackend) Lismember(key string, element string) (bool, error) {
-	lines, err := backend.Lrange(key)
+	exists, err := backend.Exists(ctx, key)
 	if err != nil {
 		return false, err
 	}
-
-	for _, line := range lines {
-		if line == element {
-			return true, nil
-		}
+	if exists {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
 	}
 
-	return false, fmt.Errorf("Value not found in list: %s.%s", backend.Namespace, key)
+	return backend.SetWithTTL(ctx, key, value, ttl)
 }
 
-func (backend UnstructuredFileBackend) Llen(key string) (int, error) {
-	elements, err := backend.Lrange(key)
+func (backend UnstructuredFileBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
+	defer unlock()
 
-	return len(elements), nil
-}
-
-func (backend UnstructuredFileBackend) Lrange(key string) ([]string, error) {
-	if exists, _ := backend.Exists(key); !exists {
-		return []string{}, fmt.Errorf("Key does not exist in namespace")
+	previous, err := backend.Get(ctx, key, "")
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
 	}
-
-	keyPath := backend.getKeyPath(key)
-	file, err := os.Open(keyPath)
 	if err != nil {
-		return []string{}, err
-	}
-	defer file.Close()
-
-	var values []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		values = append(values, scanner.Text())
+		return false, err
 	}
-
-	if err = scanner.Err(); err != nil {
-		return values, fmt.Errorf("Unable to read config value for %s.%s: %s", backend.Namespace, key, err.Error())
+	if previous != oldValue {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
 	}
 
-	return values, nil
+	return backend.SetWithTTL(ctx, key, value, ttl)
 }
 
-func (backend UnstructuredFileBackend) Lrangefrom(key string, start int) ([]string, error) {
-	elements, err := backend.Lrange(key)
+func (backend UnstructuredFileBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
-		return []string{}, err
+		return false, err
 	}
+	defer unlock()
 
-	var values []string
-	if start > len(elements) {
-		return values, nil
+	_, current, err := backend.GetWithRevision(ctx, key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return false, err
 	}
-
-	for i, element := range elements {
-		if i >= start {
-			values = append(values, element)
-		}
+	if current != revision {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
 	}
 
-	return values, nil
+	return backend.SetWithTTL(ctx, key, value, ttl)
 }
 
-func (backend UnstructuredFileBackend) Lrangefromto(key string, start int, stop int) ([]string, error) {
-	elements, err := backend.Lrange(key)
+func (backend UnstructuredFileBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
-		return []string{}, err
+		return "", err
 	}
+	defer unlock()
 
-	var values []string
-	if start > len(elements) {
-		return values, nil
+	previous, err := backend.Get(ctx, key, "")
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return "", err
 	}
 
-	for i, element := range elements {
-		if i >= start && i <= stop {
-			values = append(values, element)
-		}
+	if _, err := backend.Set(ctx, key, value); err != nil {
+		return "", err
 	}
 
-	return values, nil
+	return previous, nil
+}
+
+func (backend UnstructuredFileBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, 1)
 }
 
-func (backend UnstructuredFileBackend) Lrem(key string, countToRemove int, element string) (int, error) {
-	elements, err := backend.Lrange(key)
+// IncrBy holds the lock on the key while reading and writing its value, and
+// keeps any expiry of the key
+func (backend UnstructuredFileBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
 		return 0, err
 	}
+	defer unlock()
 
-	var newElements []string
-	removed := 0
-	if countToRemove == 0 {
-		for _, e := range elements {
-			if e == element {
-				removed++
-				continue
-			}
-			newElements = append(newElements, e)
-		}
-	} else {
-		if countToRemove < 0 {
-			reverse(elements)
-		}
-		for _, e := range elements {
-			if e == element {
-				if removed != countToRemove {
-					removed++
-					continue
-				}
-			}
-			newElements = append(newElements, e)
-		}
+	current, err := backend.Get(ctx, key, "")
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return 0, err
+	}
 
-		if countToRemove < 0 {
-			reverse(elements)
-		}
+	value, err := incrementValue(backend.Namespace, key, strings.TrimSpace(current), err == nil, increment)
+	if err != nil {
+		return 0, err
 	}
 
-	if err = backend.writeList(key, newElements); err != nil {
+	if err := backend.writeKeyFile(key, []byte(strconv.FormatInt(value, 10))); err != nil {
 		return 0, err
 	}
 
-	return removed, nil
+	return value, nil
 }
 
-func (backend UnstructuredFileBackend) Lset(key string, index int, element string) (bool, error) {
-	if err := backend.touchKey(key); err != nil {
-		return false, err
-	}
+func (backend UnstructuredFileBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, -1)
+}
 
-	elements, err := backend.Lrange(key)
+func (backend UnstructuredFileBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
 		return false, err
 	}
+	defer unlock()
 
-	absIndex := index
-	if index < 0 {
-		absIndex = -index - 1
+	if exists, err := backend.Exists(ctx, key); err != nil || !exists {
+		return false, err
 	}
 
-	if absIndex >= len(elements) {
-		return false, fmt.Errorf("Index out of range")
+	if ttl <= 0 {
+		return backend.Del(ctx, key)
 	}
 
-	var newElements []string
-	element = strings.TrimSpace(element)
-	if index < 0 {
-		reverse(elements)
+	if err := backend.writeExpiry(key, ttl); err != nil {
+		return false, err
 	}
 
-	for i, line := range elements {
-		if i == absIndex {
-			newElements = append(newElements, element)
-		} else {
-			newElements = append(newElements, line)
-		}
+	return true, nil
+}
+
+func (backend UnstructuredFileBackend) Persist(ctx context.Context, key string) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
 	}
+	defer unlock()
 
-	if index < 0 {
-		reverse(newElements)
+	if exists, err := backend.Exists(ctx, key); err != nil || !exists {
+		return false, err
 	}
 
-	if err = backend.writeList(key, newElements); err != nil {
+	expiresAt, err := backend.expiresAt(key)
+	if err != nil || expiresAt.IsZero() {
 		return false, err
 	}
 
+	if err := backend.removeExpiry(key); err != nil {
+		return false, fmt.Errorf("Unable to persist key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
 	return true, nil
 }
 
-func (backend UnstructuredFileBackend) Rpush(key string, newElements ...string) (int, error) {
-	if err := backend.touchKey(key); err != nil {
+func (backend UnstructuredFileBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if exists, err := backend.Exists(ctx, key); err != nil || !exists {
+		if err == nil {
+			err = newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
 		return 0, err
 	}
 
-	elements, err := backend.Lrange(key)
+	expiresAt, err := backend.expiresAt(key)
 	if err != nil {
 		return 0, err
 	}
+	if expiresAt.IsZero() {
+		return NoExpiration, nil
+	}
 
-	elements = append(elements, newElements...)
+	return time.Until(expiresAt), nil
+}
 
-	if err = backend.writeList(key, elements); err != nil {
-		return 0, err
-	}
+func (backend UnstructuredFileBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, backend.notifyKey, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, false))
+	})
+}
 
-	return len(elements), nil
+func (backend UnstructuredFileBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, backend.notifyKey, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, true))
+	})
 }
 
-func (backend UnstructuredFileBackend) Sadd(key string, newMembers ...string) (int, error) {
-	if err := backend.touchKey(key); err != nil {
-		return 0, err
+func (backend UnstructuredFileBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
 	}
 
-	members, err := backend.Smembers(key)
+	lines, err := backend.Lrange(ctx, key)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	addedCount := 0
-	for _, member := range newMembers {
-		if _, ok := members[member]; !ok {
-			members[member] = true
-			addedCount++
-		}
+	if index < 0 {
+		index = len(lines) + index
 	}
-
-	if err = backend.writeSet(key, members); err != nil {
-		return 0, err
+	if index < 0 || index >= len(lines) {
+		return "", newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
 	}
 
-	return addedCount, nil
+	return lines[index], nil
 }
 
-func (backend UnstructuredFileBackend) Sismember(key string, member string) (bool, error) {
-	if exists, _ := backend.Exists(key); !exists {
-		return false, fmt.Errorf("Set does not exist: %s.%s", backend.Namespace, key)
+func (backend UnstructuredFileBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := validateListPosition(backend.Namespace, key, position); err != nil {
+		return 0, err
 	}
 
-	members, err := backend.Smembers(key)
+	ctx, unlock, err := backend.lockKey(ctx, key)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
+	defer unlock()
 
-	_, ok := members[member]
-	return ok, nil
-}
-
-func (backend UnstructuredFileBackend) Smembers(key string) (map[string]bool, error) {
-	members := make(map[string]bool)
-	if exists, _ := backend.Exists(key); !exists {
-		return members, fmt.Errorf("Key does not exist in namespace")
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
 	}
 
-	keyPath := backend.getKeyPath(key)
-	file, err := os.Open(keyPath)
+	elements, err := backend.Lrange(ctx, key)
 	if err != nil {
-		return members, err
+		return 0, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		members[scanner.Text()] = true
+	elements, err = insertElement(backend.Namespace, key, elements, position, pivot, element)
+	if err != nil {
+		return 0, err
 	}
 
-	if err = scanner.Err(); err != nil {
-		return members, fmt.Errorf("Unable to read config value for %s.%s: %s", backend.Namespace, key, err.Error())
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
 	}
 
-	return members, nil
+	return len(elements), nil
 }
 
-func (backend UnstructuredFileBackend) Srem(key string, membersToRemove ...string) (int, error) {
-	if exists, _ := backend.Exists(key); !exists {
-		return 0, fmt.Errorf("Key does not exist in namespace")
-	}
-
-	members, err := backend.Smembers(key)
+func (backend UnstructuredFileBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	lines, err := backend.Lrange(ctx, key)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 
-	removedCount := 0
-	for _, member := range membersToRemove {
-		if _, ok := members[member]; ok {
-			delete(members, member)
-			removedCount++
+	for _, line := range lines {
+		if line == element {
+			return true, nil
 		}
 	}
-	if err = backend.writeSet(key, members); err != nil {
-		return 0, err
-	}
 
-	return removedCount, nil
+	return false, nil
 }
 
-func (backend UnstructuredFileBackend) getKeyPath(key string) string {
-	return path.Join(backend.NamespaceRoot, key)
+func (backend UnstructuredFileBackend) Llen(ctx context.Context, key string) (int, error) {
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
 }
 
-// propertyTouch ensures a given application property file exists
-func (backend UnstructuredFileBackend) touchKey(key string) error {
-	if exists, _ := backend.Exists(key); exists {
-		return nil
+func (backend UnstructuredFileBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := validateListDirections(backend.Namespace, source, from, to); err != nil {
+		return "", err
 	}
 
-	if err := backend.makeNamespaceDirectory(); err != nil {
-		return fmt.Errorf("Unable to create config directory for %s: %s", backend.Namespace, err.Error())
+	ctx, unlock, err := backend.lockKeys(ctx, source, destination)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if exists, _ := backend.Exists(ctx, source); !exists {
+		return "", newKeyError(backend.Namespace, source, ErrKeyNotFound)
+	}
+
+	sourceElements, err := backend.Lrange(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
+	destinationElements, err := backend.Lrange(ctx, destination)
+	if err != nil {
+		return "", err
+	}
+
+	element, sourceElements, destinationElements := moveElement(sourceElements, destinationElements, source == destination, from, to)
+	if source != destination {
+		if err := backend.writeList(ctx, source, sourceElements); err != nil {
+			return "", err
+		}
+	}
+
+	if err := backend.writeList(ctx, destination, destinationElements); err != nil {
+		return "", err
+	}
+
+	return element, nil
+}
+
+func (backend UnstructuredFileBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, false)
+}
+
+func (backend UnstructuredFileBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := validateListPositionOptions(backend.Namespace, key, rank, count); err != nil {
+		return []int{}, err
+	}
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return []int{}, err
+	}
+
+	return listPositions(elements, element, rank, count), nil
+}
+
+func (backend UnstructuredFileBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	elements = prependElements(elements, newElements)
+
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend UnstructuredFileBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	values := []string{}
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return values, nil
+	}
+
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return values, err
+	}
+
+	file, err := os.Open(keyPath)
+	if err != nil {
+		return values, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		values = append(values, scanner.Text())
+	}
+
+	if err = scanner.Err(); err != nil {
+		return values, fmt.Errorf("Unable to read config value for %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return values, nil
+}
+
+func (backend UnstructuredFileBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, start, -1)
+}
+
+func (backend UnstructuredFileBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	offset, limit, ok := normalizeListRange(len(elements), start, stop)
+	if !ok {
+		return []string{}, nil
+	}
+
+	return elements[offset : offset+limit], nil
+}
+
+func (backend UnstructuredFileBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if countToRemove < 0 {
+		reverse(elements)
+	}
+
+	newElements := []string{}
+	removed := 0
+	for _, e := range elements {
+		if e == element && (countToRemove == 0 || removed < abs(countToRemove)) {
+			removed++
+			continue
+		}
+		newElements = append(newElements, e)
+	}
+
+	if countToRemove < 0 {
+		reverse(newElements)
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeList(ctx, key, newElements); err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func (backend UnstructuredFileBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return false, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	if index < 0 {
+		index = len(elements) + index
+	}
+	if index < 0 || index >= len(elements) {
+		return false, newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+
+	elements[index] = element
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend UnstructuredFileBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	offset, limit, ok := normalizeListRange(len(elements), start, stop)
+	if !ok {
+		offset, limit = 0, 0
+	}
+
+	if err = backend.writeList(ctx, key, elements[offset:offset+limit]); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend UnstructuredFileBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, true)
+}
+
+func (backend UnstructuredFileBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	elements = append(elements, newElements...)
+
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend UnstructuredFileBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for _, member := range newMembers {
+		if _, ok := members[member]; !ok {
+			members[member] = true
+			addedCount++
+		}
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Scard(ctx context.Context, key string) (int, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(members), nil
+}
+
+func (backend UnstructuredFileBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, diffMembers)
+}
+
+func (backend UnstructuredFileBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, diffMembers)
+}
+
+func (backend UnstructuredFileBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, interMembers)
+}
+
+func (backend UnstructuredFileBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, interMembers)
+}
+
+func (backend UnstructuredFileBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := members[member]
+	return ok, nil
+}
+
+func (backend UnstructuredFileBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	members := make(map[string]bool)
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return members, nil
+	}
+
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return members, err
+	}
+
+	file, err := os.Open(keyPath)
+	if err != nil {
+		return members, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		members[scanner.Text()] = true
+	}
+
+	if err = scanner.Err(); err != nil {
+		return members, fmt.Errorf("Unable to read config value for %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return members, nil
+}
+
+func (backend UnstructuredFileBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	ctx, unlock, err := backend.lockKeys(ctx, source, destination)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	sourceMembers, err := backend.Smembers(ctx, source)
+	if err != nil {
+		return false, err
+	}
+
+	destinationMembers, err := backend.Smembers(ctx, destination)
+	if err != nil {
+		return false, err
+	}
+
+	if !sourceMembers[member] {
+		return false, nil
+	}
+	if source == destination {
+		return true, nil
+	}
+
+	delete(sourceMembers, member)
+	if err := backend.writeSet(ctx, source, sourceMembers); err != nil {
+		return false, err
+	}
+
+	destinationMembers[member] = true
+	if err := backend.writeSet(ctx, destination, destinationMembers); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend UnstructuredFileBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+	defer unlock()
+
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	popped := randomMembers(members, count)
+	for _, member := range popped {
+		delete(members, member)
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return []string{}, err
+	}
+
+	return popped, nil
+}
+
+func (backend UnstructuredFileBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return randomMembers(members, count), nil
+}
+
+func (backend UnstructuredFileBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if _, ok := members[member]; ok {
+			delete(members, member)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, unionMembers)
+}
+
+func (backend UnstructuredFileBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, unionMembers)
+}
+
+func (backend UnstructuredFileBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, field := range fieldsToRemove {
+		if _, ok := fields[field]; ok {
+			delete(fields, field)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeHash(ctx, key, fields); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := fields[field]
+	return ok, nil
+}
+
+func (backend UnstructuredFileBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", newKeyError(backend.Namespace, key, ErrFieldNotFound)
+	}
+
+	return value, nil
+}
+
+// Hgetall reads each line of the file holding a key as a field=value pair
+func (backend UnstructuredFileBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	lines, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	return parseHashLines(lines), nil
+}
+
+func (backend UnstructuredFileBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return sortedFields(fields), nil
+}
+
+func (backend UnstructuredFileBackend) Hlen(ctx context.Context, key string) (int, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(fields), nil
+}
+
+func (backend UnstructuredFileBackend) Hset(ctx context.Context, key string, newFields map[string]string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for field, value := range newFields {
+		if _, ok := fields[field]; !ok {
+			addedCount++
+		}
+		fields[field] = value
+	}
+
+	if len(newFields) == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeHash(ctx, key, fields); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Zadd(ctx context.Context, key string, newScores map[string]float64) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for member, score := range newScores {
+		if _, ok := scores[member]; !ok {
+			addedCount++
+		}
+		scores[member] = score
+	}
+
+	if len(newScores) == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSortedSet(ctx, key, scores); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Zcard(ctx context.Context, key string) (int, error) {
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(scores), nil
+}
+
+func (backend UnstructuredFileBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByRank(sortedScores(scores), start, stop), nil
+}
+
+func (backend UnstructuredFileBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByScore(sortedScores(scores), min, max), nil
+}
+
+func (backend UnstructuredFileBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
 	}
 
-	keyPath := backend.getKeyPath(key)
-	file, err := os.Create(keyPath)
+	scores, err := backend.scores(ctx, key)
 	if err != nil {
-		return fmt.Errorf("Unable to writeconfig value %s.%s: %s", backend.Namespace, key, err.Error())
+		return 0, err
+	}
+
+	rank, ok := rankOf(sortedScores(scores), member)
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return rank, nil
+}
+
+func (backend UnstructuredFileBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if _, ok := scores[member]; ok {
+			delete(scores, member)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSortedSet(ctx, key, scores); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend UnstructuredFileBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	scores, err := backend.scores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := scores[member]
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return score, nil
+}
+
+// Watch polls the namespace directory for changes to keys starting with
+// prefix, comparing the modification time and size of each key file
+func (backend UnstructuredFileBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	return pollWatch(ctx, backend.Namespace, func(ctx context.Context) (map[string]string, error) {
+		keys, err := backend.keys()
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot := map[string]string{}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			info, err := os.Stat(path.Join(backend.NamespaceRoot, key))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			snapshot[key] = fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+		}
+
+		return snapshot, nil
+	})
+}
+
+// getKeyPath returns the path of the file holding a key, rejecting keys that
+// could resolve to a path outside of the namespace directory
+func (backend UnstructuredFileBackend) getKeyPath(key string) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return path.Join(backend.NamespaceRoot, key), nil
+}
+
+// withNamespace returns a copy of the backend bound to another namespace
+func (backend UnstructuredFileBackend) withNamespace(namespace string) UnstructuredFileBackend {
+	backend.Namespace = namespace
+	backend.NamespaceRoot = path.Join(backend.Root, namespace)
+	return backend
+}
+
+// inNamespace returns a copy of the backend bound to another namespace
+func (backend UnstructuredFileBackend) inNamespace(namespace string) Backend {
+	return backend.withNamespace(namespace)
+}
+
+// keys returns the name of every unexpired key in the namespace, including
+// keys nested in subdirectories
+func (backend UnstructuredFileBackend) keys() ([]string, error) {
+	keys := []string{}
+	err := filepath.WalkDir(backend.NamespaceRoot, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		// temporary and lock files are prefixed with a dot, which keys may
+		// not contain
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		key, err := filepath.Rel(backend.NamespaceRoot, filePath)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+
+		if expired, err := backend.expired(key); err != nil || expired {
+			return err
+		}
+
+		keys = append(keys, key)
+		return nil
+	})
+
+	return keys, err
+}
+
+// readKeyFile returns the contents of the file holding a key along with its
+// revision, which is the modification time of the file in nanoseconds. Both
+// are read from the same open file, which writes replace rather than modify.
+func (backend UnstructuredFileBackend) readKeyFile(key string) ([]byte, int64, error) {
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, err := os.Open(keyPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to read key %s.%s", backend.Namespace, key)
 	}
 	defer file.Close()
 
-	return nil
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to read key %s.%s", backend.Namespace, key)
+	}
+
+	b, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to read key %s.%s", backend.Namespace, key)
+	}
+
+	return b, info.ModTime().UnixNano(), nil
 }
 
-func (backend UnstructuredFileBackend) writeList(key string, elements []string) error {
-	keyPath := backend.getKeyPath(key)
-	file, err := os.OpenFile(keyPath, os.O_RDWR|os.O_TRUNC, 0600)
+// writeKeyFile atomically replaces the file holding a key
+func (backend UnstructuredFileBackend) writeKeyFile(key string, contents []byte) error {
+	keyPath, err := backend.getKeyPath(key)
 	if err != nil {
 		return err
 	}
 
-	w := bufio.NewWriter(file)
-	for _, element := range elements {
-		fmt.Fprintln(w, element)
+	return backend.writeFile(key, keyPath, contents)
+}
+
+// writeFile atomically replaces a file belonging to a key. The contents are
+// written to a temporary file in the same directory, which is renamed over
+// the file once synced as required by the durability level, so that readers
+// and crashes never observe a partially written file.
+func (backend UnstructuredFileBackend) writeFile(key string, filePath string, contents []byte) error {
+	if err := backend.makeNamespaceDirectory(); err != nil {
+		return fmt.Errorf("Unable to create config directory for %s: %s", backend.Namespace, err.Error())
+	}
+
+	fileDirectory := path.Dir(filePath)
+	if err := os.MkdirAll(fileDirectory, 0755); err != nil {
+		return fmt.Errorf("Unable to create config directory for %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	file, err := os.CreateTemp(fileDirectory, tempFilePrefix+path.Base(filePath)+"-*")
+	if err != nil {
+		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	tempPath := file.Name()
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tempPath)
+		}
+	}()
+
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	if backend.Durability != FileDurabilityNone {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return fmt.Errorf("Unable to sync config value %s.%s: %s", backend.Namespace, key, err.Error())
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	// the modification time serves as the revision of a key, so it is kept
+	// later than that of the file being replaced, by at least a microsecond
+	// for filesystems that record times with less precision
+	modTime := time.Now()
+	if info, err := os.Stat(filePath); err == nil && modTime.Sub(info.ModTime()) < time.Microsecond {
+		modTime = info.ModTime().Add(time.Microsecond)
+	}
+	if err := os.Chtimes(tempPath, modTime, modTime); err != nil {
+		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
 	}
-	if err = w.Flush(); err != nil {
+
+	backend.setPermissions(tempPath, 0600)
+	if err := os.Rename(tempPath, filePath); err != nil {
 		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
 	}
+	renamed = true
+
+	if backend.Durability == FileDurabilityFull {
+		if err := syncDirectory(fileDirectory); err != nil {
+			return fmt.Errorf("Unable to sync config directory for %s.%s: %s", backend.Namespace, key, err.Error())
+		}
+	}
 
-	file.Chmod(0600)
-	backend.setPermissions(keyPath, 0600)
 	return nil
 }
 
-func (backend UnstructuredFileBackend) writeSet(key string, members map[string]bool) error {
-	keyPath := backend.getKeyPath(key)
-	file, err := os.OpenFile(keyPath, os.O_RDWR|os.O_TRUNC, 0600)
+// getExpiryPath returns the path of the file recording when a key expires
+func (backend UnstructuredFileBackend) getExpiryPath(key string) (string, error) {
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(path.Dir(keyPath), expiryFilePrefix+path.Base(keyPath)), nil
+}
+
+// expiresAt returns the time at which a key expires, or the zero time if the
+// key does not expire
+func (backend UnstructuredFileBackend) expiresAt(key string) (time.Time, error) {
+	expiryPath, err := backend.getExpiryPath(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	b, err := os.ReadFile(expiryPath)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to read expiry of key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(b)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid expiry for key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return expiresAt, nil
+}
+
+// expired returns true if a key has an expiry that has passed. Expired keys
+// are treated as missing, and are removed when next modified.
+func (backend UnstructuredFileBackend) expired(key string) (bool, error) {
+	expiresAt, err := backend.expiresAt(key)
+	if err != nil {
+		return false, err
+	}
+
+	return !expiresAt.IsZero() && !time.Now().Before(expiresAt), nil
+}
+
+// writeExpiry records that a key expires once ttl elapses
+func (backend UnstructuredFileBackend) writeExpiry(key string, ttl time.Duration) error {
+	expiryPath, err := backend.getExpiryPath(key)
 	if err != nil {
 		return err
 	}
 
-	w := bufio.NewWriter(file)
-	for member := range members {
-		fmt.Fprintln(w, member)
+	expiresAt := time.Now().Add(ttl).UTC().Format(time.RFC3339Nano) + "\n"
+	return backend.writeFile(key, expiryPath, []byte(expiresAt))
+}
+
+// writeWithExpiry runs write, which replaces the value of a key, and makes
+// the key expire once ttl elapses, or never if ttl is not positive. A new
+// expiry is written before the value, so that the value is never visible
+// without it.
+func (backend UnstructuredFileBackend) writeWithExpiry(key string, ttl time.Duration, write func() error) error {
+	if ttl > 0 {
+		if err := backend.writeExpiry(key, ttl); err != nil {
+			return err
+		}
+		return write()
+	}
+
+	if err := write(); err != nil {
+		return err
 	}
-	if err = w.Flush(); err != nil {
+
+	if err := backend.removeExpiry(key); err != nil {
 		return fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
 	}
 
-	file.Chmod(0600)
-	backend.setPermissions(keyPath, 0600)
 	return nil
 }
 
+// removeExpiry removes the expiry of a key, if any
+func (backend UnstructuredFileBackend) removeExpiry(key string) error {
+	expiryPath, err := backend.getExpiryPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(expiryPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// removeExpired removes a key whose expiry has passed, along with it's expiry
+func (backend UnstructuredFileBackend) removeExpired(key string) error {
+	expired, err := backend.expired(key)
+	if err != nil || !expired {
+		return err
+	}
+
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return backend.removeExpiry(key)
+}
+
+// syncDirectory flushes a directory, persisting the renames within it
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// combine applies op to the members of the sets held by keys, where missing
+// keys are treated as empty sets
+func (backend UnstructuredFileBackend) combine(ctx context.Context, keys []string, op setOperation) (map[string]bool, error) {
+	sets := []map[string]bool{}
+	for _, key := range keys {
+		members, err := backend.Smembers(ctx, key)
+		if err != nil {
+			return map[string]bool{}, err
+		}
+		sets = append(sets, members)
+	}
+
+	return op(sets), nil
+}
+
+// combineStore replaces the destination key, whatever its data type, with
+// the set resulting from combine, removing it if the set is empty. Every key
+// is locked, so that the sets cannot change while they are combined.
+func (backend UnstructuredFileBackend) combineStore(ctx context.Context, destination string, keys []string, op setOperation) (int, error) {
+	ctx, unlock, err := backend.lockKeys(ctx, append([]string{destination}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.combine(ctx, keys, op)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(members) == 0 {
+		_, err := backend.Del(ctx, destination)
+		return 0, err
+	}
+
+	if err := backend.writeSet(ctx, destination, members); err != nil {
+		return 0, err
+	}
+
+	if err := backend.removeExpiry(destination); err != nil {
+		return 0, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, destination, err.Error())
+	}
+
+	return len(members), nil
+}
+
+// pop removes up to count elements from the head or tail of a list
+func (backend UnstructuredFileBackend) pop(ctx context.Context, key string, count int, fromTail bool) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+	defer unlock()
+
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	popped, remaining := popElements(elements, count, fromTail)
+	if err = backend.writeList(ctx, key, remaining); err != nil {
+		return []string{}, err
+	}
+
+	return popped, nil
+}
+
+// writeList writes the elements of a list, removing the key if the list is empty
+func (backend UnstructuredFileBackend) writeList(ctx context.Context, key string, elements []string) error {
+	if len(elements) == 0 {
+		_, err := backend.Del(ctx, key)
+		return err
+	}
+
+	var contents strings.Builder
+	for _, element := range elements {
+		contents.WriteString(element + "\n")
+	}
+
+	return backend.writeKeyFile(key, []byte(contents.String()))
+}
+
+// writeSet writes the members of a set in sorted order, removing the key if
+// the set is empty
+func (backend UnstructuredFileBackend) writeSet(ctx context.Context, key string, members map[string]bool) error {
+	return backend.writeList(ctx, key, sortedMembers(members))
+}
+
+// writeHash writes the fields of a hash as sorted field=value lines, removing
+// the key if the hash is empty. Fields containing = or a newline, and values
+// containing a newline, cannot be represented and are rejected.
+func (backend UnstructuredFileBackend) writeHash(ctx context.Context, key string, fields map[string]string) error {
+	lines := []string{}
+	for _, field := range sortedFields(fields) {
+		value := fields[field]
+		if strings.ContainsAny(field, "=\n") || strings.Contains(value, "\n") {
+			return newKeyError(backend.Namespace, key, ErrInvalidValue)
+		}
+		lines = append(lines, field+"="+value)
+	}
+
+	return backend.writeList(ctx, key, lines)
+}
+
+// parseHashLines decodes the field=value lines written by writeHash. A line
+// without a separator is read as a field holding an empty value.
+func parseHashLines(lines []string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range lines {
+		field, value, _ := strings.Cut(line, "=")
+		fields[field] = value
+	}
+
+	return fields
+}
+
+// scores reads each line of the file holding a key as a score and member
+func (backend UnstructuredFileBackend) scores(ctx context.Context, key string) (map[string]float64, error) {
+	lines, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return map[string]float64{}, err
+	}
+
+	return parseSortedSetLines(lines), nil
+}
+
+// writeSortedSet writes the members of a sorted set as "score member" lines
+// ordered by score, removing the key if the sorted set is empty. Members
+// containing a newline cannot be represented and are rejected.
+func (backend UnstructuredFileBackend) writeSortedSet(ctx context.Context, key string, scores map[string]float64) error {
+	lines := []string{}
+	for _, member := range sortedScores(scores) {
+		if strings.Contains(member.Member, "\n") {
+			return newKeyError(backend.Namespace, key, ErrInvalidValue)
+		}
+		lines = append(lines, strconv.FormatFloat(member.Score, 'g', -1, 64)+" "+member.Member)
+	}
+
+	return backend.writeList(ctx, key, lines)
+}
+
+// parseSortedSetLines decodes the "score member" lines written by
+// writeSortedSet. A line without a valid score is read as a member with a
+// score of zero.
+func parseSortedSetLines(lines []string) map[string]float64 {
+	scores := map[string]float64{}
+	for _, line := range lines {
+		value, member, ok := strings.Cut(line, " ")
+		score, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil {
+			scores[line] = 0
+			continue
+		}
+		scores[member] = score
+	}
+
+	return scores
+}
+
 // makeNamespaceDirectory ensures that a property path exists
 func (backend UnstructuredFileBackend) makeNamespaceDirectory() error {
 	if err := os.MkdirAll(backend.NamespaceRoot, 0755); err != nil {
@@ -515,12 +1820,17 @@ func (backend UnstructuredFileBackend) makeNamespaceDirectory() error {
 	return backend.setPermissions(backend.NamespaceRoot, 0755)
 }
 
-// setPermissions sets the proper owner and filemode for a given file
+// setPermissions sets the proper owner and filemode for a given file. The
+// owner is left unchanged if no system user or group is configured.
 func (backend UnstructuredFileBackend) setPermissions(path string, fileMode os.FileMode) error {
 	if err := os.Chmod(path, fileMode); err != nil {
 		return err
 	}
 
+	if backend.SystemUser == "" && backend.SystemGroup == "" {
+		return nil
+	}
+
 	group, err := user.LookupGroup(backend.SystemGroup)
 	if err != nil {
 		return err
@@ -0,0 +1,229 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// lockFilePrefix is prepended to the name of lock files. As namespaces
+	// and keys may not contain dots, lock files never shadow either.
+	lockFilePrefix = ".lock-"
+
+	// defaultLockTimeout is the time spent waiting for a lock when no
+	// lock-timeout is specified in the url
+	defaultLockTimeout = 10 * time.Second
+
+	// lockPollInterval is the time between attempts to take a held lock
+	lockPollInterval = 10 * time.Millisecond
+)
+
+// errLockHeld is returned by tryLockFile when another process holds the lock
+var errLockHeld = errors.New("Lock is held")
+
+// heldFileLocksKey is the context key recording the lock files held by the
+// current call, mapped to whether they are held exclusively. Methods called
+// while a lock is held reuse it rather than blocking on themselves.
+type heldFileLocksKey struct{}
+
+// withHeldFileLock returns a copy of ctx recording a held lock file
+func withHeldFileLock(ctx context.Context, lockPath string, exclusive bool) context.Context {
+	held := map[string]bool{}
+	if parent, ok := ctx.Value(heldFileLocksKey{}).(map[string]bool); ok {
+		for p, e := range parent {
+			held[p] = e
+		}
+	}
+
+	held[lockPath] = exclusive
+	return context.WithValue(ctx, heldFileLocksKey{}, held)
+}
+
+// heldFileLock returns whether ctx holds a lock file, and if so whether it
+// is held exclusively
+func heldFileLock(ctx context.Context, lockPath string) (bool, bool) {
+	held, _ := ctx.Value(heldFileLocksKey{}).(map[string]bool)
+	exclusive, ok := held[lockPath]
+	return ok, exclusive
+}
+
+// lockKey serializes modifications of a key across processes, by taking the
+// namespace lock shared and the key lock exclusively. An expired key is
+// removed once the lock is held, so that the modification does not revive
+// it. The returned context records the held locks, and must be passed to any
+// nested method calls.
+func (backend UnstructuredFileBackend) lockKey(ctx context.Context, key string) (context.Context, func(), error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return ctx, func() {}, err
+	}
+
+	namespaceLockPath := backend.namespaceLockPath(backend.Namespace)
+	keyLockPath := path.Join(backend.NamespaceRoot, lockFilePrefix+strings.ReplaceAll(key, "/", "."))
+
+	release := []*os.File{}
+	unlock := func() {
+		for i := len(release) - 1; i >= 0; i-- {
+			release[i].Close()
+		}
+	}
+
+	held, exclusive := heldFileLock(ctx, namespaceLockPath)
+	if held && exclusive {
+		return ctx, unlock, nil
+	}
+
+	if !held {
+		file, err := backend.lockFile(ctx, namespaceLockPath, false)
+		if err != nil {
+			return ctx, unlock, newKeyError(backend.Namespace, key, err)
+		}
+		release = append(release, file)
+		ctx = withHeldFileLock(ctx, namespaceLockPath, false)
+	}
+
+	if held, _ := heldFileLock(ctx, keyLockPath); held {
+		return ctx, unlock, nil
+	}
+
+	if err := backend.makeNamespaceDirectory(); err != nil {
+		unlock()
+		return ctx, func() {}, newKeyError(backend.Namespace, key, err)
+	}
+
+	file, err := backend.lockFile(ctx, keyLockPath, true)
+	if err != nil {
+		unlock()
+		return ctx, func() {}, newKeyError(backend.Namespace, key, err)
+	}
+	release = append(release, file)
+
+	if err := backend.removeExpired(key); err != nil {
+		unlock()
+		return ctx, func() {}, fmt.Errorf("Unable to remove expired key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return withHeldFileLock(ctx, keyLockPath, true), unlock, nil
+}
+
+// lockKeys locks several keys as lockKey does, in sorted order so that
+// concurrent callers cannot deadlock
+func (backend UnstructuredFileBackend) lockKeys(ctx context.Context, keys ...string) (context.Context, func(), error) {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	release := []func(){}
+	unlock := func() {
+		for i := len(release) - 1; i >= 0; i-- {
+			release[i]()
+		}
+	}
+
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+
+		var keyUnlock func()
+		var err error
+		ctx, keyUnlock, err = backend.lockKey(ctx, key)
+		if err != nil {
+			unlock()
+			return ctx, func() {}, err
+		}
+		release = append(release, keyUnlock)
+	}
+
+	return ctx, unlock, nil
+}
+
+// lockNamespaces takes the lock of each namespace exclusively, in sorted
+// order so that concurrent callers cannot deadlock
+func (backend UnstructuredFileBackend) lockNamespaces(ctx context.Context, namespaces ...string) (context.Context, func(), error) {
+	sorted := append([]string{}, namespaces...)
+	sort.Strings(sorted)
+
+	release := []*os.File{}
+	unlock := func() {
+		for i := len(release) - 1; i >= 0; i-- {
+			release[i].Close()
+		}
+	}
+
+	for i, namespace := range sorted {
+		if i > 0 && namespace == sorted[i-1] {
+			continue
+		}
+
+		if err := validateNamespaceName(namespace); err != nil {
+			unlock()
+			return ctx, func() {}, err
+		}
+
+		lockPath := backend.namespaceLockPath(namespace)
+		if held, exclusive := heldFileLock(ctx, lockPath); held && exclusive {
+			continue
+		}
+
+		file, err := backend.lockFile(ctx, lockPath, true)
+		if err != nil {
+			unlock()
+			return ctx, func() {}, newKeyError(namespace, "", err)
+		}
+		release = append(release, file)
+		ctx = withHeldFileLock(ctx, lockPath, true)
+	}
+
+	return ctx, unlock, nil
+}
+
+// namespaceLockPath returns the path of the lock file for a namespace. It is
+// kept outside of the namespace directory, which is removed when cleared.
+func (backend UnstructuredFileBackend) namespaceLockPath(namespace string) string {
+	return path.Join(backend.Root, lockFilePrefix+namespace)
+}
+
+// lockFile takes an advisory lock on the file at lockPath, creating it if
+// necessary. Held locks are polled for until the lock timeout elapses,
+// returning ErrLockTimeout, or the context is done. Closing the returned file
+// releases the lock.
+func (backend UnstructuredFileBackend) lockFile(ctx context.Context, lockPath string, exclusive bool) (*os.File, error) {
+	if err := os.MkdirAll(path.Dir(lockPath), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	backend.setPermissions(lockPath, 0600)
+
+	deadline := time.Now().Add(backend.LockTimeout)
+	for {
+		err := tryLockFile(file, exclusive)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, errLockHeld) {
+			file.Close()
+			return nil, err
+		}
+
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package backend
+
+import (
+	"os"
+)
+
+// tryLockFile does nothing, as advisory file locks are only supported on unix
+func tryLockFile(file *os.File, exclusive bool) error {
+	return nil
+}
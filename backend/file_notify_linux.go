@@ -0,0 +1,42 @@
+//go:build linux
+
+package backend
+
+import (
+	"os"
+	"path"
+	"syscall"
+)
+
+// notifyKey reports changes to the directory holding a key using inotify, so
+// that blocking pops wake up as soon as the key is written by any process.
+// Keys whose directory does not exist yet are polled for instead.
+func (backend UnstructuredFileBackend) notifyKey(key string) (<-chan struct{}, func(), error) {
+	keyPath, err := backend.getKeyPath(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return pollNotifier(key)
+	}
+
+	if _, err := syscall.InotifyAddWatch(fd, path.Dir(keyPath), syscall.IN_CREATE|syscall.IN_MOVED_TO|syscall.IN_CLOSE_WRITE|syscall.IN_DELETE_SELF); err != nil {
+		syscall.Close(fd)
+		return pollNotifier(key)
+	}
+
+	// the descriptor is non-blocking, so reads wait in the runtime poller
+	// and are interrupted when the file is closed
+	file := os.NewFile(uintptr(fd), "inotify")
+	changed := make(chan struct{})
+	go func() {
+		buf := make([]byte, 4096)
+		if _, err := file.Read(buf); err == nil {
+			close(changed)
+		}
+	}()
+
+	return changed, func() { file.Close() }, nil
+}
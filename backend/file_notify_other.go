@@ -0,0 +1,8 @@
+//go:build !linux
+
+package backend
+
+// notifyKey polls for changes to a key, as inotify is only available on linux
+func (backend UnstructuredFileBackend) notifyKey(key string) (<-chan struct{}, func(), error) {
+	return pollNotifier(key)
+}
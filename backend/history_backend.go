@@ -0,0 +1,816 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/user"
+	"reflect"
+	"time"
+)
+
+// HistoryNamespace is the namespace holding the history of every key when
+// history is enabled. Each key in it is a list of json encoded HistoryEntry
+// values for the keys of the same name in every other namespace.
+const HistoryNamespace = "prop-history"
+
+// operationDataTypes maps each recorded operation to the data type of the key
+// it leaves behind. Untyped backends read every key as a key-value, so keys
+// are read as the data type of the operation that changed them.
+var operationDataTypes = map[string]string{
+	"blpop":       DataTypeList,
+	"brpop":       DataTypeList,
+	"decr":        DataTypeKeyValue,
+	"getset":      DataTypeKeyValue,
+	"hdel":        DataTypeHash,
+	"hset":        DataTypeHash,
+	"incr":        DataTypeKeyValue,
+	"incrby":      DataTypeKeyValue,
+	"linsert":     DataTypeList,
+	"lmove":       DataTypeList,
+	"lpop":        DataTypeList,
+	"lpush":       DataTypeList,
+	"lrem":        DataTypeList,
+	"lset":        DataTypeList,
+	"ltrim":       DataTypeList,
+	"rpop":        DataTypeList,
+	"rpush":       DataTypeList,
+	"sadd":        DataTypeSet,
+	"sdiffstore":  DataTypeSet,
+	"set":         DataTypeKeyValue,
+	"sinterstore": DataTypeSet,
+	"smove":       DataTypeSet,
+	"spop":        DataTypeSet,
+	"srem":        DataTypeSet,
+	"sunionstore": DataTypeSet,
+	"zadd":        DataTypeSortedSet,
+	"zrem":        DataTypeSortedSet,
+}
+
+// HistoryValue is the value of a key before or after a change. Elements holds
+// the elements of a list, or the sorted members of a set, Fields holds the
+// fields of a hash, and Scores holds the members of a sorted set.
+type HistoryValue struct {
+	DataType string             `json:"data_type"`
+	Value    string             `json:"value,omitempty"`
+	Elements []string           `json:"elements,omitempty"`
+	Fields   map[string]string  `json:"fields,omitempty"`
+	Scores   map[string]float64 `json:"scores,omitempty"`
+}
+
+// HistoryEntry records a single change to a key. OldValue is nil if the key
+// did not exist before the change, and NewValue is nil if it was deleted.
+type HistoryEntry struct {
+	Revision  int           `json:"revision"`
+	Time      time.Time     `json:"time"`
+	Namespace string        `json:"namespace"`
+	Key       string        `json:"key"`
+	Operation string        `json:"operation"`
+	User      string        `json:"user"`
+	OldValue  *HistoryValue `json:"old_value"`
+	NewValue  *HistoryValue `json:"new_value"`
+}
+
+// HistoricalBackend is implemented by backends that record the changes made
+// to each key. History returns the changes to a key, oldest first, while
+// Rollback restores the value a key held after a revision, or before its
+// latest change if revision is 0.
+type HistoricalBackend interface {
+	Backend
+	History(ctx context.Context, key string) ([]HistoryEntry, error)
+	Rollback(ctx context.Context, key string, revision int) (bool, error)
+}
+
+// namespacedBackend is implemented by backends that can return a copy of
+// themselves bound to another namespace, sharing their storage and
+// connections
+type namespacedBackend interface {
+	inNamespace(namespace string) Backend
+}
+
+// HistoryBackend records every change made through it to the history of the
+// changed key, which is stored in HistoryNamespace of the same storage. The
+// value of a key is read before and after each change, so changes made
+// concurrently by other processes may be attributed to the wrong entry.
+// Expiry, backend import and backend reset are not recorded.
+type HistoryBackend struct {
+	Backend
+	Namespace string
+
+	// Limit is the number of entries kept in the history of each key, which
+	// is shared by the keys of the same name in every namespace. The oldest
+	// entries are trimmed after each change, and every entry is kept if
+	// Limit is 0.
+	Limit int
+
+	history Backend
+}
+
+// NewHistoryBackend create new instance of HistoryBackend, recording the
+// history of keys in the namespace using history, a backend bound to
+// HistoryNamespace
+func NewHistoryBackend(namespace string, backend Backend, history Backend) HistoryBackend {
+	return HistoryBackend{
+		Backend:   backend,
+		Namespace: namespace,
+		history:   history,
+	}
+}
+
+// newHistoryBackend wraps a backend constructed from a url with history=true,
+// reusing its storage where possible and otherwise constructing a second
+// backend bound to HistoryNamespace
+func newHistoryBackend(namespace string, backend Backend, limit int, construct func() (Backend, error)) (HistoryBackend, error) {
+	var history Backend
+	if b, ok := backend.(namespacedBackend); ok {
+		history = b.inNamespace(HistoryNamespace)
+	} else {
+		var err error
+		history, err = construct()
+		if err != nil {
+			return HistoryBackend{}, err
+		}
+	}
+
+	b := NewHistoryBackend(namespace, backend, history)
+	b.Limit = limit
+	return b, nil
+}
+
+// Unwrap returns the wrapped backend
+func (backend HistoryBackend) Unwrap() Backend {
+	return backend.Backend
+}
+
+// Close closes the wrapped backend and the history backend, if they hold
+// resources that must be released
+func (backend HistoryBackend) Close() error {
+	var err error
+	if closer, ok := backend.Backend.(io.Closer); ok {
+		err = closer.Close()
+	}
+
+	if _, shared := backend.Backend.(namespacedBackend); !shared {
+		if closer, ok := backend.history.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	return err
+}
+
+// Transaction runs fn within a transaction of the wrapped backend, which
+// also covers the recorded history when it shares the same storage
+func (backend HistoryBackend) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	b, ok := backend.Backend.(TransactionalBackend)
+	if !ok {
+		return ErrNotImplemented
+	}
+
+	return b.Transaction(ctx, fn)
+}
+
+// Watch watches the wrapped backend for changes
+func (backend HistoryBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	b, ok := backend.Backend.(WatchableBackend)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+
+	return b.Watch(ctx, prefix)
+}
+
+// History returns the changes recorded for a key, oldest first
+func (backend HistoryBackend) History(ctx context.Context, key string) ([]HistoryEntry, error) {
+	elements, err := backend.history.Lrange(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []HistoryEntry{}
+	for _, element := range elements {
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(element), &entry); err != nil {
+			return nil, err
+		}
+
+		if entry.Namespace == backend.Namespace {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// Rollback restores the value a key held after a revision, or before its
+// latest change if revision is 0. The rollback is itself recorded, and may be
+// rolled back in turn. The timeout of the key is not restored.
+func (backend HistoryBackend) Rollback(ctx context.Context, key string, revision int) (bool, error) {
+	entries, err := backend.History(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	var target *HistoryValue
+	if revision == 0 && len(entries) > 0 {
+		found = true
+		target = entries[len(entries)-1].OldValue
+	}
+	for _, entry := range entries {
+		if revision > 0 && entry.Revision == revision {
+			found = true
+			target = entry.NewValue
+		}
+	}
+	if !found {
+		return false, newKeyError(backend.Namespace, key, ErrRevisionNotFound)
+	}
+
+	dataType := ""
+	if target != nil {
+		dataType = target.DataType
+	}
+
+	restore := func(ctx context.Context) error {
+		return backend.trackDataType(ctx, key, "rollback", dataType, func() error {
+			if _, err := backend.Backend.Del(ctx, key); err != nil {
+				return err
+			}
+			if target == nil {
+				return nil
+			}
+
+			var err error
+			switch target.DataType {
+			case DataTypeList:
+				_, err = backend.Backend.Rpush(ctx, key, target.Elements...)
+			case DataTypeSet:
+				_, err = backend.Backend.Sadd(ctx, key, target.Elements...)
+			case DataTypeHash:
+				_, err = backend.Backend.Hset(ctx, key, target.Fields)
+			case DataTypeSortedSet:
+				_, err = backend.Backend.Zadd(ctx, key, target.Scores)
+			default:
+				_, err = backend.Backend.Set(ctx, key, target.Value)
+			}
+			return err
+		})
+	}
+
+	if SupportsTransactions(backend.Backend) {
+		err = backend.Transaction(ctx, restore)
+	} else {
+		err = restore(ctx)
+	}
+
+	return err == nil, err
+}
+
+func (backend HistoryBackend) Del(ctx context.Context, key string) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "del", func() (err error) {
+		ok, err = backend.Backend.Del(ctx, key)
+		return err
+	})
+	return ok, err
+}
+
+// NamespaceClear records the deletion of every key in the namespace, if the
+// wrapped backend supports exporting its keys
+func (backend HistoryBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	p, exportErr := backend.Backend.BackendExport(ctx)
+
+	ok, err := backend.Backend.NamespaceClear(ctx, namespace)
+	if err != nil || exportErr != nil {
+		return ok, err
+	}
+
+	for _, property := range p.Properties {
+		if property.Namespace != namespace {
+			continue
+		}
+
+		old := &HistoryValue{DataType: property.DataType}
+		switch value := property.Value.(type) {
+		case string:
+			old.Value = value
+		case []string:
+			old.Elements = value
+		case map[string]string:
+			old.Fields = value
+		case map[string]float64:
+			old.Scores = value
+		}
+		if err := backend.record(ctx, namespace, property.Key, "namespace clear", old, nil); err != nil {
+			return ok, err
+		}
+	}
+
+	return ok, nil
+}
+
+func (backend HistoryBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "set", func() (err error) {
+		ok, err = backend.Backend.Set(ctx, key, value)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "set", func() (err error) {
+		ok, err = backend.Backend.SetWithTTL(ctx, key, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "set", func() (err error) {
+		ok, err = backend.Backend.SetIfNotExists(ctx, key, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "set", func() (err error) {
+		ok, err = backend.Backend.SetIfValue(ctx, key, oldValue, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "set", func() (err error) {
+		ok, err = backend.Backend.SetIfRevision(ctx, key, revision, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	var previous string
+	err := backend.track(ctx, key, "getset", func() (err error) {
+		previous, err = backend.Backend.GetSet(ctx, key, value)
+		return err
+	})
+	return previous, err
+}
+
+func (backend HistoryBackend) Incr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := backend.track(ctx, key, "incr", func() (err error) {
+		value, err = backend.Backend.Incr(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (backend HistoryBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	var value int64
+	err := backend.track(ctx, key, "incrby", func() (err error) {
+		value, err = backend.Backend.IncrBy(ctx, key, increment)
+		return err
+	})
+	return value, err
+}
+
+func (backend HistoryBackend) Decr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := backend.track(ctx, key, "decr", func() (err error) {
+		value, err = backend.Backend.Decr(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (backend HistoryBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "expire", func() (err error) {
+		ok, err = backend.Backend.Expire(ctx, key, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return backend.trackBlockingPop(ctx, key, "blpop", false, func() (string, error) {
+		return backend.Backend.Blpop(ctx, key, timeout)
+	})
+}
+
+func (backend HistoryBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return backend.trackBlockingPop(ctx, key, "brpop", true, func() (string, error) {
+		return backend.Backend.Brpop(ctx, key, timeout)
+	})
+}
+
+func (backend HistoryBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	var length int
+	err := backend.track(ctx, key, "linsert", func() (err error) {
+		length, err = backend.Backend.Linsert(ctx, key, position, pivot, element)
+		return err
+	})
+	return length, err
+}
+
+func (backend HistoryBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	var element string
+	move := func() (err error) {
+		element, err = backend.Backend.Lmove(ctx, source, destination, from, to)
+		return err
+	}
+
+	if source == destination {
+		return element, backend.track(ctx, source, "lmove", move)
+	}
+
+	err := backend.track(ctx, source, "lmove", func() error {
+		return backend.track(ctx, destination, "lmove", move)
+	})
+	return element, err
+}
+
+func (backend HistoryBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	var elements []string
+	err := backend.track(ctx, key, "lpop", func() (err error) {
+		elements, err = backend.Backend.Lpop(ctx, key, count)
+		return err
+	})
+	return elements, err
+}
+
+func (backend HistoryBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	var length int
+	err := backend.track(ctx, key, "lpush", func() (err error) {
+		length, err = backend.Backend.Lpush(ctx, key, newElements...)
+		return err
+	})
+	return length, err
+}
+
+func (backend HistoryBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	var removed int
+	err := backend.track(ctx, key, "lrem", func() (err error) {
+		removed, err = backend.Backend.Lrem(ctx, key, countToRemove, element)
+		return err
+	})
+	return removed, err
+}
+
+func (backend HistoryBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	var ok bool
+	err := backend.track(ctx, key, "lset", func() (err error) {
+		ok, err = backend.Backend.Lset(ctx, key, index, element)
+		return err
+	})
+	return ok, err
+}
+
+func (backend HistoryBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	var trimmed bool
+	err := backend.track(ctx, key, "ltrim", func() (err error) {
+		trimmed, err = backend.Backend.Ltrim(ctx, key, start, stop)
+		return err
+	})
+	return trimmed, err
+}
+
+func (backend HistoryBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	var elements []string
+	err := backend.track(ctx, key, "rpop", func() (err error) {
+		elements, err = backend.Backend.Rpop(ctx, key, count)
+		return err
+	})
+	return elements, err
+}
+
+func (backend HistoryBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	var length int
+	err := backend.track(ctx, key, "rpush", func() (err error) {
+		length, err = backend.Backend.Rpush(ctx, key, newElements...)
+		return err
+	})
+	return length, err
+}
+
+func (backend HistoryBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	var added int
+	err := backend.track(ctx, key, "sadd", func() (err error) {
+		added, err = backend.Backend.Sadd(ctx, key, newMembers...)
+		return err
+	})
+	return added, err
+}
+
+func (backend HistoryBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.track(ctx, destination, "sdiffstore", func() (err error) {
+		count, err = backend.Backend.Sdiffstore(ctx, destination, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (backend HistoryBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.track(ctx, destination, "sinterstore", func() (err error) {
+		count, err = backend.Backend.Sinterstore(ctx, destination, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (backend HistoryBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	var moved bool
+	move := func() (err error) {
+		moved, err = backend.Backend.Smove(ctx, source, destination, member)
+		return err
+	}
+
+	if source == destination {
+		return moved, backend.track(ctx, source, "smove", move)
+	}
+
+	err := backend.track(ctx, source, "smove", func() error {
+		return backend.track(ctx, destination, "smove", move)
+	})
+	return moved, err
+}
+
+func (backend HistoryBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	var members []string
+	err := backend.track(ctx, key, "spop", func() (err error) {
+		members, err = backend.Backend.Spop(ctx, key, count)
+		return err
+	})
+	return members, err
+}
+
+func (backend HistoryBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	var removed int
+	err := backend.track(ctx, key, "srem", func() (err error) {
+		removed, err = backend.Backend.Srem(ctx, key, membersToRemove...)
+		return err
+	})
+	return removed, err
+}
+
+func (backend HistoryBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.track(ctx, destination, "sunionstore", func() (err error) {
+		count, err = backend.Backend.Sunionstore(ctx, destination, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (backend HistoryBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	var removed int
+	err := backend.track(ctx, key, "hdel", func() (err error) {
+		removed, err = backend.Backend.Hdel(ctx, key, fieldsToRemove...)
+		return err
+	})
+	return removed, err
+}
+
+func (backend HistoryBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	var added int
+	err := backend.track(ctx, key, "hset", func() (err error) {
+		added, err = backend.Backend.Hset(ctx, key, fields)
+		return err
+	})
+	return added, err
+}
+
+func (backend HistoryBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	var added int
+	err := backend.track(ctx, key, "zadd", func() (err error) {
+		added, err = backend.Backend.Zadd(ctx, key, scores)
+		return err
+	})
+	return added, err
+}
+
+func (backend HistoryBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	var removed int
+	err := backend.track(ctx, key, "zrem", func() (err error) {
+		removed, err = backend.Backend.Zrem(ctx, key, membersToRemove...)
+		return err
+	})
+	return removed, err
+}
+
+// track runs fn, recording the change it makes to a key
+func (backend HistoryBackend) track(ctx context.Context, key string, operation string, fn func() error) error {
+	return backend.trackDataType(ctx, key, operation, operationDataTypes[operation], fn)
+}
+
+// trackDataType records the change made by fn, which leaves the key holding
+// dataType, or any data type if dataType is empty. The value before the
+// change is read as the data type last recorded for the key.
+func (backend HistoryBackend) trackDataType(ctx context.Context, key string, operation string, dataType string, fn func() error) error {
+	oldDataType, err := backend.recordedDataType(ctx, key)
+	if err != nil {
+		return err
+	}
+	if oldDataType == "" {
+		oldDataType = dataType
+	}
+
+	old, err := backend.value(ctx, key, oldDataType)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	if dataType == "" && old != nil {
+		dataType = old.DataType
+	}
+
+	current, err := backend.value(ctx, key, dataType)
+	if err != nil {
+		return err
+	}
+
+	return backend.record(ctx, backend.Namespace, key, operation, old, current)
+}
+
+// trackBlockingPop records the change made by a blocking pop. The value read
+// before blocking may be stale by the time an element is popped, so the old
+// value is instead rebuilt by adding the popped element back to the list.
+func (backend HistoryBackend) trackBlockingPop(ctx context.Context, key string, operation string, fromTail bool, fn func() (string, error)) (string, error) {
+	element, err := fn()
+	if err != nil {
+		return element, err
+	}
+
+	current, err := backend.value(ctx, key, DataTypeList)
+	if err != nil {
+		return element, err
+	}
+
+	old := &HistoryValue{DataType: DataTypeList, Elements: []string{element}}
+	if current != nil {
+		if fromTail {
+			old.Elements = append(append([]string{}, current.Elements...), element)
+		} else {
+			old.Elements = prependElements(current.Elements, []string{element})
+		}
+	}
+
+	return element, backend.record(ctx, backend.Namespace, key, operation, old, current)
+}
+
+// record appends an entry to the history of a key, unless its value is unchanged
+func (backend HistoryBackend) record(ctx context.Context, namespace string, key string, operation string, oldValue *HistoryValue, newValue *HistoryValue) error {
+	if namespace == HistoryNamespace || reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+
+	entries, err := backend.withNamespace(namespace).History(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	revision := 1
+	if len(entries) > 0 {
+		revision = entries[len(entries)-1].Revision + 1
+	}
+
+	entry := HistoryEntry{
+		Revision:  revision,
+		Time:      time.Now().UTC(),
+		Namespace: namespace,
+		Key:       key,
+		Operation: operation,
+		User:      currentUser(),
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	length, err := backend.history.Rpush(ctx, key, string(data))
+	if err != nil || backend.Limit <= 0 || length <= backend.Limit {
+		return err
+	}
+
+	_, err = backend.history.Ltrim(ctx, key, -backend.Limit, -1)
+	return err
+}
+
+// recordedDataType returns the data type of the latest value recorded for a
+// key, or an empty string if no changes to the key were recorded
+func (backend HistoryBackend) recordedDataType(ctx context.Context, key string) (string, error) {
+	entries, err := backend.History(ctx, key)
+	if err != nil || len(entries) == 0 {
+		return "", err
+	}
+
+	latest := entries[len(entries)-1]
+	if latest.NewValue != nil {
+		return latest.NewValue.DataType, nil
+	}
+	return latest.OldValue.DataType, nil
+}
+
+// value returns the current value of a key, or nil if it does not exist. The
+// key is read as dataType if it is specified, and otherwise, or if the key
+// holds another data type, as each data type in turn.
+func (backend HistoryBackend) value(ctx context.Context, key string, dataType string) (*HistoryValue, error) {
+	exists, err := backend.Backend.Exists(ctx, key)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	dataTypes := []string{DataTypeKeyValue, DataTypeList, DataTypeSet, DataTypeHash, DataTypeSortedSet}
+	if dataType != "" {
+		dataTypes = append([]string{dataType}, dataTypes...)
+	}
+
+	for _, dataType := range dataTypes {
+		value, err := backend.valueOfType(ctx, key, dataType)
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, nil
+		}
+		if !errors.Is(err, ErrWrongType) {
+			return value, err
+		}
+	}
+
+	return nil, newKeyError(backend.Namespace, key, ErrWrongType)
+}
+
+// valueOfType returns the current value of a key holding dataType
+func (backend HistoryBackend) valueOfType(ctx context.Context, key string, dataType string) (*HistoryValue, error) {
+	switch dataType {
+	case DataTypeList:
+		elements, err := backend.Backend.Lrange(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &HistoryValue{DataType: DataTypeList, Elements: elements}, nil
+	case DataTypeSet:
+		members, err := backend.Backend.Smembers(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &HistoryValue{DataType: DataTypeSet, Elements: sortedMembers(members)}, nil
+	case DataTypeHash:
+		fields, err := backend.Backend.Hgetall(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &HistoryValue{DataType: DataTypeHash, Fields: fields}, nil
+	case DataTypeSortedSet:
+		sorted, err := backend.Backend.Zrange(ctx, key, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		scores := map[string]float64{}
+		for _, member := range sorted {
+			scores[member.Member] = member.Score
+		}
+		return &HistoryValue{DataType: DataTypeSortedSet, Scores: scores}, nil
+	default:
+		value, err := backend.Backend.Get(ctx, key, "")
+		if err != nil {
+			return nil, err
+		}
+		return &HistoryValue{DataType: DataTypeKeyValue, Value: value}, nil
+	}
+}
+
+// withNamespace returns a copy of the backend reading the history of another
+// namespace
+func (backend HistoryBackend) withNamespace(namespace string) HistoryBackend {
+	backend.Namespace = namespace
+	return backend
+}
+
+// currentUser returns the name of the operating system user making a change
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
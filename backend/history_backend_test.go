@@ -0,0 +1,200 @@
+package backend_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dokku/prop/backend"
+	"github.com/dokku/prop/backend/backendtest"
+)
+
+func TestHistoryBackendConformance(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     func(t *testing.T) string
+		untyped bool
+	}{
+		{name: "mem", url: func(t *testing.T) string { return "mem:" + t.Name() }},
+		{name: "file", url: func(t *testing.T) string { return "file:" + t.TempDir() }, untyped: true},
+		{name: "file+json", url: func(t *testing.T) string { return "file+json:" + t.TempDir() }},
+		{name: "sqlite", url: func(t *testing.T) string { return "sqlite:" + filepath.Join(t.TempDir(), "prop.db") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backendtest.Run(t, func(t *testing.T) backendtest.NewBackend {
+				url := tt.url(t) + "?history=true"
+				return func(namespace string) (backend.Backend, error) {
+					return backend.ConstructBackend(t.Context(), url, namespace)
+				}
+			}, backendtest.Options{Untyped: tt.untyped})
+		})
+	}
+}
+
+func TestHistoryBackendUntypedDataTypes(t *testing.T) {
+	b, err := backend.ConstructBackend(t.Context(), "file:"+t.TempDir()+"?history=true", "default")
+	if err != nil {
+		t.Fatalf("ConstructBackend returned an error: %s", err)
+	}
+
+	h, ok := b.(backend.HistoricalBackend)
+	if !ok {
+		t.Fatalf("ConstructBackend returned %T, which does not record history", b)
+	}
+
+	if _, err := b.Rpush(t.Context(), "list", "a", "b"); err != nil {
+		t.Fatalf("Rpush returned an error: %s", err)
+	}
+	if _, err := b.Sadd(t.Context(), "set", "x", "y"); err != nil {
+		t.Fatalf("Sadd returned an error: %s", err)
+	}
+	if _, err := b.Del(t.Context(), "list"); err != nil {
+		t.Fatalf("Del returned an error: %s", err)
+	}
+
+	tests := []struct {
+		key      string
+		expected []*backend.HistoryValue
+	}{
+		{
+			key: "list",
+			expected: []*backend.HistoryValue{
+				{DataType: backend.DataTypeList, Elements: []string{"a", "b"}},
+				nil,
+			},
+		},
+		{
+			key: "set",
+			expected: []*backend.HistoryValue{
+				{DataType: backend.DataTypeSet, Elements: []string{"x", "y"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		entries, err := h.History(t.Context(), tt.key)
+		if err != nil {
+			t.Fatalf("History(%s) returned an error: %s", tt.key, err)
+		}
+
+		values := []*backend.HistoryValue{}
+		for _, entry := range entries {
+			values = append(values, entry.NewValue)
+		}
+		if !reflect.DeepEqual(values, tt.expected) {
+			got, _ := json.Marshal(values)
+			want, _ := json.Marshal(tt.expected)
+			t.Errorf("History(%s) recorded %s, expected %s", tt.key, got, want)
+		}
+	}
+
+	if _, err := h.Rollback(t.Context(), "list", 0); err != nil {
+		t.Fatalf("Rollback returned an error: %s", err)
+	}
+
+	elements, err := b.Lrange(t.Context(), "list")
+	if err != nil {
+		t.Fatalf("Lrange returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(elements, []string{"a", "b"}) {
+		t.Errorf("Rollback restored %v, expected [a b]", elements)
+	}
+}
+
+func TestHistoryBackendLimit(t *testing.T) {
+	url := "file+json:" + t.TempDir() + "?history=true&history-limit=2"
+	b, err := backend.ConstructBackend(t.Context(), url, "default")
+	if err != nil {
+		t.Fatalf("ConstructBackend returned an error: %s", err)
+	}
+
+	h, ok := b.(backend.HistoricalBackend)
+	if !ok {
+		t.Fatalf("ConstructBackend returned %T, which does not record history", b)
+	}
+
+	for _, value := range []string{"one", "two", "three", "four"} {
+		if _, err := b.Set(t.Context(), "mykey", value); err != nil {
+			t.Fatalf("Set returned an error: %s", err)
+		}
+	}
+
+	entries, err := h.History(t.Context(), "mykey")
+	if err != nil {
+		t.Fatalf("History returned an error: %s", err)
+	}
+
+	revisions := []int{}
+	for _, entry := range entries {
+		revisions = append(revisions, entry.Revision)
+	}
+	if !reflect.DeepEqual(revisions, []int{3, 4}) {
+		t.Errorf("History kept revisions %v, expected [3 4]", revisions)
+	}
+
+	if _, err := h.Rollback(t.Context(), "mykey", 1); !errors.Is(err, backend.ErrRevisionNotFound) {
+		t.Errorf("Rollback to a trimmed revision returned %v, expected ErrRevisionNotFound", err)
+	}
+
+	if _, err := h.Rollback(t.Context(), "mykey", 3); err != nil {
+		t.Fatalf("Rollback returned an error: %s", err)
+	}
+	if value, err := b.Get(t.Context(), "mykey", ""); err != nil || value != "three" {
+		t.Errorf("Rollback restored %q (%v), expected three", value, err)
+	}
+
+	entries, err = h.History(t.Context(), "mykey")
+	if err != nil {
+		t.Fatalf("History returned an error: %s", err)
+	}
+	if len(entries) != 2 || entries[1].Revision != 5 {
+		t.Errorf("History recorded %d entries ending with %+v, expected 2 ending with revision 5", len(entries), entries[len(entries)-1])
+	}
+
+	for _, value := range []string{"0", "-1", "many"} {
+		if _, err := backend.ConstructBackend(t.Context(), "mem:"+t.Name()+"?history=true&history-limit="+value, "default"); err == nil {
+			t.Errorf("ConstructBackend accepted history-limit=%s", value)
+		}
+	}
+}
+
+func TestHistoryNamespaceReserved(t *testing.T) {
+	url := "mem:" + t.Name() + "?history=true"
+	if _, err := backend.ConstructBackend(t.Context(), url, backend.HistoryNamespace); !errors.Is(err, backend.ErrInvalidNamespace) {
+		t.Errorf("ConstructBackend(%s) returned %v, expected ErrInvalidNamespace", backend.HistoryNamespace, err)
+	}
+
+	b, err := backend.ConstructBackend(t.Context(), url, "default")
+	if err != nil {
+		t.Fatalf("ConstructBackend returned an error: %s", err)
+	}
+	if _, err := b.NamespaceClear(t.Context(), backend.HistoryNamespace); !errors.Is(err, backend.ErrInvalidNamespace) {
+		t.Errorf("NamespaceClear(%s) returned %v, expected ErrInvalidNamespace", backend.HistoryNamespace, err)
+	}
+
+	if _, err := b.Set(t.Context(), "mykey", "value"); err != nil {
+		t.Fatalf("Set returned an error: %s", err)
+	}
+
+	export, err := b.BackendExport(t.Context())
+	if err != nil {
+		t.Fatalf("BackendExport returned an error: %s", err)
+	}
+
+	imported, err := backend.ConstructBackend(t.Context(), "mem:"+t.Name()+"-copy?history=true", "default")
+	if err != nil {
+		t.Fatalf("ConstructBackend returned an error: %s", err)
+	}
+	if _, err := imported.BackendImport(t.Context(), export, true); err != nil {
+		t.Fatalf("BackendImport of an export including %s returned an error: %s", backend.HistoryNamespace, err)
+	}
+
+	entries, err := imported.(backend.HistoricalBackend).History(t.Context(), "mykey")
+	if err != nil || len(entries) != 1 {
+		t.Errorf("History of the imported key returned %d entries (%v), expected 1", len(entries), err)
+	}
+}
@@ -0,0 +1,666 @@
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+// LegacyBackend is the Backend interface prior to the addition of contexts.
+// Implementations may be wrapped with NewLegacyBackendAdapter.
+type LegacyBackend interface {
+	BackendExport() (PropertyCollection, error)
+	BackendImport(p PropertyCollection, clear bool) (bool, error)
+	BackendReset() (bool, error)
+	Del(key string) (bool, error)
+	Exists(key string) (bool, error)
+	NamespaceExists(namespace string) (bool, error)
+	NamespaceClear(namespace string) (bool, error)
+	Get(key string, defaultValue string) (string, error)
+	GetAll() (map[string]string, error)
+	GetAllByPrefix(prefix string) (map[string]string, error)
+	Set(key string, value string) (bool, error)
+	Lindex(key string, index int) (string, error)
+	Lismember(key string, element string) (bool, error)
+	Llen(key string) (int, error)
+	Lrange(key string) ([]string, error)
+	Lrangefrom(key string, start int) ([]string, error)
+	Lrangefromto(key string, start int, stop int) ([]string, error)
+	Lrem(key string, countToRemove int, element string) (int, error)
+	Lset(key string, index int, element string) (bool, error)
+	Rpush(key string, newElements ...string) (int, error)
+	Sadd(key string, newMembers ...string) (int, error)
+	Sismember(key string, member string) (bool, error)
+	Smembers(key string) (map[string]bool, error)
+	Srem(key string, membersToRemove ...string) (int, error)
+}
+
+// LegacyBackendAdapter adapts a LegacyBackend to the Backend interface. The
+// context is checked before each call, but cannot interrupt a call that is
+// already in progress.
+type LegacyBackendAdapter struct {
+	Backend LegacyBackend
+}
+
+// NewLegacyBackendAdapter create new instance of LegacyBackendAdapter
+func NewLegacyBackendAdapter(backend LegacyBackend) LegacyBackendAdapter {
+	return LegacyBackendAdapter{Backend: backend}
+}
+
+func (adapter LegacyBackendAdapter) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	if err := ctx.Err(); err != nil {
+		return PropertyCollection{}, err
+	}
+
+	return adapter.Backend.BackendExport()
+}
+
+func (adapter LegacyBackendAdapter) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.BackendImport(p, clear)
+}
+
+func (adapter LegacyBackendAdapter) BackendReset(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.BackendReset()
+}
+
+func (adapter LegacyBackendAdapter) Del(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Del(key)
+}
+
+func (adapter LegacyBackendAdapter) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Exists(key)
+}
+
+func (adapter LegacyBackendAdapter) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.NamespaceExists(namespace)
+}
+
+func (adapter LegacyBackendAdapter) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.NamespaceClear(namespace)
+}
+
+func (adapter LegacyBackendAdapter) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return adapter.Backend.Get(key, defaultValue)
+}
+
+func (adapter LegacyBackendAdapter) GetAll(ctx context.Context) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]string{}, err
+	}
+
+	return adapter.Backend.GetAll()
+}
+
+func (adapter LegacyBackendAdapter) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]string{}, err
+	}
+
+	return adapter.Backend.GetAllByPrefix(prefix)
+}
+
+func (adapter LegacyBackendAdapter) Set(ctx context.Context, key string, value string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Set(key, value)
+}
+
+// SetWithTTL is not part of the LegacyBackend interface, which has no
+// expiration
+func (adapter LegacyBackendAdapter) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// SetIfNotExists is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// SetIfValue is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// GetWithRevision is not part of the LegacyBackend interface, which does not
+// track revisions
+func (adapter LegacyBackendAdapter) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	return "", 0, ErrNotImplemented
+}
+
+// SetIfRevision is not part of the LegacyBackend interface, which does not
+// track revisions
+func (adapter LegacyBackendAdapter) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// GetSet is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) GetSet(ctx context.Context, key string, value string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrNotImplemented
+}
+
+// Incr is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Incr(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// IncrBy is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Decr is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Decr(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Expire is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// Persist is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Persist(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// TTL is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Blpop is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrNotImplemented
+}
+
+// Brpop is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Lindex(ctx context.Context, key string, index int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return adapter.Backend.Lindex(key, index)
+}
+
+// Linsert is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Lismember(key, element)
+}
+
+func (adapter LegacyBackendAdapter) Llen(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return adapter.Backend.Llen(key)
+}
+
+// Lmove is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrNotImplemented
+}
+
+// Lpop is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return []string{}, ErrNotImplemented
+}
+
+// Lpos is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return []int{}, err
+	}
+
+	return []int{}, ErrNotImplemented
+}
+
+// Lpush is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Lrange(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return adapter.Backend.Lrange(key)
+}
+
+func (adapter LegacyBackendAdapter) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return adapter.Backend.Lrangefrom(key, start)
+}
+
+func (adapter LegacyBackendAdapter) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return adapter.Backend.Lrangefromto(key, start, stop)
+}
+
+func (adapter LegacyBackendAdapter) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return adapter.Backend.Lrem(key, countToRemove, element)
+}
+
+func (adapter LegacyBackendAdapter) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Lset(key, index, element)
+}
+
+// Ltrim is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// Rpop is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return []string{}, ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return adapter.Backend.Rpush(key, newElements...)
+}
+
+func (adapter LegacyBackendAdapter) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return adapter.Backend.Sadd(key, newMembers...)
+}
+
+// Scard is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Scard(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Sdiff is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]bool{}, err
+	}
+
+	return map[string]bool{}, ErrNotImplemented
+}
+
+// Sdiffstore is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Sinter is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]bool{}, err
+	}
+
+	return map[string]bool{}, ErrNotImplemented
+}
+
+// Sinterstore is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return adapter.Backend.Sismember(key, member)
+}
+
+func (adapter LegacyBackendAdapter) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]bool{}, err
+	}
+
+	return adapter.Backend.Smembers(key)
+}
+
+// Smove is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// Spop is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return []string{}, ErrNotImplemented
+}
+
+// Srandmember is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return []string{}, ErrNotImplemented
+}
+
+func (adapter LegacyBackendAdapter) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return adapter.Backend.Srem(key, membersToRemove...)
+}
+
+// Sunion is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]bool{}, err
+	}
+
+	return map[string]bool{}, ErrNotImplemented
+}
+
+// Sunionstore is not part of the LegacyBackend interface, as it cannot be
+// implemented atomically on top of it
+func (adapter LegacyBackendAdapter) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Hdel is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Hexists is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return false, ErrNotImplemented
+}
+
+// Hget is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hget(ctx context.Context, key string, field string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrNotImplemented
+}
+
+// Hgetall is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]string{}, err
+	}
+
+	return map[string]string{}, ErrNotImplemented
+}
+
+// Hkeys is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hkeys(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return []string{}, ErrNotImplemented
+}
+
+// Hlen is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hlen(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Hset is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Zadd is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Zcard is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zcard(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Zrange is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	if err := ctx.Err(); err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return []SortedSetMember{}, ErrNotImplemented
+}
+
+// Zrangebyscore is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	if err := ctx.Err(); err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return []SortedSetMember{}, ErrNotImplemented
+}
+
+// Zrank is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zrank(ctx context.Context, key string, member string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Zrem is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
+
+// Zscore is not part of the LegacyBackend interface
+func (adapter LegacyBackendAdapter) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, ErrNotImplemented
+}
@@ -0,0 +1,1414 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+// memoryStores holds the named stores shared by every mem:name url
+var memoryStores = struct {
+	sync.Mutex
+	stores map[string]*memoryStore
+}{stores: map[string]*memoryStore{}}
+
+func init() {
+	// dburl registers a two character alias for every scheme, and "me" is
+	// already taken by memsql
+	dburl.Register(dburl.Scheme{
+		Driver:  "mem",
+		Aliases: []string{"memory", "mm"},
+		Generator: func(u *dburl.URL) (string, string, error) {
+			return u.Opaque, "", nil
+		},
+		Opaque: true,
+	})
+
+	Register("mem", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewMemoryBackendFromURL(namespace, u)
+	})
+}
+
+// memoryValue is a single property held in memory
+type memoryValue struct {
+	dataType string
+	value    string
+	elements []string
+	members  map[string]bool
+	fields   map[string]string
+	scores   map[string]float64
+
+	// expiresAt is the time the value expires, or zero if it does not
+	expiresAt time.Time
+
+	// revision is assigned by the store each time the value is put
+	revision int64
+}
+
+// newKeyValue returns a key-value that expires after ttl, or never if ttl is
+// not positive
+func newKeyValue(value string, ttl time.Duration) *memoryValue {
+	v := &memoryValue{dataType: DataTypeKeyValue, value: value}
+	if ttl > 0 {
+		v.expiresAt = time.Now().Add(ttl)
+	}
+	return v
+}
+
+// expired returns true if the value has an expiry that has passed
+func (v *memoryValue) expired() bool {
+	return !v.expiresAt.IsZero() && !time.Now().Before(v.expiresAt)
+}
+
+// memoryStore holds the properties of every namespace
+type memoryStore struct {
+	sync.RWMutex
+	namespaces map[string]map[string]*memoryValue
+
+	// revision is the revision last assigned to a value, which is kept when
+	// the store is reset so that revisions are never reused
+	revision int64
+}
+
+type MemoryBackend struct {
+	Namespace string
+
+	store *memoryStore
+}
+
+// NewMemoryBackend create new instance of MemoryBackend with empty, private storage
+func NewMemoryBackend(namespace string) MemoryBackend {
+	backend := MemoryBackend{}
+	backend.Namespace = namespace
+	backend.store = &memoryStore{namespaces: map[string]map[string]*memoryValue{}}
+	return backend
+}
+
+// NewMemoryBackendFromURL create new instance of MemoryBackend. A url of
+// mem: uses private storage, while mem:name shares storage with every other
+// backend in the process constructed with the same name.
+func NewMemoryBackendFromURL(namespace string, url *dburl.URL) (MemoryBackend, error) {
+	backend := NewMemoryBackend(namespace)
+	if url.Opaque == "" {
+		return backend, nil
+	}
+
+	memoryStores.Lock()
+	defer memoryStores.Unlock()
+	if store, ok := memoryStores.stores[url.Opaque]; ok {
+		backend.store = store
+	} else {
+		memoryStores.stores[url.Opaque] = backend.store
+	}
+
+	return backend, nil
+}
+
+// inNamespace returns a copy of the backend bound to another namespace
+func (backend MemoryBackend) inNamespace(namespace string) Backend {
+	backend.Namespace = namespace
+	return backend
+}
+
+func (backend MemoryBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	properties := PropertyCollection{Properties: []Property{}}
+	for namespace, keys := range backend.store.namespaces {
+		for key, v := range keys {
+			if v.expired() {
+				continue
+			}
+
+			property := Property{
+				DataType:  v.dataType,
+				Namespace: namespace,
+				Key:       key,
+			}
+			switch v.dataType {
+			case DataTypeKeyValue:
+				property.Value = v.value
+			case DataTypeList:
+				property.Value = append([]string{}, v.elements...)
+			case DataTypeSet:
+				property.Value = sortedMembers(v.members)
+			case DataTypeHash:
+				property.Value = copyFields(v.fields)
+			case DataTypeSortedSet:
+				property.Value = copyScores(v.scores)
+			}
+			properties.Properties = append(properties.Properties, property)
+		}
+	}
+
+	sort.Slice(properties.Properties, func(i, j int) bool {
+		a, b := properties.Properties[i], properties.Properties[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Key < b.Key
+	})
+
+	return properties, nil
+}
+
+func (backend MemoryBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	values := make([]*memoryValue, len(p.Properties))
+	for i, property := range p.Properties {
+		v := &memoryValue{dataType: property.DataType}
+		switch property.DataType {
+		case DataTypeKeyValue:
+			value, err := property.StringValue()
+			if err != nil {
+				return false, err
+			}
+			v.value = value
+		case DataTypeList:
+			elements, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			v.elements = append([]string{}, elements...)
+		case DataTypeSet:
+			members, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			v.members = make(map[string]bool)
+			for _, member := range members {
+				v.members[member] = true
+			}
+		case DataTypeHash:
+			fields, err := property.HashValue()
+			if err != nil {
+				return false, err
+			}
+			v.fields = copyFields(fields)
+		case DataTypeSortedSet:
+			scores, err := property.SortedSetValue()
+			if err != nil {
+				return false, err
+			}
+			v.scores = copyScores(scores)
+		default:
+			return false, fmt.Errorf("Invalid data type for property %s.%s: %s", property.Namespace, property.Key, property.DataType)
+		}
+
+		if property.DataType != DataTypeKeyValue && len(v.elements) == 0 && len(v.members) == 0 && len(v.fields) == 0 && len(v.scores) == 0 {
+			v = nil
+		}
+		values[i] = v
+	}
+
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	if clear {
+		backend.store.namespaces = map[string]map[string]*memoryValue{}
+	}
+
+	for i, property := range p.Properties {
+		backend.store.put(property.Namespace, property.Key, values[i])
+	}
+
+	return true, nil
+}
+
+func (backend MemoryBackend) BackendReset(ctx context.Context) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	backend.store.namespaces = map[string]map[string]*memoryValue{}
+	return true, nil
+}
+
+func (backend MemoryBackend) Del(ctx context.Context, key string) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	backend.store.put(backend.Namespace, key, nil)
+	return true, nil
+}
+
+func (backend MemoryBackend) Exists(ctx context.Context, key string) (bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	return backend.store.get(backend.Namespace, key) != nil, nil
+}
+
+func (backend MemoryBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	for _, v := range backend.store.namespaces[namespace] {
+		if !v.expired() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (backend MemoryBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	delete(backend.store.namespaces, namespace)
+	return true, nil
+}
+
+func (backend MemoryBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		if defaultValue != "" {
+			return defaultValue, nil
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	return v.value, nil
+}
+
+func (backend MemoryBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return "", 0, err
+	}
+	if v == nil {
+		return "", 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	return v.value, v.revision, nil
+}
+
+func (backend MemoryBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	return backend.GetAllByPrefix(ctx, "")
+}
+
+func (backend MemoryBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	keyValuePairs := make(map[string]string)
+	for key, v := range backend.store.namespaces[backend.Namespace] {
+		if v.dataType == DataTypeKeyValue && !v.expired() && strings.HasPrefix(key, prefix) {
+			keyValuePairs[key] = v.value
+		}
+	}
+
+	return keyValuePairs, nil
+}
+
+func (backend MemoryBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return backend.SetWithTTL(ctx, key, value, 0)
+}
+
+func (backend MemoryBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	backend.store.put(backend.Namespace, key, newKeyValue(value, ttl))
+	return true, nil
+}
+
+func (backend MemoryBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	if backend.store.get(backend.Namespace, key) != nil {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	backend.store.put(backend.Namespace, key, newKeyValue(value, ttl))
+	return true, nil
+}
+
+func (backend MemoryBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return false, err
+	}
+	if v == nil || v.value != oldValue {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	backend.store.put(backend.Namespace, key, newKeyValue(value, ttl))
+	return true, nil
+}
+
+func (backend MemoryBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return false, err
+	}
+
+	current := int64(0)
+	if v != nil {
+		current = v.revision
+	}
+	if current != revision {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	backend.store.put(backend.Namespace, key, newKeyValue(value, ttl))
+	return true, nil
+}
+
+func (backend MemoryBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return "", err
+	}
+
+	previous := ""
+	if v != nil {
+		previous = v.value
+	}
+
+	backend.store.put(backend.Namespace, key, &memoryValue{dataType: DataTypeKeyValue, value: value})
+	return previous, nil
+}
+
+func (backend MemoryBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, 1)
+}
+
+// IncrBy keeps any expiry of the key, as the value is modified in place
+func (backend MemoryBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeKeyValue)
+	if err != nil {
+		return 0, err
+	}
+
+	current := ""
+	if v != nil {
+		current = v.value
+	}
+
+	value, err := incrementValue(backend.Namespace, key, current, v != nil, increment)
+	if err != nil {
+		return 0, err
+	}
+
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeKeyValue}
+	}
+	v.value = strconv.FormatInt(value, 10)
+	backend.store.put(backend.Namespace, key, v)
+
+	return value, nil
+}
+
+func (backend MemoryBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, -1)
+}
+
+func (backend MemoryBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v := backend.store.get(backend.Namespace, key)
+	if v == nil {
+		return false, nil
+	}
+
+	if ttl <= 0 {
+		backend.store.put(backend.Namespace, key, nil)
+		return true, nil
+	}
+
+	v.expiresAt = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (backend MemoryBackend) Persist(ctx context.Context, key string) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v := backend.store.get(backend.Namespace, key)
+	if v == nil || v.expiresAt.IsZero() {
+		return false, nil
+	}
+
+	v.expiresAt = time.Time{}
+	return true, nil
+}
+
+func (backend MemoryBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v := backend.store.get(backend.Namespace, key)
+	if v == nil {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if v.expiresAt.IsZero() {
+		return NoExpiration, nil
+	}
+
+	return time.Until(v.expiresAt), nil
+}
+
+func (backend MemoryBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, pollNotifier, func() (string, error) {
+		return firstElement(backend.pop(key, 1, false))
+	})
+}
+
+func (backend MemoryBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, pollNotifier, func() (string, error) {
+		return firstElement(backend.pop(key, 1, true))
+	})
+}
+
+func (backend MemoryBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if index < 0 {
+		index = len(v.elements) + index
+	}
+	if index < 0 || index >= len(v.elements) {
+		return "", newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+
+	return v.elements[index], nil
+}
+
+func (backend MemoryBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := validateListPosition(backend.Namespace, key, position); err != nil {
+		return 0, err
+	}
+
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	elements, err := insertElement(backend.Namespace, key, v.elements, position, pivot, element)
+	if err != nil {
+		return 0, err
+	}
+
+	v.elements = elements
+	return len(v.elements), nil
+}
+
+func (backend MemoryBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil || v == nil {
+		return false, err
+	}
+
+	for _, e := range v.elements {
+		if e == element {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (backend MemoryBackend) Llen(ctx context.Context, key string) (int, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	return len(v.elements), nil
+}
+
+func (backend MemoryBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := validateListDirections(backend.Namespace, source, from, to); err != nil {
+		return "", err
+	}
+
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	src, err := backend.lookup(source, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+	if src == nil {
+		return "", newKeyError(backend.Namespace, source, ErrKeyNotFound)
+	}
+
+	dst, err := backend.lookup(destination, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+	if dst == nil {
+		dst = &memoryValue{dataType: DataTypeList}
+	}
+
+	element, sourceElements, destinationElements := moveElement(src.elements, dst.elements, source == destination, from, to)
+	src.elements = sourceElements
+	if len(src.elements) == 0 {
+		backend.store.put(backend.Namespace, source, nil)
+	}
+
+	dst.elements = destinationElements
+	backend.store.put(backend.Namespace, destination, dst)
+	return element, nil
+}
+
+func (backend MemoryBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(key, count, false)
+}
+
+func (backend MemoryBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := validateListPositionOptions(backend.Namespace, key, rank, count); err != nil {
+		return []int{}, err
+	}
+
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil || v == nil {
+		return []int{}, err
+	}
+
+	return listPositions(v.elements, element, rank, count), nil
+}
+
+func (backend MemoryBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeList}
+		backend.store.put(backend.Namespace, key, v)
+	}
+
+	v.elements = prependElements(v.elements, newElements)
+	return len(v.elements), nil
+}
+
+func (backend MemoryBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, 0, -1)
+}
+
+func (backend MemoryBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, start, -1)
+}
+
+func (backend MemoryBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil || v == nil {
+		return []string{}, err
+	}
+
+	offset, limit, ok := normalizeListRange(len(v.elements), start, stop)
+	if !ok {
+		return []string{}, nil
+	}
+
+	return append([]string{}, v.elements[offset:offset+limit]...), nil
+}
+
+func (backend MemoryBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	elements := append([]string{}, v.elements...)
+	if countToRemove < 0 {
+		reverse(elements)
+	}
+
+	removed := 0
+	newElements := []string{}
+	for _, e := range elements {
+		if e == element && (countToRemove == 0 || removed < abs(countToRemove)) {
+			removed++
+			continue
+		}
+		newElements = append(newElements, e)
+	}
+
+	if countToRemove < 0 {
+		reverse(newElements)
+	}
+
+	v.elements = newElements
+	if len(v.elements) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return removed, nil
+}
+
+func (backend MemoryBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return false, err
+	}
+	if v == nil {
+		return false, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if index < 0 {
+		index = len(v.elements) + index
+	}
+	if index < 0 || index >= len(v.elements) {
+		return false, newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+
+	v.elements[index] = element
+	return true, nil
+}
+
+func (backend MemoryBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return false, err
+	}
+	if v == nil {
+		return true, nil
+	}
+
+	offset, limit, ok := normalizeListRange(len(v.elements), start, stop)
+	if !ok {
+		backend.store.put(backend.Namespace, key, nil)
+		return true, nil
+	}
+
+	v.elements = append([]string{}, v.elements[offset:offset+limit]...)
+	return true, nil
+}
+
+func (backend MemoryBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(key, count, true)
+}
+
+func (backend MemoryBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeList}
+		backend.store.put(backend.Namespace, key, v)
+	}
+
+	v.elements = append(v.elements, newElements...)
+	return len(v.elements), nil
+}
+
+func (backend MemoryBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeSet, members: map[string]bool{}}
+		backend.store.put(backend.Namespace, key, v)
+	}
+
+	addedCount := 0
+	for _, member := range newMembers {
+		if !v.members[member] {
+			v.members[member] = true
+			addedCount++
+		}
+	}
+
+	return addedCount, nil
+}
+
+func (backend MemoryBackend) Scard(ctx context.Context, key string) (int, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	return len(v.members), nil
+}
+
+func (backend MemoryBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(keys, diffMembers)
+}
+
+func (backend MemoryBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(destination, keys, diffMembers)
+}
+
+func (backend MemoryBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(keys, interMembers)
+}
+
+func (backend MemoryBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(destination, keys, interMembers)
+}
+
+func (backend MemoryBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil || v == nil {
+		return false, err
+	}
+
+	return v.members[member], nil
+}
+
+func (backend MemoryBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	members := make(map[string]bool)
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil || v == nil {
+		return members, err
+	}
+
+	for member := range v.members {
+		members[member] = true
+	}
+
+	return members, nil
+}
+
+func (backend MemoryBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	src, err := backend.lookup(source, DataTypeSet)
+	if err != nil {
+		return false, err
+	}
+
+	dst, err := backend.lookup(destination, DataTypeSet)
+	if err != nil {
+		return false, err
+	}
+
+	if src == nil || !src.members[member] {
+		return false, nil
+	}
+	if source == destination {
+		return true, nil
+	}
+
+	delete(src.members, member)
+	if len(src.members) == 0 {
+		backend.store.put(backend.Namespace, source, nil)
+	}
+
+	if dst == nil {
+		dst = &memoryValue{dataType: DataTypeSet, members: map[string]bool{}}
+		backend.store.put(backend.Namespace, destination, dst)
+	}
+	dst.members[member] = true
+
+	return true, nil
+}
+
+func (backend MemoryBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil {
+		return []string{}, err
+	}
+	if v == nil {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	popped := randomMembers(v.members, count)
+	for _, member := range popped {
+		delete(v.members, member)
+	}
+
+	if len(v.members) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return popped, nil
+}
+
+func (backend MemoryBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil || v == nil {
+		return []string{}, err
+	}
+
+	return randomMembers(v.members, count), nil
+}
+
+func (backend MemoryBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeSet)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if v.members[member] {
+			delete(v.members, member)
+			removedCount++
+		}
+	}
+
+	if len(v.members) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return removedCount, nil
+}
+
+func (backend MemoryBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(keys, unionMembers)
+}
+
+func (backend MemoryBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(destination, keys, unionMembers)
+}
+
+func (backend MemoryBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, field := range fieldsToRemove {
+		if _, ok := v.fields[field]; ok {
+			delete(v.fields, field)
+			removedCount++
+		}
+	}
+
+	if len(v.fields) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return removedCount, nil
+}
+
+func (backend MemoryBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil || v == nil {
+		return false, err
+	}
+
+	_, ok := v.fields[field]
+	return ok, nil
+}
+
+func (backend MemoryBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	value, ok := v.fields[field]
+	if !ok {
+		return "", newKeyError(backend.Namespace, key, ErrFieldNotFound)
+	}
+
+	return value, nil
+}
+
+func (backend MemoryBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil || v == nil {
+		return map[string]string{}, err
+	}
+
+	return copyFields(v.fields), nil
+}
+
+func (backend MemoryBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return sortedFields(fields), nil
+}
+
+func (backend MemoryBackend) Hlen(ctx context.Context, key string) (int, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	return len(v.fields), nil
+}
+
+func (backend MemoryBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeHash)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeHash, fields: map[string]string{}}
+		backend.store.put(backend.Namespace, key, v)
+	}
+
+	addedCount := 0
+	for field, value := range fields {
+		if _, ok := v.fields[field]; !ok {
+			addedCount++
+		}
+		v.fields[field] = value
+	}
+
+	return addedCount, nil
+}
+
+func (backend MemoryBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	if len(scores) == 0 {
+		return 0, nil
+	}
+	if v == nil {
+		v = &memoryValue{dataType: DataTypeSortedSet, scores: map[string]float64{}}
+		backend.store.put(backend.Namespace, key, v)
+	}
+
+	addedCount := 0
+	for member, score := range scores {
+		if _, ok := v.scores[member]; !ok {
+			addedCount++
+		}
+		v.scores[member] = score
+	}
+
+	return addedCount, nil
+}
+
+func (backend MemoryBackend) Zcard(ctx context.Context, key string) (int, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	return len(v.scores), nil
+}
+
+func (backend MemoryBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil || v == nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByRank(sortedScores(v.scores), start, stop), nil
+}
+
+func (backend MemoryBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil || v == nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByScore(sortedScores(v.scores), min, max), nil
+}
+
+func (backend MemoryBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	rank, ok := rankOf(sortedScores(v.scores), member)
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return rank, nil
+}
+
+func (backend MemoryBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil || v == nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if _, ok := v.scores[member]; ok {
+			delete(v.scores, member)
+			removedCount++
+		}
+	}
+
+	if len(v.scores) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return removedCount, nil
+}
+
+func (backend MemoryBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	v, err := backend.lookup(key, DataTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	score, ok := v.scores[member]
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return score, nil
+}
+
+// Watch polls the store for changes to keys starting with prefix
+func (backend MemoryBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	return pollWatch(ctx, backend.Namespace, exportSnapshot(backend, backend.Namespace, prefix))
+}
+
+// pop removes up to count elements from the head or tail of a list
+func (backend MemoryBackend) pop(key string, count int, fromTail bool) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	v, err := backend.lookup(key, DataTypeList)
+	if err != nil {
+		return []string{}, err
+	}
+	if v == nil {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	popped, remaining := popElements(v.elements, count, fromTail)
+	v.elements = remaining
+	if len(v.elements) == 0 {
+		backend.store.put(backend.Namespace, key, nil)
+	}
+
+	return popped, nil
+}
+
+// combine applies op to the members of the sets held by keys, where missing
+// keys are treated as empty sets
+func (backend MemoryBackend) combine(keys []string, op setOperation) (map[string]bool, error) {
+	backend.store.RLock()
+	defer backend.store.RUnlock()
+
+	return backend.combineLocked(keys, op)
+}
+
+// combineLocked is combine for callers already holding the store lock
+func (backend MemoryBackend) combineLocked(keys []string, op setOperation) (map[string]bool, error) {
+	sets := []map[string]bool{}
+	for _, key := range keys {
+		v, err := backend.lookup(key, DataTypeSet)
+		if err != nil {
+			return map[string]bool{}, err
+		}
+		if v == nil {
+			sets = append(sets, map[string]bool{})
+			continue
+		}
+		sets = append(sets, v.members)
+	}
+
+	return op(sets), nil
+}
+
+// combineStore replaces the destination key, whatever its data type, with
+// the set resulting from combine, removing it if the set is empty
+func (backend MemoryBackend) combineStore(destination string, keys []string, op setOperation) (int, error) {
+	backend.store.Lock()
+	defer backend.store.Unlock()
+
+	members, err := backend.combineLocked(keys, op)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(members) == 0 {
+		backend.store.put(backend.Namespace, destination, nil)
+		return 0, nil
+	}
+
+	backend.store.put(backend.Namespace, destination, &memoryValue{dataType: DataTypeSet, members: members})
+	return len(members), nil
+}
+
+// lookup returns the value of a key, or an error if it holds a data type
+// other than the expected one. The store lock must be held by the caller.
+func (backend MemoryBackend) lookup(key string, expected string) (*memoryValue, error) {
+	v := backend.store.get(backend.Namespace, key)
+	if v != nil && v.dataType != expected {
+		return nil, newKeyError(backend.Namespace, key, ErrWrongType)
+	}
+
+	return v, nil
+}
+
+// get returns the value of a key, or nil if the key is missing or expired
+func (store *memoryStore) get(namespace string, key string) *memoryValue {
+	v := store.namespaces[namespace][key]
+	if v == nil || v.expired() {
+		return nil
+	}
+
+	return v
+}
+
+// put stores a value, assigning it the next revision, and removes the key
+// when the value is nil
+func (store *memoryStore) put(namespace string, key string, v *memoryValue) {
+	keys, ok := store.namespaces[namespace]
+	if v == nil {
+		delete(keys, key)
+		if ok && len(keys) == 0 {
+			delete(store.namespaces, namespace)
+		}
+		return
+	}
+
+	if !ok {
+		keys = make(map[string]*memoryValue)
+		store.namespaces[namespace] = keys
+	}
+	store.revision++
+	v.revision = store.revision
+	keys[key] = v
+}
+
+func sortedMembers(members map[string]bool) []string {
+	sorted := []string{}
+	for member := range members {
+		sorted = append(sorted, member)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sortedFields returns the fields of a hash in sorted order
+func sortedFields(fields map[string]string) []string {
+	sorted := []string{}
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// copyFields returns a copy of the fields of a hash
+func copyFields(fields map[string]string) map[string]string {
+	copied := make(map[string]string, len(fields))
+	for field, value := range fields {
+		copied[field] = value
+	}
+	return copied
+}
+
+// copyScores returns a copy of the scores of a sorted set
+func copyScores(scores map[string]float64) map[string]float64 {
+	copied := make(map[string]float64, len(scores))
+	for member, score := range scores {
+		copied[member] = score
+	}
+	return copied
+}
+
+// sortedScores returns the members of a sorted set ordered by score, with
+// members of equal score ordered lexicographically
+func sortedScores(scores map[string]float64) []SortedSetMember {
+	sorted := []SortedSetMember{}
+	for member, score := range scores {
+		sorted = append(sorted, SortedSetMember{Member: member, Score: score})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score < sorted[j].Score
+		}
+		return sorted[i].Member < sorted[j].Member
+	})
+	return sorted
+}
+
+// rangeByRank returns the members of a sorted set between an inclusive
+// start and stop rank, where negative ranks count from the highest score
+func rangeByRank(sorted []SortedSetMember, start int, stop int) []SortedSetMember {
+	offset, limit, ok := normalizeListRange(len(sorted), start, stop)
+	if !ok {
+		return []SortedSetMember{}
+	}
+
+	return append([]SortedSetMember{}, sorted[offset:offset+limit]...)
+}
+
+// rangeByScore returns the members of a sorted set with a score between an
+// inclusive min and max
+func rangeByScore(sorted []SortedSetMember, min float64, max float64) []SortedSetMember {
+	members := []SortedSetMember{}
+	for _, member := range sorted {
+		if member.Score >= min && member.Score <= max {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// rankOf returns the position of a member in a sorted set
+func rankOf(sorted []SortedSetMember, member string) (int, bool) {
+	for rank, m := range sorted {
+		if m.Member == member {
+			return rank, true
+		}
+	}
+	return 0, false
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
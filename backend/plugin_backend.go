@@ -0,0 +1,657 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+// pluginExecutablePrefix is prepended to the plugin name when searching the
+// PATH for the plugin executable
+const pluginExecutablePrefix = "prop-plugin-"
+
+func init() {
+	Register("plugin", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewPluginBackend(ctx, namespace, u)
+	})
+}
+
+// PluginBackend forwards every method call to an external plugin executable
+// speaking the plugin protocol over its stdin and stdout
+type PluginBackend struct {
+	Name      string
+	Namespace string
+	client    *pluginClient
+}
+
+// pluginClient serializes calls to a running plugin
+type pluginClient struct {
+	sync.Mutex
+	stdin   io.WriteCloser
+	encoder *json.Encoder
+	decoder *json.Decoder
+	nextID  uint64
+	closed  bool
+
+	// kill stops the plugin once the context of a call is done, unblocking
+	// any pending read of its response
+	kill func()
+
+	// wait waits for the plugin to exit once its stdin is closed
+	wait func() error
+
+	// err is set once communication with the plugin fails, as the stream
+	// can no longer be trusted
+	err error
+}
+
+// NewPluginBackend create new instance of PluginBackend, launching the
+// prop-plugin-NAME executable for a plugin+NAME:// url. The context only
+// bounds the handshake, not the lifetime of the plugin process.
+func NewPluginBackend(ctx context.Context, namespace string, url *dburl.URL) (PluginBackend, error) {
+	name := url.Transport
+	if name == "" {
+		return PluginBackend{}, fmt.Errorf("Invalid plugin url: missing plugin name")
+	}
+
+	path, err := exec.LookPath(pluginExecutablePrefix + name)
+	if err != nil {
+		return PluginBackend{}, fmt.Errorf("Unable to find plugin %s: %s", name, err.Error())
+	}
+
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return PluginBackend{}, fmt.Errorf("Unable to start plugin %s: %s", name, err.Error())
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return PluginBackend{}, fmt.Errorf("Unable to start plugin %s: %s", name, err.Error())
+	}
+	if err := cmd.Start(); err != nil {
+		return PluginBackend{}, fmt.Errorf("Unable to start plugin %s: %s", name, err.Error())
+	}
+
+	client := &pluginClient{
+		stdin:   stdin,
+		encoder: json.NewEncoder(stdin),
+		decoder: json.NewDecoder(stdout),
+		kill: func() {
+			cmd.Process.Kill()
+			stdout.Close()
+		},
+		wait: cmd.Wait,
+	}
+
+	return startPluginBackend(ctx, name, namespace, url, client)
+}
+
+// NewPluginBackendWithConn create new instance of PluginBackend speaking the
+// plugin protocol over conn instead of launching an executable, such as one
+// end of a net.Pipe whose other end is served by plugin.ServeConn. The url is
+// sent to the plugin during the handshake, and conn is closed along with the
+// backend.
+func NewPluginBackendWithConn(ctx context.Context, namespace string, url *dburl.URL, conn io.ReadWriteCloser) (PluginBackend, error) {
+	client := &pluginClient{
+		stdin:   conn,
+		encoder: json.NewEncoder(conn),
+		decoder: json.NewDecoder(conn),
+		kill: func() {
+			conn.Close()
+		},
+		wait: func() error {
+			return nil
+		},
+	}
+
+	return startPluginBackend(ctx, url.Transport, namespace, url, client)
+}
+
+// startPluginBackend performs the handshake with a started plugin, closing
+// the client if the plugin is rejected
+func startPluginBackend(ctx context.Context, name string, namespace string, url *dburl.URL, client *pluginClient) (PluginBackend, error) {
+	params := PluginHandshake{
+		ProtocolVersion: PluginProtocolVersion,
+		Namespace:       namespace,
+		URL:             url.String(),
+	}
+	var handshake PluginHandshake
+	if err := client.call(ctx, PluginMethodHandshake, params, &handshake); err != nil {
+		client.close()
+		return PluginBackend{}, fmt.Errorf("Unable to start plugin %s: %s", name, err.Error())
+	}
+	if handshake.ProtocolVersion != PluginProtocolVersion {
+		client.close()
+		return PluginBackend{}, fmt.Errorf("Unable to start plugin %s: unsupported protocol version %d", name, handshake.ProtocolVersion)
+	}
+
+	return PluginBackend{
+		Name:      name,
+		Namespace: namespace,
+		client:    client,
+	}, nil
+}
+
+// Close stops the plugin by closing its stdin
+func (backend PluginBackend) Close() error {
+	return backend.client.close()
+}
+
+func (backend PluginBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	var p PropertyCollection
+	err := backend.client.call(ctx, "BackendExport", PluginArgs{}, &p)
+	return p, err
+}
+
+func (backend PluginBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	var imported bool
+	err := backend.client.call(ctx, "BackendImport", PluginArgs{Properties: &p, Clear: clear}, &imported)
+	return imported, err
+}
+
+func (backend PluginBackend) BackendReset(ctx context.Context) (bool, error) {
+	var reset bool
+	err := backend.client.call(ctx, "BackendReset", PluginArgs{}, &reset)
+	return reset, err
+}
+
+func (backend PluginBackend) Del(ctx context.Context, key string) (bool, error) {
+	var deleted bool
+	err := backend.client.call(ctx, "Del", PluginArgs{Key: key}, &deleted)
+	return deleted, err
+}
+
+func (backend PluginBackend) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := backend.client.call(ctx, "Exists", PluginArgs{Key: key}, &exists)
+	return exists, err
+}
+
+func (backend PluginBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	var exists bool
+	err := backend.client.call(ctx, "NamespaceExists", PluginArgs{Namespace: namespace}, &exists)
+	return exists, err
+}
+
+func (backend PluginBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	var cleared bool
+	err := backend.client.call(ctx, "NamespaceClear", PluginArgs{Namespace: namespace}, &cleared)
+	return cleared, err
+}
+
+func (backend PluginBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	var value string
+	err := backend.client.call(ctx, "Get", PluginArgs{Key: key, DefaultValue: defaultValue}, &value)
+	return value, err
+}
+
+func (backend PluginBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	var value PluginValueWithRevision
+	err := backend.client.call(ctx, "GetWithRevision", PluginArgs{Key: key}, &value)
+	return value.Value, value.Revision, err
+}
+
+func (backend PluginBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	keyValuePairs := map[string]string{}
+	err := backend.client.call(ctx, "GetAll", PluginArgs{}, &keyValuePairs)
+	return keyValuePairs, err
+}
+
+func (backend PluginBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keyValuePairs := map[string]string{}
+	err := backend.client.call(ctx, "GetAllByPrefix", PluginArgs{Prefix: prefix}, &keyValuePairs)
+	return keyValuePairs, err
+}
+
+func (backend PluginBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "Set", PluginArgs{Key: key, Value: value}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "SetWithTTL", PluginArgs{Key: key, Value: value, TTL: ttl}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "SetIfNotExists", PluginArgs{Key: key, Value: value, TTL: ttl}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "SetIfValue", PluginArgs{Key: key, OldValue: oldValue, Value: value, TTL: ttl}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "SetIfRevision", PluginArgs{Key: key, Revision: revision, Value: value, TTL: ttl}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	var previous string
+	err := backend.client.call(ctx, "GetSet", PluginArgs{Key: key, Value: value}, &previous)
+	return previous, err
+}
+
+func (backend PluginBackend) Incr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := backend.client.call(ctx, "Incr", PluginArgs{Key: key}, &value)
+	return value, err
+}
+
+func (backend PluginBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	var value int64
+	err := backend.client.call(ctx, "IncrBy", PluginArgs{Key: key, Increment: increment}, &value)
+	return value, err
+}
+
+func (backend PluginBackend) Decr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := backend.client.call(ctx, "Decr", PluginArgs{Key: key}, &value)
+	return value, err
+}
+
+func (backend PluginBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var expired bool
+	err := backend.client.call(ctx, "Expire", PluginArgs{Key: key, TTL: ttl}, &expired)
+	return expired, err
+}
+
+func (backend PluginBackend) Persist(ctx context.Context, key string) (bool, error) {
+	var persisted bool
+	err := backend.client.call(ctx, "Persist", PluginArgs{Key: key}, &persisted)
+	return persisted, err
+}
+
+func (backend PluginBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := backend.client.call(ctx, "TTL", PluginArgs{Key: key}, &ttl)
+	return ttl, err
+}
+
+func (backend PluginBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	var element string
+	err := backend.client.call(ctx, "Blpop", PluginArgs{Key: key, Timeout: timeout}, &element)
+	return element, err
+}
+
+func (backend PluginBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	var element string
+	err := backend.client.call(ctx, "Brpop", PluginArgs{Key: key, Timeout: timeout}, &element)
+	return element, err
+}
+
+func (backend PluginBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	var element string
+	err := backend.client.call(ctx, "Lindex", PluginArgs{Key: key, Index: index}, &element)
+	return element, err
+}
+
+func (backend PluginBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Linsert", PluginArgs{Key: key, Position: position, Pivot: pivot, Element: element}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	var isMember bool
+	err := backend.client.call(ctx, "Lismember", PluginArgs{Key: key, Element: element}, &isMember)
+	return isMember, err
+}
+
+func (backend PluginBackend) Llen(ctx context.Context, key string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Llen", PluginArgs{Key: key}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	var element string
+	err := backend.client.call(ctx, "Lmove", PluginArgs{Source: source, Destination: destination, From: from, To: to}, &element)
+	return element, err
+}
+
+func (backend PluginBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	elements := []string{}
+	err := backend.client.call(ctx, "Lpop", PluginArgs{Key: key, Count: count}, &elements)
+	return elements, err
+}
+
+func (backend PluginBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	positions := []int{}
+	err := backend.client.call(ctx, "Lpos", PluginArgs{Key: key, Element: element, Rank: rank, Count: count}, &positions)
+	return positions, err
+}
+
+func (backend PluginBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Lpush", PluginArgs{Key: key, NewElements: newElements}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	elements := []string{}
+	err := backend.client.call(ctx, "Lrange", PluginArgs{Key: key}, &elements)
+	return elements, err
+}
+
+func (backend PluginBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	elements := []string{}
+	err := backend.client.call(ctx, "Lrangefrom", PluginArgs{Key: key, Start: start}, &elements)
+	return elements, err
+}
+
+func (backend PluginBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	elements := []string{}
+	err := backend.client.call(ctx, "Lrangefromto", PluginArgs{Key: key, Start: start, Stop: stop}, &elements)
+	return elements, err
+}
+
+func (backend PluginBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	var removed int
+	err := backend.client.call(ctx, "Lrem", PluginArgs{Key: key, CountToRemove: countToRemove, Element: element}, &removed)
+	return removed, err
+}
+
+func (backend PluginBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	var set bool
+	err := backend.client.call(ctx, "Lset", PluginArgs{Key: key, Index: index, Element: element}, &set)
+	return set, err
+}
+
+func (backend PluginBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	var trimmed bool
+	err := backend.client.call(ctx, "Ltrim", PluginArgs{Key: key, Start: start, Stop: stop}, &trimmed)
+	return trimmed, err
+}
+
+func (backend PluginBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	elements := []string{}
+	err := backend.client.call(ctx, "Rpop", PluginArgs{Key: key, Count: count}, &elements)
+	return elements, err
+}
+
+func (backend PluginBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Rpush", PluginArgs{Key: key, NewElements: newElements}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	var added int
+	err := backend.client.call(ctx, "Sadd", PluginArgs{Key: key, NewMembers: newMembers}, &added)
+	return added, err
+}
+
+func (backend PluginBackend) Scard(ctx context.Context, key string) (int, error) {
+	var count int
+	err := backend.client.call(ctx, "Scard", PluginArgs{Key: key}, &count)
+	return count, err
+}
+
+func (backend PluginBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	members := map[string]bool{}
+	err := backend.client.call(ctx, "Sdiff", PluginArgs{Keys: keys}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.client.call(ctx, "Sdiffstore", PluginArgs{Destination: destination, Keys: keys}, &count)
+	return count, err
+}
+
+func (backend PluginBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	members := map[string]bool{}
+	err := backend.client.call(ctx, "Sinter", PluginArgs{Keys: keys}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.client.call(ctx, "Sinterstore", PluginArgs{Destination: destination, Keys: keys}, &count)
+	return count, err
+}
+
+func (backend PluginBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	var isMember bool
+	err := backend.client.call(ctx, "Sismember", PluginArgs{Key: key, Member: member}, &isMember)
+	return isMember, err
+}
+
+func (backend PluginBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	members := map[string]bool{}
+	err := backend.client.call(ctx, "Smembers", PluginArgs{Key: key}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	var moved bool
+	err := backend.client.call(ctx, "Smove", PluginArgs{Source: source, Destination: destination, Member: member}, &moved)
+	return moved, err
+}
+
+func (backend PluginBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	members := []string{}
+	err := backend.client.call(ctx, "Spop", PluginArgs{Key: key, Count: count}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	members := []string{}
+	err := backend.client.call(ctx, "Srandmember", PluginArgs{Key: key, Count: count}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	var removed int
+	err := backend.client.call(ctx, "Srem", PluginArgs{Key: key, MembersToRemove: membersToRemove}, &removed)
+	return removed, err
+}
+
+func (backend PluginBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	members := map[string]bool{}
+	err := backend.client.call(ctx, "Sunion", PluginArgs{Keys: keys}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	var count int
+	err := backend.client.call(ctx, "Sunionstore", PluginArgs{Destination: destination, Keys: keys}, &count)
+	return count, err
+}
+
+func (backend PluginBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	var removed int
+	err := backend.client.call(ctx, "Hdel", PluginArgs{Key: key, FieldsToRemove: fieldsToRemove}, &removed)
+	return removed, err
+}
+
+func (backend PluginBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	var exists bool
+	err := backend.client.call(ctx, "Hexists", PluginArgs{Key: key, Field: field}, &exists)
+	return exists, err
+}
+
+func (backend PluginBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	var value string
+	err := backend.client.call(ctx, "Hget", PluginArgs{Key: key, Field: field}, &value)
+	return value, err
+}
+
+func (backend PluginBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	fields := map[string]string{}
+	err := backend.client.call(ctx, "Hgetall", PluginArgs{Key: key}, &fields)
+	return fields, err
+}
+
+func (backend PluginBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields := []string{}
+	err := backend.client.call(ctx, "Hkeys", PluginArgs{Key: key}, &fields)
+	return fields, err
+}
+
+func (backend PluginBackend) Hlen(ctx context.Context, key string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Hlen", PluginArgs{Key: key}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	var added int
+	err := backend.client.call(ctx, "Hset", PluginArgs{Key: key, Fields: fields}, &added)
+	return added, err
+}
+
+func (backend PluginBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	var added int
+	err := backend.client.call(ctx, "Zadd", PluginArgs{Key: key, Scores: scores}, &added)
+	return added, err
+}
+
+func (backend PluginBackend) Zcard(ctx context.Context, key string) (int, error) {
+	var length int
+	err := backend.client.call(ctx, "Zcard", PluginArgs{Key: key}, &length)
+	return length, err
+}
+
+func (backend PluginBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	members := []SortedSetMember{}
+	err := backend.client.call(ctx, "Zrange", PluginArgs{Key: key, Start: start, Stop: stop}, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	members := []SortedSetMember{}
+	args := PluginArgs{
+		Key: key,
+		Min: strconv.FormatFloat(min, 'g', -1, 64),
+		Max: strconv.FormatFloat(max, 'g', -1, 64),
+	}
+	err := backend.client.call(ctx, "Zrangebyscore", args, &members)
+	return members, err
+}
+
+func (backend PluginBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	var rank int
+	err := backend.client.call(ctx, "Zrank", PluginArgs{Key: key, Member: member}, &rank)
+	return rank, err
+}
+
+func (backend PluginBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	var removed int
+	err := backend.client.call(ctx, "Zrem", PluginArgs{Key: key, MembersToRemove: membersToRemove}, &removed)
+	return removed, err
+}
+
+func (backend PluginBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	var score float64
+	err := backend.client.call(ctx, "Zscore", PluginArgs{Key: key, Member: member}, &score)
+	return score, err
+}
+
+// Watch polls the plugin for changes to keys starting with prefix, using
+// exports of the backend
+func (backend PluginBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	return pollWatch(ctx, backend.Namespace, exportSnapshot(backend, backend.Namespace, prefix))
+}
+
+// call sends a single request to the plugin and decodes the result. As a
+// response cannot be abandoned without corrupting the stream, the plugin
+// process is killed if the context is done before the response arrives.
+func (client *pluginClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	client.Lock()
+	defer client.Unlock()
+
+	if client.err != nil {
+		return client.err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("Unable to encode plugin request: %s", err.Error())
+	}
+
+	client.nextID++
+	request := PluginRequest{
+		JSONRPC: "2.0",
+		ID:      client.nextID,
+		Method:  method,
+		Params:  raw,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		request.Deadline = &deadline
+	}
+
+	stop := context.AfterFunc(ctx, client.kill)
+	defer stop()
+
+	if err := client.encoder.Encode(request); err != nil {
+		return client.fail(ctx, err)
+	}
+
+	var response PluginResponse
+	if err := client.decoder.Decode(&response); err != nil {
+		return client.fail(ctx, err)
+	}
+	if response.ID != request.ID {
+		client.err = fmt.Errorf("Unable to communicate with plugin: unexpected response id %d", response.ID)
+		return client.err
+	}
+
+	if response.Error != nil {
+		return response.Error
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("Invalid plugin response for %s: %s", method, err.Error())
+	}
+
+	return nil
+}
+
+// fail records a communication error, returning the context error instead
+// if the plugin was killed because the context is done
+func (client *pluginClient) fail(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		client.err = fmt.Errorf("Plugin was stopped: %s", ctxErr.Error())
+		return ctxErr
+	}
+
+	client.err = fmt.Errorf("Unable to communicate with plugin: %s", err.Error())
+	return client.err
+}
+
+// close closes the plugin stdin and waits for the plugin to exit
+func (client *pluginClient) close() error {
+	client.Lock()
+	defer client.Unlock()
+
+	if client.closed {
+		return nil
+	}
+
+	client.closed = true
+	client.err = fmt.Errorf("Plugin is closed")
+	client.stdin.Close()
+	return client.wait()
+}
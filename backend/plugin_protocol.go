@@ -0,0 +1,197 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PluginProtocolVersion is the version of the plugin protocol spoken by this
+// package. Plugins are rejected during the handshake if their version differs.
+// It is incremented whenever methods or params are added to the protocol.
+const PluginProtocolVersion = 11
+
+const (
+	// PluginMethodHandshake is the first method called on every plugin
+	PluginMethodHandshake = "Handshake"
+
+	// PluginErrorCodeParse is returned for requests that are not valid json
+	PluginErrorCodeParse = -32700
+
+	// PluginErrorCodeInvalidRequest is returned for requests sent out of order
+	PluginErrorCodeInvalidRequest = -32600
+
+	// PluginErrorCodeMethodNotFound is returned for unknown methods
+	PluginErrorCodeMethodNotFound = -32601
+
+	// PluginErrorCodeInvalidParams is returned for params that cannot be decoded
+	PluginErrorCodeInvalidParams = -32602
+
+	// PluginErrorCodeBackend is returned for errors returned by the backend
+	// that do not match any of the more specific codes
+	PluginErrorCodeBackend = -32000
+
+	// PluginErrorCodeKeyNotFound is returned for ErrKeyNotFound errors
+	PluginErrorCodeKeyNotFound = -32001
+
+	// PluginErrorCodeWrongType is returned for ErrWrongType errors
+	PluginErrorCodeWrongType = -32002
+
+	// PluginErrorCodeIndexOutOfRange is returned for ErrIndexOutOfRange errors
+	PluginErrorCodeIndexOutOfRange = -32003
+
+	// PluginErrorCodeNotImplemented is returned for ErrNotImplemented errors
+	PluginErrorCodeNotImplemented = -32004
+
+	// PluginErrorCodeInvalidKey is returned for ErrInvalidKey errors
+	PluginErrorCodeInvalidKey = -32005
+
+	// PluginErrorCodeInvalidNamespace is returned for ErrInvalidNamespace errors
+	PluginErrorCodeInvalidNamespace = -32006
+
+	// PluginErrorCodeInvalidValue is returned for ErrInvalidValue errors
+	PluginErrorCodeInvalidValue = -32007
+
+	// PluginErrorCodeLockTimeout is returned for ErrLockTimeout errors
+	PluginErrorCodeLockTimeout = -32008
+
+	// PluginErrorCodeConditionFailed is returned for ErrConditionFailed errors
+	PluginErrorCodeConditionFailed = -32009
+
+	// PluginErrorCodeFieldNotFound is returned for ErrFieldNotFound errors
+	PluginErrorCodeFieldNotFound = -32010
+
+	// PluginErrorCodeMemberNotFound is returned for ErrMemberNotFound errors
+	PluginErrorCodeMemberNotFound = -32011
+
+	// PluginErrorCodeNotInteger is returned for ErrNotInteger errors
+	PluginErrorCodeNotInteger = -32012
+
+	// PluginErrorCodeElementNotFound is returned for ErrElementNotFound errors
+	PluginErrorCodeElementNotFound = -32013
+)
+
+// pluginErrorCodes maps error codes to the errors they are returned for
+var pluginErrorCodes = map[int]error{
+	PluginErrorCodeKeyNotFound:      ErrKeyNotFound,
+	PluginErrorCodeWrongType:        ErrWrongType,
+	PluginErrorCodeIndexOutOfRange:  ErrIndexOutOfRange,
+	PluginErrorCodeNotImplemented:   ErrNotImplemented,
+	PluginErrorCodeInvalidKey:       ErrInvalidKey,
+	PluginErrorCodeInvalidNamespace: ErrInvalidNamespace,
+	PluginErrorCodeInvalidValue:     ErrInvalidValue,
+	PluginErrorCodeLockTimeout:      ErrLockTimeout,
+	PluginErrorCodeConditionFailed:  ErrConditionFailed,
+	PluginErrorCodeFieldNotFound:    ErrFieldNotFound,
+	PluginErrorCodeMemberNotFound:   ErrMemberNotFound,
+	PluginErrorCodeNotInteger:       ErrNotInteger,
+	PluginErrorCodeElementNotFound:  ErrElementNotFound,
+}
+
+// PluginRequest is a json-rpc 2.0 request sent to a plugin over its stdin,
+// one request per line. Method is either PluginMethodHandshake or the name of
+// a Backend interface method. Deadline is set when the caller's context has
+// one, and is applied to the context passed to the backend.
+type PluginRequest struct {
+	JSONRPC  string          `json:"jsonrpc"`
+	ID       uint64          `json:"id"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Deadline *time.Time      `json:"deadline,omitempty"`
+}
+
+// PluginResponse is a json-rpc 2.0 response written by a plugin to its
+// stdout, one response per line
+type PluginResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *PluginError    `json:"error,omitempty"`
+}
+
+// PluginError is the error member of a json-rpc 2.0 response
+type PluginError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewPluginError returns the PluginError sent for an error returned by a backend
+func NewPluginError(err error) *PluginError {
+	for code, target := range pluginErrorCodes {
+		if errors.Is(err, target) {
+			return &PluginError{Code: code, Message: err.Error()}
+		}
+	}
+
+	return &PluginError{Code: PluginErrorCodeBackend, Message: err.Error()}
+}
+
+func (e *PluginError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error matching the error code, so that callers may
+// match errors returned by plugins with errors.Is. Methods unknown to the
+// plugin are reported as ErrNotImplemented.
+func (e *PluginError) Unwrap() error {
+	if e.Code == PluginErrorCodeMethodNotFound {
+		return ErrNotImplemented
+	}
+
+	return pluginErrorCodes[e.Code]
+}
+
+// PluginHandshake is exchanged as the params and result of the handshake.
+// Namespace and URL are only set in the params.
+type PluginHandshake struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Namespace       string `json:"namespace,omitempty"`
+	URL             string `json:"url,omitempty"`
+}
+
+// PluginValueWithRevision is the result of GetWithRevision
+type PluginValueWithRevision struct {
+	Value    string `json:"value"`
+	Revision int64  `json:"revision"`
+}
+
+// PluginArgs holds the arguments of a Backend method call, named after the
+// parameters of the interface method
+type PluginArgs struct {
+	Clear           bool                `json:"clear,omitempty"`
+	Count           int                 `json:"count,omitempty"`
+	CountToRemove   int                 `json:"count_to_remove,omitempty"`
+	DefaultValue    string              `json:"default_value,omitempty"`
+	Destination     string              `json:"destination,omitempty"`
+	Element         string              `json:"element,omitempty"`
+	Field           string              `json:"field,omitempty"`
+	Fields          map[string]string   `json:"fields,omitempty"`
+	FieldsToRemove  []string            `json:"fields_to_remove,omitempty"`
+	From            string              `json:"from,omitempty"`
+	Increment       int64               `json:"increment,omitempty"`
+	Index           int                 `json:"index,omitempty"`
+	Key             string              `json:"key,omitempty"`
+	Keys            []string            `json:"keys,omitempty"`
+	Max             string              `json:"max,omitempty"`
+	Member          string              `json:"member,omitempty"`
+	MembersToRemove []string            `json:"members_to_remove,omitempty"`
+	Min             string              `json:"min,omitempty"`
+	Namespace       string              `json:"namespace,omitempty"`
+	OldValue        string              `json:"old_value,omitempty"`
+	NewElements     []string            `json:"new_elements,omitempty"`
+	NewMembers      []string            `json:"new_members,omitempty"`
+	Pivot           string              `json:"pivot,omitempty"`
+	Position        string              `json:"position,omitempty"`
+	Prefix          string              `json:"prefix,omitempty"`
+	Properties      *PropertyCollection `json:"properties,omitempty"`
+	Rank            int                 `json:"rank,omitempty"`
+	Revision        int64               `json:"revision,omitempty"`
+	Scores          map[string]float64  `json:"scores,omitempty"`
+	Source          string              `json:"source,omitempty"`
+	Start           int                 `json:"start,omitempty"`
+	Stop            int                 `json:"stop,omitempty"`
+	Timeout         time.Duration       `json:"timeout,omitempty"`
+	To              string              `json:"to,omitempty"`
+	TTL             time.Duration       `json:"ttl,omitempty"`
+	Value           string              `json:"value,omitempty"`
+}
@@ -0,0 +1,32 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dokku/prop/backend"
+)
+
+func TestPluginErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "key not found", err: backend.NewPluginError(backend.ErrKeyNotFound), expected: backend.ErrKeyNotFound},
+		{name: "not implemented", err: backend.NewPluginError(backend.ErrNotImplemented), expected: backend.ErrNotImplemented},
+		{name: "method not found", err: &backend.PluginError{Code: backend.PluginErrorCodeMethodNotFound, Message: "Unknown method Lmove"}, expected: backend.ErrNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.expected) {
+				t.Errorf("errors.Is(%v, %v) returned false", tt.err, tt.expected)
+			}
+		})
+	}
+
+	if err := backend.NewPluginError(errors.New("Other")); errors.Is(err, backend.ErrNotImplemented) {
+		t.Errorf("errors.Is(%v, %v) returned true", err, backend.ErrNotImplemented)
+	}
+}
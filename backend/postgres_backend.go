@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xo/dburl"
+)
+
+// postgresSchemaLock is the advisory lock id held while creating the schema
+const postgresSchemaLock = 8675309
+
+// postgresWatchChannel is the channel notified by the properties table
+// trigger with the namespace and key of every modified row
+const postgresWatchChannel = "prop_changes"
+
+// postgresDialect creates the documented properties table, using advisory
+// locks to serialize schema creation and read-modify-write operations
+var postgresDialect = sqlDialect{
+	schema: []string{
+		`DO $$ BEGIN
+			CREATE TYPE "data_types" AS ENUM ('key_value', 'list', 'set', 'hash', 'sorted_set');
+		EXCEPTION
+			WHEN duplicate_object THEN null;
+		END $$`,
+		`CREATE TABLE IF NOT EXISTS "properties" (
+			"id" SERIAL PRIMARY KEY,
+			"namespace" varchar NOT NULL DEFAULT 'default',
+			"data_type" data_types NOT NULL,
+			"key" varchar NOT NULL,
+			"value" text NOT NULL,
+			"created_at" timestamp
+		)`,
+		`CREATE INDEX IF NOT EXISTS "namespace_by_data_type" ON "properties" ("namespace", "data_type")`,
+		`CREATE INDEX IF NOT EXISTS "namespace_by_key" ON "properties" ("namespace", "key")`,
+		`CREATE TABLE IF NOT EXISTS "expirations" (
+			"namespace" varchar NOT NULL,
+			"key" varchar NOT NULL,
+			"expires_at" bigint NOT NULL,
+			PRIMARY KEY ("namespace", "key")
+		)`,
+		`CREATE INDEX IF NOT EXISTS "expirations_by_expires_at" ON "expirations" ("expires_at")`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "properties_id_idx" ON "properties" ("id")`,
+		`CREATE OR REPLACE FUNCTION "prop_notify_change"() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				PERFORM pg_notify('` + postgresWatchChannel + `', json_build_object('namespace', OLD."namespace", 'key', OLD."key")::text);
+			ELSE
+				PERFORM pg_notify('` + postgresWatchChannel + `', json_build_object('namespace', NEW."namespace", 'key', NEW."key")::text);
+			END IF;
+			RETURN NULL;
+		END $$ LANGUAGE plpgsql`,
+		`DO $$ BEGIN
+			CREATE TRIGGER "properties_notify_change" AFTER INSERT OR UPDATE OR DELETE ON "properties"
+				FOR EACH ROW EXECUTE PROCEDURE "prop_notify_change"();
+		EXCEPTION
+			WHEN duplicate_object THEN null;
+		END $$`,
+	},
+	lockSchema: func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresSchemaLock)
+		return err
+	},
+	upgradeSchema: upgradePostgresSchema,
+	lockKey: func(ctx context.Context, tx *sql.Tx, name string) error {
+		_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, name)
+		return err
+	},
+	rebind: func(query string) string {
+		return query
+	},
+}
+
+// postgresEnumValues are the data types added to the data_types enum after
+// it was first created, which are added to existing databases on startup
+var postgresEnumValues = []string{"hash", "sorted_set"}
+
+// upgradePostgresSchema adds missing values to the data_types enum. Values
+// added within a transaction may not be used until it commits, and older
+// servers refuse to add them within one at all, so each is added on its own
+// connection while holding the schema lock for the session.
+func upgradePostgresSchema(ctx context.Context, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresSchemaLock); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresSchemaLock)
+
+	for _, value := range postgresEnumValues {
+		if _, err := conn.ExecContext(ctx, `ALTER TYPE "data_types" ADD VALUE IF NOT EXISTS `+pq.QuoteLiteral(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register("postgres", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewPostgresBackend(ctx, namespace, u)
+	})
+}
+
+type PostgresBackend struct {
+	sqlBackend
+
+	// dsn is used to open the connection listening for changes, and is
+	// empty for backends constructed with an existing database handle
+	dsn string
+}
+
+// NewPostgresBackend create new instance of PostgresBackend
+func NewPostgresBackend(ctx context.Context, namespace string, url *dburl.URL) (PostgresBackend, error) {
+	u := *url
+	query := u.Query()
+	query.Del("namespace")
+	u.RawQuery = query.Encode()
+
+	dsn, _, err := dburl.GenPostgres(&u)
+	if err != nil {
+		return PostgresBackend{}, fmt.Errorf("Invalid postgres url: %s", err.Error())
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return PostgresBackend{}, fmt.Errorf("Unable to connect to postgres: %s", err.Error())
+	}
+
+	backend, err := NewPostgresBackendWithDB(ctx, namespace, db)
+	if err != nil {
+		return backend, err
+	}
+
+	backend.dsn = dsn
+	return backend, nil
+}
+
+// NewPostgresBackendWithDB create new instance of PostgresBackend using an
+// existing database handle, creating the properties table if necessary
+func NewPostgresBackendWithDB(ctx context.Context, namespace string, db *sql.DB) (PostgresBackend, error) {
+	backend, err := newSQLBackend(ctx, namespace, db, postgresDialect)
+	if err != nil {
+		return PostgresBackend{}, err
+	}
+
+	return PostgresBackend{sqlBackend: backend}, nil
+}
+
+// Watch listens for the notifications sent by the properties table trigger
+// for keys starting with prefix. Expired keys are reported once they are
+// purged by the next operation on the backend. Backends constructed with an
+// existing database handle poll the properties table instead.
+func (backend PostgresBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	if backend.dsn == "" {
+		return backend.sqlBackend.Watch(ctx, prefix)
+	}
+
+	listener := pq.NewListener(backend.dsn, 10*time.Millisecond, time.Minute, nil)
+	if err := listener.Listen(postgresWatchChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer listener.Close()
+
+		for {
+			var notification *pq.Notification
+			select {
+			case <-ctx.Done():
+				return
+			case notification = <-listener.Notify:
+			}
+
+			// a nil notification is sent after reconnecting
+			if notification == nil {
+				continue
+			}
+
+			var change struct {
+				Namespace string `json:"namespace"`
+				Key       string `json:"key"`
+			}
+			if err := json.Unmarshal([]byte(notification.Extra), &change); err != nil {
+				continue
+			}
+			if change.Namespace != backend.Namespace || !strings.HasPrefix(change.Key, prefix) {
+				continue
+			}
+
+			event := Event{Type: EventChanged, Namespace: change.Namespace, Key: change.Key}
+			if exists, err := backend.Exists(ctx, change.Key); err == nil && !exists {
+				event.Type = EventDeleted
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
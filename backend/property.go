@@ -1,5 +1,29 @@
 package backend
 
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// DataTypeKeyValue is the data type of a property holding a single string
+	DataTypeKeyValue = "key_value"
+
+	// DataTypeList is the data type of a property holding an ordered list of strings
+	DataTypeList = "list"
+
+	// DataTypeSet is the data type of a property holding a set of strings
+	DataTypeSet = "set"
+
+	// DataTypeHash is the data type of a property holding a map of fields to
+	// string values
+	DataTypeHash = "hash"
+
+	// DataTypeSortedSet is the data type of a property holding a set of
+	// strings, each ordered by a numeric score
+	DataTypeSortedSet = "sorted_set"
+)
+
 type Property struct {
 	DataType  string
 	Namespace string
@@ -10,3 +34,100 @@ type Property struct {
 type PropertyCollection struct {
 	Properties []Property
 }
+
+// SortedSetMember is a member of a sorted set and its score
+type SortedSetMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// StringValue returns the value of a key_value property
+func (p Property) StringValue() (string, error) {
+	value, ok := p.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+	}
+
+	return value, nil
+}
+
+// ListValue returns the value of a list or set property. Values that were
+// decoded from json are converted from []interface{} as necessary.
+func (p Property) ListValue() ([]string, error) {
+	switch value := p.Value.(type) {
+	case []string:
+		return value, nil
+	case []interface{}:
+		elements := []string{}
+		for _, v := range value {
+			element, ok := v.(string)
+			if !ok {
+				return []string{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+			}
+			elements = append(elements, element)
+		}
+		return elements, nil
+	case map[string]bool:
+		members := []string{}
+		for member := range value {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		return members, nil
+	}
+
+	return []string{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+}
+
+// HashValue returns the value of a hash property. Values that were decoded
+// from json are converted from map[string]interface{} as necessary.
+func (p Property) HashValue() (map[string]string, error) {
+	switch value := p.Value.(type) {
+	case map[string]string:
+		return value, nil
+	case map[string]interface{}:
+		fields := map[string]string{}
+		for field, v := range value {
+			fieldValue, ok := v.(string)
+			if !ok {
+				return map[string]string{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+			}
+			fields[field] = fieldValue
+		}
+		return fields, nil
+	}
+
+	return map[string]string{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+}
+
+// SortedSetValue returns the value of a sorted_set property as a map of
+// members to scores. Values that were decoded from json are converted from
+// map[string]interface{} as necessary.
+func (p Property) SortedSetValue() (map[string]float64, error) {
+	switch value := p.Value.(type) {
+	case map[string]float64:
+		return value, nil
+	case map[string]interface{}:
+		scores := map[string]float64{}
+		for member, v := range value {
+			score, ok := v.(float64)
+			if !ok {
+				return map[string]float64{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+			}
+			scores[member] = score
+		}
+		return scores, nil
+	}
+
+	return map[string]float64{}, fmt.Errorf("Invalid value for property %s.%s", p.Namespace, p.Key)
+}
+
+// propertyNamespaces returns the namespace of each property in a collection
+func propertyNamespaces(p PropertyCollection) []string {
+	namespaces := []string{}
+	for _, property := range p.Properties {
+		namespaces = append(namespaces, property.Namespace)
+	}
+
+	return namespaces
+}
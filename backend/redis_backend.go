@@ -0,0 +1,1264 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xo/dburl"
+)
+
+// redisNamespaceDelimiter separates the namespace from the key name
+const redisNamespaceDelimiter = ":"
+
+// redisRevisionsKey is the hash holding the revision of every key-value
+// written by the backend, under the namespaced name of the key. It does not
+// contain the delimiter, so it is neither exported nor reset, and revisions
+// keep counting up when a key is deleted and written again.
+const redisRevisionsKey = "prop-revisions"
+
+// lismemberScript returns 1 if ARGV[1] is an element of the list at KEYS[1]
+var lismemberScript = redis.NewScript(`
+local elements = redis.call('LRANGE', KEYS[1], 0, -1)
+for _, element in ipairs(elements) do
+  if element == ARGV[1] then
+    return 1
+  end
+end
+return 0
+`)
+
+// lsetScript sets the element at index ARGV[1] of the list at KEYS[1] to
+// ARGV[2], resolving negative indexes against the length of the list
+var lsetScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return redis.error_reply('ERR no such key')
+end
+local length = redis.call('LLEN', KEYS[1])
+local index = tonumber(ARGV[1])
+if index < 0 then
+  index = length + index
+end
+if index < 0 or index >= length then
+  return redis.error_reply('ERR index out of range')
+end
+redis.call('LSET', KEYS[1], index, ARGV[2])
+return 1
+`)
+
+// setStoreScript runs the store command ARGV[1], such as SUNIONSTORE, on the
+// destination KEYS[1] and the sets KEYS[2..]. The destination is removed when
+// the result is empty, which not every redis implementation does.
+var setStoreScript = redis.NewScript(`
+local count = redis.call(ARGV[1], unpack(KEYS))
+if count == 0 then
+  redis.call('DEL', KEYS[1])
+end
+return count
+`)
+
+// setScript sets the string at KEYS[1] to ARGV[1] and increments its
+// revision in the hash KEYS[2]. The key expires after ARGV[2] milliseconds if
+// it is positive, and otherwise any expiry is cleared as SET does. ARGV[3]
+// names a condition checked first: "nx" requires the key to not exist,
+// "value" requires it to hold ARGV[4], and "revision" requires its revision
+// to be ARGV[4], which is 0 for a key that does not exist. Returns 0 if the
+// condition does not hold.
+var setScript = redis.NewScript(`
+local condition = ARGV[3]
+if condition == 'nx' and redis.call('EXISTS', KEYS[1]) == 1 then
+  return 0
+end
+if condition == 'value' and redis.call('GET', KEYS[1]) ~= ARGV[4] then
+  return 0
+end
+if condition == 'revision' then
+  local revision = 0
+  if redis.call('GET', KEYS[1]) then
+    revision = tonumber(redis.call('HGET', KEYS[2], KEYS[1])) or -1
+  end
+  if revision ~= tonumber(ARGV[4]) then
+    return 0
+  end
+end
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+redis.call('HINCRBY', KEYS[2], KEYS[1], 1)
+return 1
+`)
+
+// getWithRevisionScript returns the string at KEYS[1] and its revision in the
+// hash KEYS[2], or false if the key does not exist. Keys written by other
+// clients are assigned a revision when first read.
+var getWithRevisionScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if not value then
+  return false
+end
+local revision = redis.call('HGET', KEYS[2], KEYS[1])
+if not revision then
+  revision = redis.call('HINCRBY', KEYS[2], KEYS[1], 1)
+end
+return {value, tonumber(revision)}
+`)
+
+// getSetScript sets the string at KEYS[1] to ARGV[1], increments its revision
+// in the hash KEYS[2] and returns the previous value
+var getSetScript = redis.NewScript(`
+local previous = redis.call('GETSET', KEYS[1], ARGV[1])
+redis.call('HINCRBY', KEYS[2], KEYS[1], 1)
+return previous
+`)
+
+// incrByScript increments the integer at KEYS[1] by ARGV[1], increments its
+// revision in the hash KEYS[2] and returns the new value
+var incrByScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+redis.call('HINCRBY', KEYS[2], KEYS[1], 1)
+return value
+`)
+
+func init() {
+	dburl.Register(dburl.Scheme{
+		Driver:    "redis",
+		Generator: dburl.GenScheme("redis"),
+		Transport: dburl.TransportTCP | dburl.TransportUnix,
+	})
+
+	Register("redis", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewRedisBackend(namespace, u)
+	})
+}
+
+type RedisBackend struct {
+	Namespace string
+	Client    *redis.Client
+}
+
+// NewRedisBackend create new instance of RedisBackend
+func NewRedisBackend(namespace string, url *dburl.URL) (RedisBackend, error) {
+	u := url.URL
+	query := u.Query()
+	query.Del("namespace")
+	u.RawQuery = query.Encode()
+
+	options, err := redis.ParseURL(u.String())
+	if err != nil {
+		return RedisBackend{}, fmt.Errorf("Invalid redis url: %s", err.Error())
+	}
+	options.ContextTimeoutEnabled = true
+
+	return NewRedisBackendWithClient(namespace, redis.NewClient(options)), nil
+}
+
+// NewRedisBackendWithClient create new instance of RedisBackend using an existing client
+func NewRedisBackendWithClient(namespace string, client *redis.Client) RedisBackend {
+	backend := RedisBackend{}
+	backend.Namespace = namespace
+	backend.Client = client
+	return backend
+}
+
+// inNamespace returns a copy of the backend bound to another namespace
+func (backend RedisBackend) inNamespace(namespace string) Backend {
+	backend.Namespace = namespace
+	return backend
+}
+
+func (backend RedisBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	properties := PropertyCollection{Properties: []Property{}}
+	keys, err := backend.scanKeys(ctx, "*"+redisNamespaceDelimiter+"*")
+	if err != nil {
+		return properties, err
+	}
+
+	for _, fullKey := range keys {
+		parts := strings.SplitN(fullKey, redisNamespaceDelimiter, 2)
+		property := Property{
+			Namespace: parts[0],
+			Key:       parts[1],
+		}
+
+		dataType, err := backend.Client.Type(ctx, fullKey).Result()
+		if err != nil {
+			return properties, err
+		}
+
+		switch dataType {
+		case "string":
+			property.DataType = DataTypeKeyValue
+			property.Value, err = backend.Client.Get(ctx, fullKey).Result()
+		case "list":
+			property.DataType = DataTypeList
+			property.Value, err = backend.Client.LRange(ctx, fullKey, 0, -1).Result()
+		case "set":
+			var members []string
+			members, err = backend.Client.SMembers(ctx, fullKey).Result()
+			sort.Strings(members)
+			property.DataType = DataTypeSet
+			property.Value = members
+		case "hash":
+			property.DataType = DataTypeHash
+			property.Value, err = backend.Client.HGetAll(ctx, fullKey).Result()
+		case "zset":
+			var members []redis.Z
+			members, err = backend.Client.ZRangeWithScores(ctx, fullKey, 0, -1).Result()
+			property.DataType = DataTypeSortedSet
+			property.Value = redisScores(members)
+		default:
+			continue
+		}
+
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return properties, err
+		}
+
+		properties.Properties = append(properties.Properties, property)
+	}
+
+	return properties, nil
+}
+
+func (backend RedisBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	if clear {
+		if _, err := backend.BackendReset(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	for _, property := range p.Properties {
+		fullKey := property.Namespace + redisNamespaceDelimiter + property.Key
+		_, err := backend.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, fullKey)
+			switch property.DataType {
+			case DataTypeKeyValue:
+				value, err := property.StringValue()
+				if err != nil {
+					return err
+				}
+				pipe.Set(ctx, fullKey, value, 0)
+				pipe.HIncrBy(ctx, redisRevisionsKey, fullKey, 1)
+			case DataTypeList:
+				elements, err := property.ListValue()
+				if err != nil {
+					return err
+				}
+				if len(elements) > 0 {
+					pipe.RPush(ctx, fullKey, stringsToInterfaces(elements)...)
+				}
+			case DataTypeSet:
+				members, err := property.ListValue()
+				if err != nil {
+					return err
+				}
+				if len(members) > 0 {
+					pipe.SAdd(ctx, fullKey, stringsToInterfaces(members)...)
+				}
+			case DataTypeHash:
+				fields, err := property.HashValue()
+				if err != nil {
+					return err
+				}
+				if len(fields) > 0 {
+					pipe.HSet(ctx, fullKey, fields)
+				}
+			case DataTypeSortedSet:
+				scores, err := property.SortedSetValue()
+				if err != nil {
+					return err
+				}
+				if len(scores) > 0 {
+					pipe.ZAdd(ctx, fullKey, redisMembers(scores)...)
+				}
+			default:
+				return fmt.Errorf("Invalid data type for property %s.%s: %s", property.Namespace, property.Key, property.DataType)
+			}
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) BackendReset(ctx context.Context) (bool, error) {
+	keys, err := backend.scanKeys(ctx, "*"+redisNamespaceDelimiter+"*")
+	if err != nil {
+		return false, err
+	}
+
+	if err := backend.deleteKeys(ctx, keys); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) Del(ctx context.Context, key string) (bool, error) {
+	if err := backend.Client.Del(ctx, backend.getKey(key)).Err(); err != nil {
+		return false, fmt.Errorf("Unable to remove key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) Exists(ctx context.Context, key string) (bool, error) {
+	count, err := backend.Client.Exists(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (backend RedisBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	pattern := escapeRedisPattern(namespace+redisNamespaceDelimiter) + "*"
+	iter := backend.Client.Scan(ctx, 0, pattern, 1000).Iterator()
+	if iter.Next(ctx) {
+		return true, nil
+	}
+
+	return false, iter.Err()
+}
+
+func (backend RedisBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	keys, err := backend.scanKeys(ctx, escapeRedisPattern(namespace+redisNamespaceDelimiter)+"*")
+	if err != nil {
+		return false, err
+	}
+
+	if err := backend.deleteKeys(ctx, keys); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	value, err := backend.Client.Get(ctx, backend.getKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		if defaultValue != "" {
+			return defaultValue, nil
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return "", backend.redisError(key, err)
+	}
+
+	return value, nil
+}
+
+func (backend RedisBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	result, err := getWithRevisionScript.Run(ctx, backend.Client, []string{backend.getKey(key), redisRevisionsKey}).Slice()
+	if errors.Is(err, redis.Nil) {
+		return "", 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return "", 0, backend.redisError(key, err)
+	}
+
+	value, _ := result[0].(string)
+	revision, _ := result[1].(int64)
+	return value, revision, nil
+}
+
+func (backend RedisBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	return backend.GetAllByPrefix(ctx, "")
+}
+
+func (backend RedisBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keyValuePairs := make(map[string]string)
+	keys, err := backend.scanKeys(ctx, escapeRedisPattern(backend.getKey(prefix))+"*")
+	if err != nil {
+		return keyValuePairs, err
+	}
+
+	pipe := backend.Client.Pipeline()
+	types := make(map[string]*redis.StatusCmd)
+	for _, fullKey := range keys {
+		types[fullKey] = pipe.Type(ctx, fullKey)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return keyValuePairs, err
+	}
+
+	for fullKey, cmd := range types {
+		if cmd.Val() != "string" {
+			continue
+		}
+
+		value, err := backend.Client.Get(ctx, fullKey).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return keyValuePairs, err
+		}
+
+		keyValuePairs[strings.TrimPrefix(fullKey, backend.getKey(""))] = value
+	}
+
+	return keyValuePairs, nil
+}
+
+func (backend RedisBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return backend.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL sets the key and its expiry with a single SET command, run by
+// setScript along with incrementing the revision of the key
+func (backend RedisBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if _, err := backend.set(ctx, key, value, ttl, ""); err != nil {
+		return false, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	set, err := backend.set(ctx, key, value, ttl, "nx")
+	if err != nil {
+		return false, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+	if !set {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	set, err := backend.set(ctx, key, value, ttl, "value", oldValue)
+	if err != nil {
+		return false, backend.redisError(key, err)
+	}
+	if !set {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	set, err := backend.set(ctx, key, value, ttl, "revision", revision)
+	if err != nil {
+		return false, backend.redisError(key, err)
+	}
+	if !set {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return true, nil
+}
+
+// set runs setScript, returning false if the condition does not hold
+func (backend RedisBackend) set(ctx context.Context, key string, value string, ttl time.Duration, condition string, expected ...interface{}) (bool, error) {
+	args := append([]interface{}{value, positiveTTL(ttl).Milliseconds(), condition}, expected...)
+	set, err := setScript.Run(ctx, backend.Client, []string{backend.getKey(key), redisRevisionsKey}, args...).Int()
+	return set == 1, err
+}
+
+func (backend RedisBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	previous, err := getSetScript.Run(ctx, backend.Client, []string{backend.getKey(key), redisRevisionsKey}, value).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", backend.redisError(key, err)
+	}
+
+	return previous, nil
+}
+
+func (backend RedisBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, 1)
+}
+
+func (backend RedisBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	value, err := incrByScript.Run(ctx, backend.Client, []string{backend.getKey(key), redisRevisionsKey}, increment).Int64()
+	if err != nil {
+		if strings.Contains(err.Error(), "not an integer") || strings.Contains(err.Error(), "overflow") {
+			return 0, newKeyError(backend.Namespace, key, ErrNotInteger)
+		}
+		return 0, backend.redisError(key, err)
+	}
+
+	return value, nil
+}
+
+func (backend RedisBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, -1)
+}
+
+func (backend RedisBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		count, err := backend.Client.Del(ctx, backend.getKey(key)).Result()
+		if err != nil {
+			return false, fmt.Errorf("Unable to remove key %s.%s: %s", backend.Namespace, key, err.Error())
+		}
+
+		return count > 0, nil
+	}
+
+	return backend.Client.PExpire(ctx, backend.getKey(key), ttl).Result()
+}
+
+func (backend RedisBackend) Persist(ctx context.Context, key string) (bool, error) {
+	return backend.Client.Persist(ctx, backend.getKey(key)).Result()
+}
+
+func (backend RedisBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := backend.Client.PTTL(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL replies with -2 for a missing key and -1 for a key without an expiry
+	switch ttl {
+	case -2:
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	case -1:
+		return NoExpiration, nil
+	}
+
+	return ttl, nil
+}
+
+func (backend RedisBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return backend.blockingPop(ctx, key, timeout, backend.Client.BLPop)
+}
+
+func (backend RedisBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return backend.blockingPop(ctx, key, timeout, backend.Client.BRPop)
+}
+
+func (backend RedisBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	element, err := backend.Client.LIndex(ctx, backend.getKey(key), int64(index)).Result()
+	if errors.Is(err, redis.Nil) {
+		if exists, _ := backend.Exists(ctx, key); !exists {
+			return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+	if err != nil {
+		return "", backend.redisError(key, err)
+	}
+
+	return element, nil
+}
+
+func (backend RedisBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := validateListPosition(backend.Namespace, key, position); err != nil {
+		return 0, err
+	}
+
+	length, err := backend.Client.LInsert(ctx, backend.getKey(key), position, pivot, element).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+	if length == 0 {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if length < 0 {
+		return 0, newKeyError(backend.Namespace, key, ErrElementNotFound)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	isMember, err := lismemberScript.Run(ctx, backend.Client, []string{backend.getKey(key)}, element).Int()
+	if err != nil {
+		return false, backend.redisError(key, err)
+	}
+
+	return isMember == 1, nil
+}
+
+func (backend RedisBackend) Llen(ctx context.Context, key string) (int, error) {
+	length, err := backend.Client.LLen(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := validateListDirections(backend.Namespace, source, from, to); err != nil {
+		return "", err
+	}
+
+	element, err := backend.Client.LMove(ctx, backend.getKey(source), backend.getKey(destination), strings.ToUpper(from), strings.ToUpper(to)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", newKeyError(backend.Namespace, source, ErrKeyNotFound)
+	}
+	if err != nil {
+		return "", backend.redisError(source, err)
+	}
+
+	return element, nil
+}
+
+func (backend RedisBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	elements, err := backend.Client.LPopCount(ctx, backend.getKey(key), count).Result()
+	if errors.Is(err, redis.Nil) {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+
+	return elements, nil
+}
+
+func (backend RedisBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := validateListPositionOptions(backend.Namespace, key, rank, count); err != nil {
+		return []int{}, err
+	}
+
+	results, err := backend.Client.LPosCount(ctx, backend.getKey(key), element, int64(count), redis.LPosArgs{Rank: int64(rank)}).Result()
+	if errors.Is(err, redis.Nil) {
+		return []int{}, nil
+	}
+	if err != nil {
+		return []int{}, backend.redisError(key, err)
+	}
+
+	positions := make([]int, len(results))
+	for i, position := range results {
+		positions[i] = int(position)
+	}
+
+	return positions, nil
+}
+
+func (backend RedisBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	length, err := backend.Client.LPush(ctx, backend.getKey(key), stringsToInterfaces(newElements)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, 0, -1)
+}
+
+func (backend RedisBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, start, -1)
+}
+
+func (backend RedisBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	elements, err := backend.Client.LRange(ctx, backend.getKey(key), int64(start), int64(stop)).Result()
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+
+	return elements, nil
+}
+
+func (backend RedisBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	removed, err := backend.Client.LRem(ctx, backend.getKey(key), int64(countToRemove), element).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(removed), nil
+}
+
+func (backend RedisBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	err := lsetScript.Run(ctx, backend.Client, []string{backend.getKey(key)}, index, element).Err()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return false, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+		if strings.Contains(err.Error(), "index out of range") {
+			return false, newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+		}
+		return false, backend.redisError(key, err)
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	if err := backend.Client.LTrim(ctx, backend.getKey(key), int64(start), int64(stop)).Err(); err != nil {
+		return false, backend.redisError(key, err)
+	}
+
+	return true, nil
+}
+
+func (backend RedisBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	elements, err := backend.Client.RPopCount(ctx, backend.getKey(key), count).Result()
+	if errors.Is(err, redis.Nil) {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+
+	return elements, nil
+}
+
+func (backend RedisBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	length, err := backend.Client.RPush(ctx, backend.getKey(key), stringsToInterfaces(newElements)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	addedCount, err := backend.Client.SAdd(ctx, backend.getKey(key), stringsToInterfaces(newMembers)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(addedCount), nil
+}
+
+func (backend RedisBackend) Scard(ctx context.Context, key string) (int, error) {
+	count, err := backend.Client.SCard(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(count), nil
+}
+
+func (backend RedisBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, backend.Client.SDiff)
+}
+
+func (backend RedisBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, "SDIFFSTORE", destination, keys)
+}
+
+func (backend RedisBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, backend.Client.SInter)
+}
+
+func (backend RedisBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, "SINTERSTORE", destination, keys)
+}
+
+func (backend RedisBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	isMember, err := backend.Client.SIsMember(ctx, backend.getKey(key), member).Result()
+	if err != nil {
+		return false, backend.redisError(key, err)
+	}
+
+	return isMember, nil
+}
+
+func (backend RedisBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	members, err := backend.Client.SMembersMap(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return map[string]bool{}, backend.redisError(key, err)
+	}
+
+	response := make(map[string]bool)
+	for member := range members {
+		response[member] = true
+	}
+
+	return response, nil
+}
+
+func (backend RedisBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	moved, err := backend.Client.SMove(ctx, backend.getKey(source), backend.getKey(destination), member).Result()
+	if err != nil {
+		return false, backend.redisError(source, err)
+	}
+
+	return moved, nil
+}
+
+func (backend RedisBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	members, err := backend.Client.SPopN(ctx, backend.getKey(key), int64(count)).Result()
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+	if len(members) == 0 {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	return members, nil
+}
+
+func (backend RedisBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	members, err := backend.Client.SRandMemberN(ctx, backend.getKey(key), int64(count)).Result()
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+
+	return members, nil
+}
+
+func (backend RedisBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	removedCount, err := backend.Client.SRem(ctx, backend.getKey(key), stringsToInterfaces(membersToRemove)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(removedCount), nil
+}
+
+func (backend RedisBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, backend.Client.SUnion)
+}
+
+func (backend RedisBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, "SUNIONSTORE", destination, keys)
+}
+
+func (backend RedisBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	removedCount, err := backend.Client.HDel(ctx, backend.getKey(key), fieldsToRemove...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(removedCount), nil
+}
+
+func (backend RedisBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	exists, err := backend.Client.HExists(ctx, backend.getKey(key), field).Result()
+	if err != nil {
+		return false, backend.redisError(key, err)
+	}
+
+	return exists, nil
+}
+
+func (backend RedisBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	value, err := backend.Client.HGet(ctx, backend.getKey(key), field).Result()
+	if errors.Is(err, redis.Nil) {
+		if exists, _ := backend.Exists(ctx, key); !exists {
+			return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrFieldNotFound)
+	}
+	if err != nil {
+		return "", backend.redisError(key, err)
+	}
+
+	return value, nil
+}
+
+func (backend RedisBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	fields, err := backend.Client.HGetAll(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return map[string]string{}, backend.redisError(key, err)
+	}
+
+	return fields, nil
+}
+
+func (backend RedisBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields, err := backend.Client.HKeys(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return []string{}, backend.redisError(key, err)
+	}
+
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func (backend RedisBackend) Hlen(ctx context.Context, key string) (int, error) {
+	length, err := backend.Client.HLen(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	addedCount, err := backend.Client.HSet(ctx, backend.getKey(key), fields).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(addedCount), nil
+}
+
+func (backend RedisBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	if len(scores) == 0 {
+		return 0, nil
+	}
+
+	addedCount, err := backend.Client.ZAdd(ctx, backend.getKey(key), redisMembers(scores)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(addedCount), nil
+}
+
+func (backend RedisBackend) Zcard(ctx context.Context, key string) (int, error) {
+	length, err := backend.Client.ZCard(ctx, backend.getKey(key)).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(length), nil
+}
+
+func (backend RedisBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	members, err := backend.Client.ZRangeWithScores(ctx, backend.getKey(key), int64(start), int64(stop)).Result()
+	if err != nil {
+		return []SortedSetMember{}, backend.redisError(key, err)
+	}
+
+	return sortedSetMembers(members), nil
+}
+
+func (backend RedisBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	members, err := backend.Client.ZRangeByScoreWithScores(ctx, backend.getKey(key), &redis.ZRangeBy{
+		Min: formatRedisScore(min),
+		Max: formatRedisScore(max),
+	}).Result()
+	if err != nil {
+		return []SortedSetMember{}, backend.redisError(key, err)
+	}
+
+	return sortedSetMembers(members), nil
+}
+
+func (backend RedisBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	rank, err := backend.Client.ZRank(ctx, backend.getKey(key), member).Result()
+	if errors.Is(err, redis.Nil) {
+		if exists, _ := backend.Exists(ctx, key); !exists {
+			return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(rank), nil
+}
+
+func (backend RedisBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	removedCount, err := backend.Client.ZRem(ctx, backend.getKey(key), stringsToInterfaces(membersToRemove)...).Result()
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return int(removedCount), nil
+}
+
+func (backend RedisBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	score, err := backend.Client.ZScore(ctx, backend.getKey(key), member).Result()
+	if errors.Is(err, redis.Nil) {
+		if exists, _ := backend.Exists(ctx, key); !exists {
+			return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+	if err != nil {
+		return 0, backend.redisError(key, err)
+	}
+
+	return score, nil
+}
+
+// Watch subscribes to keyspace notifications for keys starting with prefix.
+// Notifications must be enabled on the server by setting
+// notify-keyspace-events to KA, which is verified if the server permits
+// reading its configuration.
+func (backend RedisBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	if config, err := backend.Client.ConfigGet(ctx, "notify-keyspace-events").Result(); err == nil {
+		flags := config["notify-keyspace-events"]
+		if !strings.Contains(flags, "K") || (!strings.Contains(flags, "A") && !containsAllFlags(flags, "g$lshzx")) {
+			return nil, fmt.Errorf("Keyspace notifications are not enabled, set notify-keyspace-events to KA on the redis server")
+		}
+	}
+
+	channelPrefix := fmt.Sprintf("__keyspace@%d__:%s", backend.Client.Options().DB, backend.getKey(""))
+	pubsub := backend.Client.PSubscribe(ctx, escapeRedisPattern(channelPrefix+prefix)+"*")
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			var message *redis.Message
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok = <-messages:
+				if !ok {
+					return
+				}
+			}
+
+			event := Event{
+				Type:      EventChanged,
+				Namespace: backend.Namespace,
+				Key:       strings.TrimPrefix(message.Channel, channelPrefix),
+			}
+			switch message.Payload {
+			case "expire", "persist":
+				continue
+			case "del", "expired", "evicted", "rename_from":
+				event.Type = EventDeleted
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// blockingPop runs BLPOP or BRPOP on a single key. Redis only blocks for
+// whole seconds without logging a warning, so the timeout is rounded up.
+func (backend RedisBackend) blockingPop(ctx context.Context, key string, timeout time.Duration, pop func(ctx context.Context, timeout time.Duration, keys ...string) *redis.StringSliceCmd) (string, error) {
+	if err := validateBlockingTimeout(backend.Namespace, key, timeout); err != nil {
+		return "", err
+	}
+
+	if remainder := timeout % time.Second; remainder > 0 {
+		timeout += time.Second - remainder
+	}
+
+	result, err := pop(ctx, timeout, backend.getKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return "", backend.redisError(key, err)
+	}
+
+	return result[1], nil
+}
+
+// combine runs SDIFF, SINTER or SUNION on the sets held by keys. Redis
+// rejects the commands without any keys, for which an empty set is returned.
+func (backend RedisBackend) combine(ctx context.Context, keys []string, cmd func(ctx context.Context, keys ...string) *redis.StringSliceCmd) (map[string]bool, error) {
+	members := map[string]bool{}
+	if len(keys) == 0 {
+		return members, nil
+	}
+
+	result, err := cmd(ctx, backend.getKeys(keys)...).Result()
+	if err != nil {
+		return members, backend.redisError(keys[0], err)
+	}
+
+	for _, member := range result {
+		members[member] = true
+	}
+
+	return members, nil
+}
+
+// combineStore runs the store command, one of SDIFFSTORE, SINTERSTORE or
+// SUNIONSTORE, on the sets held by keys. Without any keys, the destination is
+// replaced by an empty set.
+func (backend RedisBackend) combineStore(ctx context.Context, command string, destination string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		_, err := backend.Del(ctx, destination)
+		return 0, err
+	}
+
+	redisKeys := append([]string{backend.getKey(destination)}, backend.getKeys(keys)...)
+	count, err := setStoreScript.Run(ctx, backend.Client, redisKeys, command).Int()
+	if err != nil {
+		return 0, backend.redisError(keys[0], err)
+	}
+
+	return int(count), nil
+}
+
+func (backend RedisBackend) getKey(key string) string {
+	return backend.Namespace + redisNamespaceDelimiter + key
+}
+
+// getKeys returns the redis key of each key
+func (backend RedisBackend) getKeys(keys []string) []string {
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = backend.getKey(key)
+	}
+
+	return redisKeys
+}
+
+// redisError converts WRONGTYPE replies into an ErrWrongType error
+func (backend RedisBackend) redisError(key string, err error) error {
+	if err != nil && strings.Contains(err.Error(), "WRONGTYPE") {
+		return newKeyError(backend.Namespace, key, ErrWrongType)
+	}
+
+	return err
+}
+
+// scanKeys returns all distinct keys matching a glob-style pattern
+func (backend RedisBackend) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	seen := make(map[string]bool)
+	keys := []string{}
+	iter := backend.Client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		if seen[iter.Val()] {
+			continue
+		}
+		seen[iter.Val()] = true
+		keys = append(keys, iter.Val())
+	}
+
+	return keys, iter.Err()
+}
+
+// deleteKeys removes keys in batches to avoid overly large DEL commands
+func (backend RedisBackend) deleteKeys(ctx context.Context, keys []string) error {
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > 1000 {
+			batch = keys[:1000]
+		}
+		if err := backend.Client.Del(ctx, batch...).Err(); err != nil {
+			return err
+		}
+		keys = keys[len(batch):]
+	}
+
+	return nil
+}
+
+// escapeRedisPattern escapes glob characters so a string is matched literally by SCAN
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// containsAllFlags returns true if every character of required is in flags
+func containsAllFlags(flags string, required string) bool {
+	for _, flag := range required {
+		if !strings.ContainsRune(flags, flag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// positiveTTL converts a ttl into the expiration passed to SET, which is zero
+// for no expiry and otherwise at least the one millisecond resolution of PX.
+// Negative expirations are not passed through, as they keep the existing
+// expiry of the key.
+func positiveTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return max(ttl, time.Millisecond)
+}
+
+// formatRedisScore formats a score bound as accepted by ZRANGEBYSCORE
+func formatRedisScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+// redisMembers converts the scores of a sorted set into ZADD members
+func redisMembers(scores map[string]float64) []redis.Z {
+	members := []redis.Z{}
+	for member, score := range scores {
+		members = append(members, redis.Z{Score: score, Member: member})
+	}
+	return members
+}
+
+// redisScores converts ZRANGE members into the scores of a sorted set
+func redisScores(members []redis.Z) map[string]float64 {
+	scores := map[string]float64{}
+	for _, member := range members {
+		scores[fmt.Sprint(member.Member)] = member.Score
+	}
+	return scores
+}
+
+// sortedSetMembers converts ZRANGE members into sorted set members
+func sortedSetMembers(members []redis.Z) []SortedSetMember {
+	sorted := []SortedSetMember{}
+	for _, member := range members {
+		sorted = append(sorted, SortedSetMember{Member: fmt.Sprint(member.Member), Score: member.Score})
+	}
+	return sorted
+}
+
+func stringsToInterfaces(ss []string) []interface{} {
+	values := make([]interface{}, len(ss))
+	for i, s := range ss {
+		values[i] = s
+	}
+	return values
+}
@@ -0,0 +1,44 @@
+package backend_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dokku/prop/backend"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisBackendWatch(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	b := backend.NewRedisBackendWithClient("default", client)
+	events, err := b.Watch(t.Context(), "watched")
+	if err != nil {
+		t.Fatalf("Watch returned an error: %s", err)
+	}
+
+	// miniredis does not publish keyspace notifications, so the messages a
+	// redis server would send are published directly
+	server.Publish("__keyspace@0__:default:other", "set")
+	server.Publish("__keyspace@0__:default:watched", "expire")
+	server.Publish("__keyspace@0__:default:watched", "set")
+	server.Publish("__keyspace@0__:default:watched-key", "expired")
+
+	expected := []backend.Event{
+		{Type: backend.EventChanged, Namespace: "default", Key: "watched"},
+		{Type: backend.EventDeleted, Namespace: "default", Key: "watched-key"},
+	}
+	for _, want := range expected {
+		select {
+		case event := <-events:
+			if event != want {
+				t.Errorf("Watch sent %v, expected %v", event, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch did not send %v", want)
+		}
+	}
+}
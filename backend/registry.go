@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xo/dburl"
+)
+
+// Factory constructs a Backend for a parsed url
+type Factory func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a backend available to ConstructBackend for urls using the
+// given scheme. Schemes known to dburl also match any of their aliases, while
+// any other scheme is parsed as a plain url. If Register is called twice with
+// the same scheme or if factory is nil, it panics.
+func Register(scheme string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("backend: Register factory is nil")
+	}
+	if _, exists := factories[scheme]; exists {
+		panic("backend: Register called twice for scheme " + scheme)
+	}
+
+	factories[scheme] = factory
+}
+
+// Schemes returns a sorted list of the registered url schemes
+func Schemes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	schemes := []string{}
+	for scheme := range factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// lookupFactory returns the factory registered for a parsed url, preferring
+// the scheme as written, including any "+transport" suffix, over the driver
+// and other aliases it resolves to
+func lookupFactory(u *dburl.URL) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := append([]string{strings.ToLower(u.OriginalScheme), u.Scheme, u.UnaliasedDriver}, dburl.Protocols(u.UnaliasedDriver)...)
+	for _, name := range names {
+		if factory, ok := factories[name]; ok {
+			return factory, true
+		}
+	}
+
+	return nil, false
+}
+
+// unknownSchemeError returns the error for a url without a registered backend
+func unknownSchemeError(u *dburl.URL) error {
+	return fmt.Errorf("Unknown backend scheme %s, registered schemes: %s", u.OriginalScheme, strings.Join(Schemes(), ", "))
+}
+
+// ParseURL parses a backend url. Urls with schemes that are unknown to dburl
+// are parsed as plain urls so that they may still be registered, with any
+// "+transport" suffix of the scheme split into the Transport field. As dburl
+// resolves file urls to a database driver based on the file contents, file
+// urls are parsed as plain urls holding the path in the Opaque field, and the
+// transport selects the format the files are stored in.
+func ParseURL(rawURL string) (*dburl.URL, error) {
+	if isFileURL(rawURL) {
+		u, err := parsePlainURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if u.Opaque == "" {
+			u.Opaque, u.Host, u.Path, u.RawPath = u.Host+u.Path, "", "", ""
+		}
+		return u, nil
+	}
+
+	u, err := dburl.Parse(rawURL)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, dburl.ErrUnknownDatabaseScheme) {
+		return nil, fmt.Errorf("Invalid backend url: %s", err.Error())
+	}
+
+	return parsePlainURL(rawURL)
+}
+
+// isFileURL returns whether a url uses the file scheme, with or without a
+// "+transport" suffix
+func isFileURL(rawURL string) bool {
+	scheme, _, ok := strings.Cut(rawURL, ":")
+	if !ok {
+		return false
+	}
+
+	scheme, _, _ = strings.Cut(scheme, "+")
+	return strings.EqualFold(scheme, "file")
+}
+
+// parsePlainURL parses a url without resolving its scheme through dburl
+func parsePlainURL(rawURL string) (*dburl.URL, error) {
+	v, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid backend url: %s", err.Error())
+	}
+
+	u := &dburl.URL{
+		URL:            *v,
+		OriginalScheme: rawURL[:len(v.Scheme)],
+	}
+	if i := strings.IndexRune(u.Scheme, '+'); i != -1 {
+		u.Transport = u.Scheme[i+1:]
+		u.Scheme = u.Scheme[:i]
+	}
+	u.Driver, u.UnaliasedDriver = u.Scheme, u.Scheme
+
+	return u, nil
+}
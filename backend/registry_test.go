@@ -0,0 +1,59 @@
+package backend_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dokku/prop/backend"
+	"github.com/xo/dburl"
+)
+
+func TestParseURLFile(t *testing.T) {
+	tests := []struct {
+		url       string
+		scheme    string
+		transport string
+		opaque    string
+	}{
+		{url: "file:/etc/prop.d", scheme: "file", opaque: "/etc/prop.d"},
+		{url: "file:///etc/prop.d?durability=file", scheme: "file", opaque: "/etc/prop.d"},
+		{url: "file:prop.d", scheme: "file", opaque: "prop.d"},
+		{url: "file+json:/etc/prop.d", scheme: "file", transport: "json", opaque: "/etc/prop.d"},
+	}
+
+	for _, tt := range tests {
+		u, err := backend.ParseURL(tt.url)
+		if err != nil {
+			t.Fatalf("ParseURL(%q): %s", tt.url, err)
+		}
+		if u.Scheme != tt.scheme || u.Transport != tt.transport || u.Opaque != tt.opaque {
+			t.Errorf("ParseURL(%q) = scheme %q, transport %q, opaque %q, expected %q, %q, %q", tt.url, u.Scheme, u.Transport, u.Opaque, tt.scheme, tt.transport, tt.opaque)
+		}
+	}
+
+	// resolving file urls must not change how dburl parses them for other
+	// users of dburl in the same process
+	filename := filepath.Join(t.TempDir(), "prop.db")
+	if err := os.WriteFile(filename, []byte("SQLite format 3\x00"), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	u, err := dburl.Parse("file:" + filename)
+	if err != nil || u.Driver != "sqlite3" {
+		t.Errorf("expected dburl to keep resolving file urls by their contents, got %v, %v", u, err)
+	}
+}
+
+func TestConstructBackendSchemes(t *testing.T) {
+	expected := []string{"file", "file+json", "mem", "plugin", "postgres", "redis", "sqlite"}
+	if schemes := backend.Schemes(); !reflect.DeepEqual(schemes, expected) {
+		t.Errorf("expected schemes %v, got %v", expected, schemes)
+	}
+
+	for _, scheme := range []string{"sqlite", "sqlite3"} {
+		if _, err := backend.ConstructBackend(t.Context(), scheme+":"+filepath.Join(t.TempDir(), "prop.db"), "default"); err != nil {
+			t.Errorf("ConstructBackend with scheme %s: %s", scheme, err)
+		}
+	}
+}
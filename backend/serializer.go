@@ -1,9 +1,5 @@
 package backend
 
-import (
-	"fmt"
-)
-
 func SerializePropertyCollection(p PropertyCollection, filename string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+	return false, ErrNotImplemented
 }
@@ -0,0 +1,1915 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDialect contains the database-specific portions of a sql backend
+type sqlDialect struct {
+	// schema contains the idempotent statements used to create the
+	// properties and expirations tables and their indexes
+	schema []string
+
+	// lockSchema serializes schema creation across processes
+	lockSchema func(ctx context.Context, tx *sql.Tx) error
+
+	// upgradeSchema runs the schema changes that may not be made within a
+	// transaction, once the schema has been created
+	upgradeSchema func(ctx context.Context, db *sql.DB) error
+
+	// lockKey serializes read-modify-write operations on a key across processes
+	lockKey func(ctx context.Context, tx *sql.Tx, name string) error
+
+	// rebind converts $N placeholders to the placeholder style of the database
+	rebind func(query string) string
+}
+
+// sqlQueryer is implemented by both *sql.DB and *sql.Tx
+type sqlQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqlTransactionKey is the context key holding the sqlTransaction started by
+// sqlBackend.Transaction
+type sqlTransactionKey struct{}
+
+// sqlTransaction is a transaction joined by every call made with the context
+// it is recorded in
+type sqlTransaction struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// sqlBackend implements the Backend interface against the properties table.
+// Every element of a list or member of a set is stored as a row, ordered by
+// id, as is every field of a hash and member of a sorted set, encoded by
+// encodeHashField and encodeSortedSetMember.
+type sqlBackend struct {
+	Namespace string
+	DB        *sql.DB
+
+	dialect sqlDialect
+}
+
+func newSQLBackend(ctx context.Context, namespace string, db *sql.DB, dialect sqlDialect) (sqlBackend, error) {
+	backend := sqlBackend{}
+	backend.Namespace = namespace
+	backend.DB = db
+	backend.dialect = dialect
+
+	if err := backend.createSchema(ctx); err != nil {
+		return backend, fmt.Errorf("Unable to create schema: %s", err.Error())
+	}
+
+	return backend, nil
+}
+
+// inNamespace returns a copy of the backend bound to another namespace
+func (backend sqlBackend) inNamespace(namespace string) Backend {
+	backend.Namespace = namespace
+	return backend
+}
+
+func (backend sqlBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	properties := PropertyCollection{Properties: []Property{}}
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return properties, err
+	}
+
+	rows, err := backend.query(ctx, backend.conn(ctx), `SELECT "namespace", "key", "data_type", "value" FROM "properties" ORDER BY "namespace", "key", "id"`)
+	if err != nil {
+		return properties, err
+	}
+	defer rows.Close()
+
+	var current *Property
+	for rows.Next() {
+		var namespace, key, dataType, value string
+		if err := rows.Scan(&namespace, &key, &dataType, &value); err != nil {
+			return properties, err
+		}
+
+		if current == nil || current.Namespace != namespace || current.Key != key {
+			properties.Properties = append(properties.Properties, Property{
+				DataType:  dataType,
+				Namespace: namespace,
+				Key:       key,
+			})
+			current = &properties.Properties[len(properties.Properties)-1]
+		}
+
+		if dataType == DataTypeKeyValue {
+			current.Value = value
+			continue
+		}
+
+		if dataType == DataTypeHash {
+			field, fieldValue, err := decodeHashField(value)
+			if err != nil {
+				return properties, err
+			}
+			fields, ok := current.Value.(map[string]string)
+			if !ok {
+				fields = map[string]string{}
+				current.Value = fields
+			}
+			fields[field] = fieldValue
+			continue
+		}
+
+		if dataType == DataTypeSortedSet {
+			member, err := decodeSortedSetMember(value)
+			if err != nil {
+				return properties, err
+			}
+			scores, ok := current.Value.(map[string]float64)
+			if !ok {
+				scores = map[string]float64{}
+				current.Value = scores
+			}
+			scores[member.Member] = member.Score
+			continue
+		}
+
+		elements, _ := current.Value.([]string)
+		current.Value = append(elements, value)
+	}
+
+	for i, property := range properties.Properties {
+		if property.DataType == DataTypeSet {
+			members := property.Value.([]string)
+			sort.Strings(members)
+			properties.Properties[i].Value = members
+		}
+	}
+
+	return properties, rows.Err()
+}
+
+func (backend sqlBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if clear {
+			if _, err := backend.exec(ctx, tx, `DELETE FROM "properties"`); err != nil {
+				return err
+			}
+			if _, err := backend.exec(ctx, tx, `DELETE FROM "expirations"`); err != nil {
+				return err
+			}
+		}
+
+		for _, property := range p.Properties {
+			var values []string
+			switch property.DataType {
+			case DataTypeKeyValue:
+				value, err := property.StringValue()
+				if err != nil {
+					return err
+				}
+				values = []string{value}
+			case DataTypeList, DataTypeSet:
+				elements, err := property.ListValue()
+				if err != nil {
+					return err
+				}
+				values = elements
+			case DataTypeHash:
+				fields, err := property.HashValue()
+				if err != nil {
+					return err
+				}
+				for _, field := range sortedFields(fields) {
+					values = append(values, encodeHashField(field, fields[field]))
+				}
+			case DataTypeSortedSet:
+				scores, err := property.SortedSetValue()
+				if err != nil {
+					return err
+				}
+				for _, member := range sortedScores(scores) {
+					values = append(values, encodeSortedSetMember(member))
+				}
+			default:
+				return fmt.Errorf("Invalid data type for property %s.%s: %s", property.Namespace, property.Key, property.DataType)
+			}
+
+			if err := backend.deleteKey(ctx, tx, property.Namespace, property.Key); err != nil {
+				return err
+			}
+			if property.DataType == DataTypeSet {
+				values = uniqueStrings(values)
+			}
+			if err := backend.insertValues(ctx, tx, property.Namespace, property.Key, property.DataType, values); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) BackendReset(ctx context.Context) (bool, error) {
+	if _, err := backend.exec(ctx, backend.conn(ctx), `DELETE FROM "properties"`); err != nil {
+		return false, err
+	}
+	if _, err := backend.exec(ctx, backend.conn(ctx), `DELETE FROM "expirations"`); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) Del(ctx context.Context, key string) (bool, error) {
+	if err := backend.deleteKey(ctx, backend.conn(ctx), backend.Namespace, key); err != nil {
+		return false, fmt.Errorf("Unable to remove key %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return false, err
+	}
+
+	_, exists, err := backend.dataType(ctx, backend.conn(ctx), key)
+	return exists, err
+}
+
+func (backend sqlBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err := backend.queryRow(ctx, backend.conn(ctx), `SELECT EXISTS(SELECT 1 FROM "properties" WHERE "namespace" = $1)`, namespace).Scan(&exists)
+	return exists, err
+}
+
+func (backend sqlBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	if _, err := backend.exec(ctx, backend.conn(ctx), `DELETE FROM "properties" WHERE "namespace" = $1`, namespace); err != nil {
+		return false, err
+	}
+	if _, err := backend.exec(ctx, backend.conn(ctx), `DELETE FROM "expirations" WHERE "namespace" = $1`, namespace); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return "", err
+	}
+
+	var dataType, value string
+	err := backend.queryRow(ctx, backend.conn(ctx), `SELECT "data_type", "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT 1`, backend.Namespace, key).Scan(&dataType, &value)
+	if err == sql.ErrNoRows {
+		if defaultValue != "" {
+			return defaultValue, nil
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if dataType != DataTypeKeyValue {
+		return "", backend.wrongTypeError(key)
+	}
+
+	return value, nil
+}
+
+func (backend sqlBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	value, revision, err := backend.keyValueWithRevision(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return "", 0, err
+	}
+	if revision == 0 {
+		return "", 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	return value, revision, nil
+}
+
+func (backend sqlBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	return backend.GetAllByPrefix(ctx, "")
+}
+
+func (backend sqlBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keyValuePairs := make(map[string]string)
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return keyValuePairs, err
+	}
+
+	rows, err := backend.query(ctx, backend.conn(ctx), `SELECT "key", "value" FROM "properties" WHERE "namespace" = $1 AND "data_type" = 'key_value' AND substr("key", 1, length(CAST($2 AS text))) = $2`, backend.Namespace, prefix)
+	if err != nil {
+		return keyValuePairs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return keyValuePairs, err
+		}
+		keyValuePairs[key] = value
+	}
+
+	return keyValuePairs, rows.Err()
+}
+
+func (backend sqlBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return backend.SetWithTTL(ctx, key, value, 0)
+}
+
+func (backend sqlBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		return backend.replaceKeyValue(ctx, tx, key, value, ttl)
+	})
+	if err != nil {
+		return false, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		_, exists, err := backend.dataType(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return newKeyError(backend.Namespace, key, ErrConditionFailed)
+		}
+
+		return backend.replaceKeyValue(ctx, tx, key, value, ttl)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		previous, exists, err := backend.keyValue(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if !exists || previous != oldValue {
+			return newKeyError(backend.Namespace, key, ErrConditionFailed)
+		}
+
+		return backend.replaceKeyValue(ctx, tx, key, value, ttl)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		_, current, err := backend.keyValueWithRevision(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if current != revision {
+			return newKeyError(backend.Namespace, key, ErrConditionFailed)
+		}
+
+		return backend.replaceKeyValue(ctx, tx, key, value, ttl)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	previous := ""
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		var err error
+		previous, _, err = backend.keyValue(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		return backend.replaceKeyValue(ctx, tx, key, value, 0)
+	})
+
+	return previous, err
+}
+
+func (backend sqlBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, 1)
+}
+
+// IncrBy replaces the row holding the value within a transaction holding the
+// lock on the key, keeping any expiry of the key
+func (backend sqlBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	var value int64
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		current, exists, err := backend.keyValue(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		value, err = incrementValue(backend.Namespace, key, current, exists, increment)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of a deleted one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+			return backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeKeyValue, []string{strconv.FormatInt(value, 10)})
+		}
+
+		// the value is written to a new row, giving it a new revision
+		if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key); err != nil {
+			return err
+		}
+		return backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeKeyValue, []string{strconv.FormatInt(value, 10)})
+	})
+
+	return value, err
+}
+
+func (backend sqlBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, -1)
+}
+
+func (backend sqlBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	exists := false
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		var err error
+		if _, exists, err = backend.dataType(ctx, tx, key); err != nil || !exists {
+			return err
+		}
+
+		if ttl <= 0 {
+			return backend.deleteKey(ctx, tx, backend.Namespace, key)
+		}
+
+		if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+			return err
+		}
+
+		return backend.insertExpiration(ctx, tx, key, ttl)
+	})
+
+	return exists, err
+}
+
+func (backend sqlBackend) Persist(ctx context.Context, key string) (bool, error) {
+	persisted := false
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		_, exists, err := backend.dataType(ctx, tx, key)
+		if err != nil || !exists {
+			return err
+		}
+
+		result, err := backend.exec(ctx, tx, `DELETE FROM "expirations" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		persisted = affected > 0
+		return err
+	})
+
+	return persisted, err
+}
+
+func (backend sqlBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl := NoExpiration
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		_, exists, err := backend.dataType(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		var expiresAt int64
+		err = backend.queryRow(ctx, tx, `SELECT "expires_at" FROM "expirations" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key).Scan(&expiresAt)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ttl = time.Until(time.UnixMilli(expiresAt))
+		return nil
+	})
+
+	return ttl, err
+}
+
+func (backend sqlBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, pollNotifier, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, "ASC"))
+	})
+}
+
+func (backend sqlBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, pollNotifier, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, "DESC"))
+	})
+}
+
+func (backend sqlBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	var element string
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		query, offset := `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT 1 OFFSET $3`, index
+		if index < 0 {
+			query, offset = `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" DESC LIMIT 1 OFFSET $3`, -index-1
+		}
+
+		err = backend.queryRow(ctx, tx, query, backend.Namespace, key, offset).Scan(&element)
+		if err == sql.ErrNoRows {
+			return newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+		}
+		return err
+	})
+
+	return element, err
+}
+
+func (backend sqlBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := validateListPosition(backend.Namespace, key, position); err != nil {
+		return 0, err
+	}
+
+	length := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		elements, err := backend.listElements(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		elements, err = insertElement(backend.Namespace, key, elements, position, pivot, element)
+		if err != nil {
+			return err
+		}
+
+		length = len(elements)
+		return backend.replaceList(ctx, tx, key, elements)
+	})
+
+	return length, err
+}
+
+func (backend sqlBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return false, err
+	}
+
+	exists, err := backend.checkDataType(ctx, backend.conn(ctx), key, DataTypeList)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	var isMember bool
+	err = backend.queryRow(ctx, backend.conn(ctx), `SELECT EXISTS(SELECT 1 FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3)`, backend.Namespace, key, element).Scan(&isMember)
+	return isMember, err
+}
+
+func (backend sqlBackend) Llen(ctx context.Context, key string) (int, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return 0, err
+	}
+
+	if _, err := backend.checkDataType(ctx, backend.conn(ctx), key, DataTypeList); err != nil {
+		return 0, err
+	}
+
+	return backend.countValues(ctx, backend.conn(ctx), key)
+}
+
+func (backend sqlBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := validateListDirections(backend.Namespace, source, from, to); err != nil {
+		return "", err
+	}
+
+	var element string
+	err := backend.withKeysTransaction(ctx, []string{source, destination}, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, source, DataTypeList)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, source, ErrKeyNotFound)
+		}
+
+		destinationExists, err := backend.checkDataType(ctx, tx, destination, DataTypeList)
+		if err != nil {
+			return err
+		}
+
+		sourceElements, err := backend.listElements(ctx, tx, source)
+		if err != nil {
+			return err
+		}
+
+		destinationElements, err := backend.listElements(ctx, tx, destination)
+		if err != nil {
+			return err
+		}
+
+		element, sourceElements, destinationElements = moveElement(sourceElements, destinationElements, source == destination, from, to)
+		if source != destination {
+			if err := backend.replaceList(ctx, tx, source, sourceElements); err != nil {
+				return err
+			}
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !destinationExists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, destination); err != nil {
+				return err
+			}
+		}
+
+		return backend.replaceList(ctx, tx, destination, destinationElements)
+	})
+
+	return element, err
+}
+
+func (backend sqlBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, "ASC")
+}
+
+func (backend sqlBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	positions := []int{}
+	if err := validateListPositionOptions(backend.Namespace, key, rank, count); err != nil {
+		return positions, err
+	}
+
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := backend.checkDataType(ctx, tx, key, DataTypeList); err != nil {
+			return err
+		}
+
+		elements, err := backend.listElements(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		positions = listPositions(elements, element, rank, count)
+		return nil
+	})
+
+	return positions, err
+}
+
+func (backend sqlBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	length := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+		}
+
+		elements, err := backend.listElements(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		elements = prependElements(elements, newElements)
+		length = len(elements)
+		return backend.replaceList(ctx, tx, key, elements)
+	})
+
+	return length, err
+}
+
+func (backend sqlBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, 0, -1)
+}
+
+func (backend sqlBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, start, -1)
+}
+
+func (backend sqlBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	elements := []string{}
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := backend.checkDataType(ctx, tx, key, DataTypeList); err != nil {
+			return err
+		}
+
+		length, err := backend.countValues(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		offset, limit, ok := normalizeListRange(length, start, stop)
+		if !ok {
+			return nil
+		}
+
+		elements, err = backend.queryValues(ctx, tx, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT $4 OFFSET $3`, backend.Namespace, key, offset, limit)
+		return err
+	})
+
+	return elements, err
+}
+
+func (backend sqlBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	removed := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := backend.checkDataType(ctx, tx, key, DataTypeList); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`
+		args := []interface{}{backend.Namespace, key, element}
+		if countToRemove > 0 {
+			query = `DELETE FROM "properties" WHERE "id" IN (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3 ORDER BY "id" LIMIT $4)`
+			args = append(args, countToRemove)
+		} else if countToRemove < 0 {
+			query = `DELETE FROM "properties" WHERE "id" IN (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3 ORDER BY "id" DESC LIMIT $4)`
+			args = append(args, -countToRemove)
+		}
+
+		result, err := backend.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		removed = int(affected)
+		return err
+	})
+
+	return removed, err
+}
+
+func (backend sqlBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		query, offset := `UPDATE "properties" SET "value" = $4 WHERE "id" = (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT 1 OFFSET $3)`, index
+		if index < 0 {
+			query, offset = `UPDATE "properties" SET "value" = $4 WHERE "id" = (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" DESC LIMIT 1 OFFSET $3)`, -index-1
+		}
+
+		result, err := backend.exec(ctx, tx, query, backend.Namespace, key, offset, element)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := backend.checkDataType(ctx, tx, key, DataTypeList); err != nil {
+			return err
+		}
+
+		length, err := backend.countValues(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		offset, limit, ok := normalizeListRange(length, start, stop)
+		if !ok {
+			_, err = backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+			return err
+		}
+
+		_, err = backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "id" NOT IN (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT $4 OFFSET $3)`, backend.Namespace, key, offset, limit)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend sqlBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, "DESC")
+}
+
+func (backend sqlBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	length := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+		}
+
+		if err := backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeList, newElements); err != nil {
+			return err
+		}
+
+		length, err = backend.countValues(ctx, tx, key)
+		return err
+	})
+
+	return length, err
+}
+
+func (backend sqlBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	addedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeSet)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+		}
+
+		existing, err := backend.queryValues(ctx, tx, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+		if err != nil {
+			return err
+		}
+
+		members := make(map[string]bool)
+		for _, member := range existing {
+			members[member] = true
+		}
+
+		var toInsert []string
+		for _, member := range newMembers {
+			if !members[member] {
+				members[member] = true
+				toInsert = append(toInsert, member)
+			}
+		}
+
+		addedCount = len(toInsert)
+		return backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeSet, toInsert)
+	})
+
+	return addedCount, err
+}
+
+func (backend sqlBackend) Scard(ctx context.Context, key string) (int, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return 0, err
+	}
+
+	if _, err := backend.checkDataType(ctx, backend.conn(ctx), key, DataTypeSet); err != nil {
+		return 0, err
+	}
+
+	return backend.countValues(ctx, backend.conn(ctx), key)
+}
+
+func (backend sqlBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, diffMembers)
+}
+
+func (backend sqlBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, diffMembers)
+}
+
+func (backend sqlBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, interMembers)
+}
+
+func (backend sqlBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, interMembers)
+}
+
+func (backend sqlBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	if err := backend.purgeExpired(ctx, backend.conn(ctx)); err != nil {
+		return false, err
+	}
+
+	exists, err := backend.checkDataType(ctx, backend.conn(ctx), key, DataTypeSet)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	var isMember bool
+	err = backend.queryRow(ctx, backend.conn(ctx), `SELECT EXISTS(SELECT 1 FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3)`, backend.Namespace, key, member).Scan(&isMember)
+	return isMember, err
+}
+
+func (backend sqlBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	members, _, err := backend.setMembers(ctx, backend.conn(ctx), key)
+	return members, err
+}
+
+func (backend sqlBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	moved := false
+	err := backend.withKeysTransaction(ctx, []string{source, destination}, func(tx *sql.Tx) error {
+		sourceMembers, _, err := backend.setMembers(ctx, tx, source)
+		if err != nil {
+			return err
+		}
+
+		destinationMembers, destinationExists, err := backend.setMembers(ctx, tx, destination)
+		if err != nil {
+			return err
+		}
+
+		if !sourceMembers[member] {
+			return nil
+		}
+		moved = true
+		if source == destination {
+			return nil
+		}
+
+		if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, source, member); err != nil {
+			return err
+		}
+
+		if destinationMembers[member] {
+			return nil
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !destinationExists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, destination); err != nil {
+				return err
+			}
+		}
+
+		return backend.insertValues(ctx, tx, backend.Namespace, destination, DataTypeSet, []string{member})
+	})
+
+	return moved, err
+}
+
+func (backend sqlBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	popped := []string{}
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return popped, err
+	}
+
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		members, exists, err := backend.setMembers(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		popped = randomMembers(members, count)
+		for _, member := range popped {
+			if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, member); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return popped, err
+}
+
+func (backend sqlBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	members, _, err := backend.setMembers(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return randomMembers(members, count), nil
+}
+
+func (backend sqlBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	removedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := backend.checkDataType(ctx, tx, key, DataTypeSet); err != nil {
+			return err
+		}
+
+		for _, member := range uniqueStrings(membersToRemove) {
+			result, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, member)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			removedCount += int(affected)
+		}
+		return nil
+	})
+
+	return removedCount, err
+}
+
+func (backend sqlBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, unionMembers)
+}
+
+func (backend sqlBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, unionMembers)
+}
+
+func (backend sqlBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	removedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		fields, _, err := backend.hashFields(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		for _, field := range uniqueStrings(fieldsToRemove) {
+			value, ok := fields[field]
+			if !ok {
+				continue
+			}
+
+			if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, encodeHashField(field, value)); err != nil {
+				return err
+			}
+			removedCount++
+		}
+		return nil
+	})
+
+	return removedCount, err
+}
+
+func (backend sqlBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := fields[field]
+	return ok, nil
+}
+
+func (backend sqlBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	fields, exists, err := backend.hashFields(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", newKeyError(backend.Namespace, key, ErrFieldNotFound)
+	}
+
+	return value, nil
+}
+
+func (backend sqlBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	fields, _, err := backend.hashFields(ctx, backend.conn(ctx), key)
+	return fields, err
+}
+
+func (backend sqlBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return sortedFields(fields), nil
+}
+
+func (backend sqlBackend) Hlen(ctx context.Context, key string) (int, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(fields), nil
+}
+
+func (backend sqlBackend) Hset(ctx context.Context, key string, newFields map[string]string) (int, error) {
+	addedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		fields, exists, err := backend.hashFields(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+		}
+
+		for _, field := range sortedFields(newFields) {
+			if value, ok := fields[field]; ok {
+				if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, encodeHashField(field, value)); err != nil {
+					return err
+				}
+			} else {
+				addedCount++
+			}
+
+			if err := backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeHash, []string{encodeHashField(field, newFields[field])}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return addedCount, err
+}
+
+func (backend sqlBackend) Zadd(ctx context.Context, key string, newScores map[string]float64) (int, error) {
+	addedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		scores, exists, err := backend.sortedSetScores(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		// a new key must not inherit the expiry of an emptied one
+		if !exists {
+			if err := backend.deleteExpiration(ctx, tx, backend.Namespace, key); err != nil {
+				return err
+			}
+		}
+
+		for _, member := range sortedScores(newScores) {
+			if score, ok := scores[member.Member]; ok {
+				if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, encodeSortedSetMember(SortedSetMember{Member: member.Member, Score: score})); err != nil {
+					return err
+				}
+			} else {
+				addedCount++
+			}
+
+			if err := backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeSortedSet, []string{encodeSortedSetMember(member)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return addedCount, err
+}
+
+func (backend sqlBackend) Zcard(ctx context.Context, key string) (int, error) {
+	scores, _, err := backend.sortedSetScores(ctx, backend.conn(ctx), key)
+	return len(scores), err
+}
+
+func (backend sqlBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	scores, _, err := backend.sortedSetScores(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByRank(sortedScores(scores), start, stop), nil
+}
+
+func (backend sqlBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	scores, _, err := backend.sortedSetScores(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByScore(sortedScores(scores), min, max), nil
+}
+
+func (backend sqlBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	scores, exists, err := backend.sortedSetScores(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	rank, ok := rankOf(sortedScores(scores), member)
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return rank, nil
+}
+
+func (backend sqlBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	removedCount := 0
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		scores, _, err := backend.sortedSetScores(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range uniqueStrings(membersToRemove) {
+			score, ok := scores[member]
+			if !ok {
+				continue
+			}
+
+			if _, err := backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2 AND "value" = $3`, backend.Namespace, key, encodeSortedSetMember(SortedSetMember{Member: member, Score: score})); err != nil {
+				return err
+			}
+			removedCount++
+		}
+		return nil
+	})
+
+	return removedCount, err
+}
+
+func (backend sqlBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	scores, exists, err := backend.sortedSetScores(ctx, backend.conn(ctx), key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	score, ok := scores[member]
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return score, nil
+}
+
+// Watch polls the properties table for changes to keys starting with prefix
+func (backend sqlBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	return pollWatch(ctx, backend.Namespace, func(ctx context.Context) (map[string]string, error) {
+		rows, err := backend.query(ctx, backend.conn(ctx), `SELECT "key", "data_type", "value" FROM "properties" WHERE "namespace" = $1 AND NOT EXISTS(SELECT 1 FROM "expirations" WHERE "expirations"."namespace" = "properties"."namespace" AND "expirations"."key" = "properties"."key" AND "expirations"."expires_at" <= $2) ORDER BY "key", "id"`, backend.Namespace, time.Now().UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		snapshot := map[string]string{}
+		for rows.Next() {
+			var key, dataType, value string
+			if err := rows.Scan(&key, &dataType, &value); err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			if fingerprint, ok := snapshot[key]; ok {
+				snapshot[key] = fingerprint + "\x00" + value
+			} else {
+				snapshot[key] = dataType + ":" + value
+			}
+		}
+
+		return snapshot, rows.Err()
+	})
+}
+
+// createSchema creates the properties table while holding the schema lock
+// so that concurrent processes do not race each other
+func (backend sqlBackend) createSchema(ctx context.Context) error {
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if backend.dialect.lockSchema != nil {
+			if err := backend.dialect.lockSchema(ctx, tx); err != nil {
+				return err
+			}
+		}
+
+		for _, statement := range backend.dialect.schema {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || backend.dialect.upgradeSchema == nil {
+		return err
+	}
+
+	return backend.dialect.upgradeSchema(ctx, backend.DB)
+}
+
+// Transaction runs fn within a single database transaction. Every call made
+// with the context passed to fn joins the transaction, which is committed if
+// fn returns nil and rolled back otherwise.
+func (backend sqlBackend) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, sqlTransactionKey{}, &sqlTransaction{db: backend.DB, tx: tx}))
+	})
+}
+
+// transaction returns the transaction recorded in ctx for the database, if any
+func (backend sqlBackend) transaction(ctx context.Context) *sql.Tx {
+	t, ok := ctx.Value(sqlTransactionKey{}).(*sqlTransaction)
+	if !ok || t.db != backend.DB {
+		return nil
+	}
+
+	return t.tx
+}
+
+// conn returns the transaction recorded in ctx, or the database otherwise
+func (backend sqlBackend) conn(ctx context.Context) sqlQueryer {
+	if tx := backend.transaction(ctx); tx != nil {
+		return tx
+	}
+
+	return backend.DB
+}
+
+// withTransaction runs fn inside of a transaction, committing on success. If
+// ctx records a transaction, fn joins it instead.
+func (backend sqlBackend) withTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if tx := backend.transaction(ctx); tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := backend.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// withKeyTransaction runs fn inside of a transaction holding a lock on the
+// key, serializing read-modify-write operations
+func (backend sqlBackend) withKeyTransaction(ctx context.Context, key string, fn func(tx *sql.Tx) error) error {
+	return backend.withKeysTransaction(ctx, []string{key}, fn)
+}
+
+// withKeysTransaction runs fn inside of a transaction holding a lock on each
+// of the keys, taken in sorted order so that concurrent callers cannot deadlock
+func (backend sqlBackend) withKeysTransaction(ctx context.Context, keys []string, fn func(tx *sql.Tx) error) error {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	return backend.withTransaction(ctx, func(tx *sql.Tx) error {
+		if backend.dialect.lockKey != nil {
+			for i, key := range sorted {
+				if i > 0 && key == sorted[i-1] {
+					continue
+				}
+
+				if err := backend.dialect.lockKey(ctx, tx, backend.Namespace+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+		return fn(tx)
+	})
+}
+
+func (backend sqlBackend) exec(ctx context.Context, q sqlQueryer, query string, args ...interface{}) (sql.Result, error) {
+	return q.ExecContext(ctx, backend.dialect.rebind(query), args...)
+}
+
+func (backend sqlBackend) query(ctx context.Context, q sqlQueryer, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.QueryContext(ctx, backend.dialect.rebind(query), args...)
+}
+
+func (backend sqlBackend) queryRow(ctx context.Context, q sqlQueryer, query string, args ...interface{}) *sql.Row {
+	return q.QueryRowContext(ctx, backend.dialect.rebind(query), args...)
+}
+
+func (backend sqlBackend) queryValues(ctx context.Context, q sqlQueryer, query string, args ...interface{}) ([]string, error) {
+	values := []string{}
+	rows, err := backend.query(ctx, q, query, args...)
+	if err != nil {
+		return values, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return values, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// dataType returns the data type of a key and whether the key exists
+func (backend sqlBackend) dataType(ctx context.Context, q sqlQueryer, key string) (string, bool, error) {
+	var dataType string
+	err := backend.queryRow(ctx, q, `SELECT "data_type" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 LIMIT 1`, backend.Namespace, key).Scan(&dataType)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return dataType, true, nil
+}
+
+// checkDataType returns whether the key exists, and an error if it holds a
+// data type other than the expected one
+func (backend sqlBackend) checkDataType(ctx context.Context, q sqlQueryer, key string, expected string) (bool, error) {
+	dataType, exists, err := backend.dataType(ctx, q, key)
+	if err != nil {
+		return false, err
+	}
+
+	if exists && dataType != expected {
+		return true, backend.wrongTypeError(key)
+	}
+
+	return exists, nil
+}
+
+// keyValue returns the value of a key_value key and whether the key exists,
+// after removing expired keys
+func (backend sqlBackend) keyValue(ctx context.Context, tx *sql.Tx, key string) (string, bool, error) {
+	value, revision, err := backend.keyValueWithRevision(ctx, tx, key)
+	return value, revision != 0, err
+}
+
+// keyValueWithRevision returns the value of a key-value and its revision,
+// which is the id of the row holding the value, or 0 if the key does not
+// exist, after removing expired keys. Every write of a key-value inserts a
+// new row, so the revision changes with each write and is never reused.
+func (backend sqlBackend) keyValueWithRevision(ctx context.Context, q sqlQueryer, key string) (string, int64, error) {
+	if err := backend.purgeExpired(ctx, q); err != nil {
+		return "", 0, err
+	}
+
+	var revision int64
+	var dataType, value string
+	err := backend.queryRow(ctx, q, `SELECT "id", "data_type", "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" LIMIT 1`, backend.Namespace, key).Scan(&revision, &dataType, &value)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	if dataType != DataTypeKeyValue {
+		return "", revision, backend.wrongTypeError(key)
+	}
+
+	return value, revision, nil
+}
+
+// hashFields returns the fields of a hash and whether the key exists, after
+// removing expired keys
+func (backend sqlBackend) hashFields(ctx context.Context, q sqlQueryer, key string) (map[string]string, bool, error) {
+	fields := map[string]string{}
+	if err := backend.purgeExpired(ctx, q); err != nil {
+		return fields, false, err
+	}
+
+	exists, err := backend.checkDataType(ctx, q, key, DataTypeHash)
+	if err != nil || !exists {
+		return fields, exists, err
+	}
+
+	values, err := backend.queryValues(ctx, q, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+	if err != nil {
+		return fields, true, err
+	}
+
+	for _, value := range values {
+		field, fieldValue, err := decodeHashField(value)
+		if err != nil {
+			return fields, true, err
+		}
+		fields[field] = fieldValue
+	}
+
+	return fields, true, nil
+}
+
+// setMembers returns the members of a set and whether the key exists, after
+// removing expired keys
+func (backend sqlBackend) setMembers(ctx context.Context, q sqlQueryer, key string) (map[string]bool, bool, error) {
+	members := map[string]bool{}
+	if err := backend.purgeExpired(ctx, q); err != nil {
+		return members, false, err
+	}
+
+	exists, err := backend.checkDataType(ctx, q, key, DataTypeSet)
+	if err != nil || !exists {
+		return members, exists, err
+	}
+
+	values, err := backend.queryValues(ctx, q, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+	if err != nil {
+		return members, true, err
+	}
+
+	for _, value := range values {
+		members[value] = true
+	}
+
+	return members, true, nil
+}
+
+// combine applies op to the members of the sets held by keys, where missing
+// keys are treated as empty sets
+func (backend sqlBackend) combine(ctx context.Context, keys []string, op setOperation) (map[string]bool, error) {
+	members := map[string]bool{}
+	err := backend.withTransaction(ctx, func(tx *sql.Tx) (err error) {
+		members, err = backend.combineSets(ctx, tx, keys, op)
+		return err
+	})
+
+	return members, err
+}
+
+// combineSets applies op to the members of the sets held by keys within q
+func (backend sqlBackend) combineSets(ctx context.Context, q sqlQueryer, keys []string, op setOperation) (map[string]bool, error) {
+	sets := []map[string]bool{}
+	for _, key := range keys {
+		members, _, err := backend.setMembers(ctx, q, key)
+		if err != nil {
+			return map[string]bool{}, err
+		}
+		sets = append(sets, members)
+	}
+
+	return op(sets), nil
+}
+
+// combineStore replaces the destination key, whatever its data type, with
+// the set resulting from combine, removing it if the set is empty
+func (backend sqlBackend) combineStore(ctx context.Context, destination string, keys []string, op setOperation) (int, error) {
+	count := 0
+	err := backend.withKeysTransaction(ctx, append([]string{destination}, keys...), func(tx *sql.Tx) error {
+		members, err := backend.combineSets(ctx, tx, keys, op)
+		if err != nil {
+			return err
+		}
+
+		if err := backend.deleteKey(ctx, tx, backend.Namespace, destination); err != nil {
+			return err
+		}
+
+		count = len(members)
+		return backend.insertValues(ctx, tx, backend.Namespace, destination, DataTypeSet, sortedMembers(members))
+	})
+
+	return count, err
+}
+
+// pop removes up to count elements from a list, starting at the head when
+// order is ASC and at the tail when order is DESC
+func (backend sqlBackend) pop(ctx context.Context, key string, count int, order string) ([]string, error) {
+	popped := []string{}
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return popped, err
+	}
+
+	err := backend.withKeyTransaction(ctx, key, func(tx *sql.Tx) error {
+		if err := backend.purgeExpired(ctx, tx); err != nil {
+			return err
+		}
+
+		exists, err := backend.checkDataType(ctx, tx, key, DataTypeList)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return newKeyError(backend.Namespace, key, ErrKeyNotFound)
+		}
+
+		popped, err = backend.queryValues(ctx, tx, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" `+order+` LIMIT $3`, backend.Namespace, key, count)
+		if err != nil {
+			return err
+		}
+
+		_, err = backend.exec(ctx, tx, `DELETE FROM "properties" WHERE "id" IN (SELECT "id" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id" `+order+` LIMIT $3)`, backend.Namespace, key, count)
+		return err
+	})
+
+	return popped, err
+}
+
+// listElements returns the elements of a list in order
+func (backend sqlBackend) listElements(ctx context.Context, q sqlQueryer, key string) ([]string, error) {
+	return backend.queryValues(ctx, q, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2 ORDER BY "id"`, backend.Namespace, key)
+}
+
+// replaceList replaces the elements of a list, keeping the expiry of the key.
+// Rows are ordered by id, so elements inserted anywhere other than the tail
+// require the list to be rewritten.
+func (backend sqlBackend) replaceList(ctx context.Context, q sqlQueryer, key string, elements []string) error {
+	if _, err := backend.exec(ctx, q, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key); err != nil {
+		return err
+	}
+
+	return backend.insertValues(ctx, q, backend.Namespace, key, DataTypeList, elements)
+}
+
+// sortedSetScores returns the scores of a sorted set and whether the key
+// exists, after removing expired keys
+func (backend sqlBackend) sortedSetScores(ctx context.Context, q sqlQueryer, key string) (map[string]float64, bool, error) {
+	scores := map[string]float64{}
+	if err := backend.purgeExpired(ctx, q); err != nil {
+		return scores, false, err
+	}
+
+	exists, err := backend.checkDataType(ctx, q, key, DataTypeSortedSet)
+	if err != nil || !exists {
+		return scores, exists, err
+	}
+
+	values, err := backend.queryValues(ctx, q, `SELECT "value" FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key)
+	if err != nil {
+		return scores, true, err
+	}
+
+	for _, value := range values {
+		member, err := decodeSortedSetMember(value)
+		if err != nil {
+			return scores, true, err
+		}
+		scores[member.Member] = member.Score
+	}
+
+	return scores, true, nil
+}
+
+func (backend sqlBackend) countValues(ctx context.Context, q sqlQueryer, key string) (int, error) {
+	var count int
+	err := backend.queryRow(ctx, q, `SELECT COUNT(*) FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, backend.Namespace, key).Scan(&count)
+	return count, err
+}
+
+// deleteKey removes every value of a key along with it's expiry
+func (backend sqlBackend) deleteKey(ctx context.Context, q sqlQueryer, namespace string, key string) error {
+	if _, err := backend.exec(ctx, q, `DELETE FROM "properties" WHERE "namespace" = $1 AND "key" = $2`, namespace, key); err != nil {
+		return err
+	}
+
+	return backend.deleteExpiration(ctx, q, namespace, key)
+}
+
+// deleteExpiration removes the expiry of a key
+func (backend sqlBackend) deleteExpiration(ctx context.Context, q sqlQueryer, namespace string, key string) error {
+	_, err := backend.exec(ctx, q, `DELETE FROM "expirations" WHERE "namespace" = $1 AND "key" = $2`, namespace, key)
+	return err
+}
+
+// insertExpiration records that a key expires once ttl elapses
+func (backend sqlBackend) insertExpiration(ctx context.Context, q sqlQueryer, key string, ttl time.Duration) error {
+	_, err := backend.exec(ctx, q, `INSERT INTO "expirations" ("namespace", "key", "expires_at") VALUES ($1, $2, $3)`, backend.Namespace, key, time.Now().Add(ttl).UnixMilli())
+	return err
+}
+
+// purgeExpired removes every key whose expiry has passed. Expired keys are
+// only checked for, without taking a write lock, in the common case where
+// there is nothing to remove.
+func (backend sqlBackend) purgeExpired(ctx context.Context, q sqlQueryer) error {
+	now := time.Now().UnixMilli()
+
+	var expired bool
+	if err := backend.queryRow(ctx, q, `SELECT EXISTS(SELECT 1 FROM "expirations" WHERE "expires_at" <= $1)`, now).Scan(&expired); err != nil {
+		return err
+	}
+	if !expired {
+		return nil
+	}
+
+	if _, err := backend.exec(ctx, q, `DELETE FROM "properties" WHERE EXISTS(SELECT 1 FROM "expirations" WHERE "expirations"."namespace" = "properties"."namespace" AND "expirations"."key" = "properties"."key" AND "expirations"."expires_at" <= $1)`, now); err != nil {
+		return err
+	}
+
+	_, err := backend.exec(ctx, q, `DELETE FROM "expirations" WHERE "expires_at" <= $1`, now)
+	return err
+}
+
+// replaceKeyValue replaces a key with a key-value that expires once ttl
+// elapses, or never if ttl is not positive. It must be called within a
+// transaction, so that the value is never visible without its expiry.
+func (backend sqlBackend) replaceKeyValue(ctx context.Context, tx *sql.Tx, key string, value string, ttl time.Duration) error {
+	if err := backend.deleteKey(ctx, tx, backend.Namespace, key); err != nil {
+		return err
+	}
+
+	if err := backend.insertValues(ctx, tx, backend.Namespace, key, DataTypeKeyValue, []string{value}); err != nil {
+		return err
+	}
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	return backend.insertExpiration(ctx, tx, key, ttl)
+}
+
+func (backend sqlBackend) insertValues(ctx context.Context, q sqlQueryer, namespace string, key string, dataType string, values []string) error {
+	for _, value := range values {
+		_, err := backend.exec(ctx, q, `INSERT INTO "properties" ("namespace", "data_type", "key", "value", "created_at") VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)`, namespace, dataType, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (backend sqlBackend) wrongTypeError(key string) error {
+	return newKeyError(backend.Namespace, key, ErrWrongType)
+}
+
+// encodeHashField encodes a field of a hash and its value as the json array
+// stored in the value column of its row
+func encodeHashField(field string, value string) string {
+	encoded, _ := json.Marshal([]string{field, value})
+	return string(encoded)
+}
+
+// decodeHashField decodes the value column of a row holding a field of a hash
+func decodeHashField(encoded string) (string, string, error) {
+	var pair []string
+	if err := json.Unmarshal([]byte(encoded), &pair); err != nil || len(pair) != 2 {
+		return "", "", fmt.Errorf("Invalid hash field %s", encoded)
+	}
+
+	return pair[0], pair[1], nil
+}
+
+// encodeSortedSetMember encodes a member of a sorted set and its score as the
+// json object stored in the value column of its row
+func encodeSortedSetMember(member SortedSetMember) string {
+	encoded, _ := json.Marshal(member)
+	return string(encoded)
+}
+
+// decodeSortedSetMember decodes the value column of a row holding a member of
+// a sorted set
+func decodeSortedSetMember(encoded string) (SortedSetMember, error) {
+	var member SortedSetMember
+	if err := json.Unmarshal([]byte(encoded), &member); err != nil {
+		return SortedSetMember{}, fmt.Errorf("Invalid sorted set member %s", encoded)
+	}
+
+	return member, nil
+}
+
+// rebindNumbered converts $N placeholders to ?N placeholders
+func rebindNumbered(query string) string {
+	return strings.ReplaceAll(query, "$", "?")
+}
+
+func uniqueStrings(ss []string) []string {
+	seen := make(map[string]bool)
+	unique := []string{}
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xo/dburl"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDialect creates the properties table using the same logical schema
+// as the postgres backend. Transactions are started with BEGIN IMMEDIATE, so
+// read-modify-write operations are serialized by the database lock.
+var sqliteDialect = sqlDialect{
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS "properties" (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"namespace" varchar NOT NULL DEFAULT 'default',
+			"data_type" varchar NOT NULL,
+			"key" varchar NOT NULL,
+			"value" text NOT NULL,
+			"created_at" timestamp
+		)`,
+		`CREATE INDEX IF NOT EXISTS "namespace_by_data_type" ON "properties" ("namespace", "data_type")`,
+		`CREATE INDEX IF NOT EXISTS "namespace_by_key" ON "properties" ("namespace", "key")`,
+		`CREATE TABLE IF NOT EXISTS "expirations" (
+			"namespace" varchar NOT NULL,
+			"key" varchar NOT NULL,
+			"expires_at" bigint NOT NULL,
+			PRIMARY KEY ("namespace", "key")
+		)`,
+		`CREATE INDEX IF NOT EXISTS "expirations_by_expires_at" ON "expirations" ("expires_at")`,
+	},
+	rebind: rebindNumbered,
+}
+
+func init() {
+	Register("sqlite", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewSQLiteBackend(ctx, namespace, u)
+	})
+}
+
+type SQLiteBackend struct {
+	sqlBackend
+}
+
+// NewSQLiteBackend create new instance of SQLiteBackend
+func NewSQLiteBackend(ctx context.Context, namespace string, url *dburl.URL) (SQLiteBackend, error) {
+	filename := url.Opaque
+	if filename == "" {
+		return SQLiteBackend{}, fmt.Errorf("Invalid sqlite url: missing database path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return SQLiteBackend{}, fmt.Errorf("Unable to create database directory: %s", err.Error())
+	}
+
+	dsn := filename + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return SQLiteBackend{}, fmt.Errorf("Unable to open sqlite database: %s", err.Error())
+	}
+
+	return NewSQLiteBackendWithDB(ctx, namespace, db)
+}
+
+// NewSQLiteBackendWithDB create new instance of SQLiteBackend using an
+// existing database handle, creating the properties table if necessary
+func NewSQLiteBackendWithDB(ctx context.Context, namespace string, db *sql.DB) (SQLiteBackend, error) {
+	backend, err := newSQLBackend(ctx, namespace, db, sqliteDialect)
+	if err != nil {
+		return SQLiteBackend{}, err
+	}
+
+	return SQLiteBackend{backend}, nil
+}
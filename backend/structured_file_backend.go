@@ -0,0 +1,1375 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+func init() {
+	Register("file+json", func(ctx context.Context, namespace string, u *dburl.URL) (Backend, error) {
+		return NewStructuredFileBackend(namespace, u)
+	})
+}
+
+// fileEnvelope is the json document written for each key, recording the data
+// type alongside the value
+type fileEnvelope struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// fileValue is a decoded key. The data type is empty for legacy files written
+// by the UnstructuredFileBackend, which hold the raw file contents in value,
+// each line of the file in elements, each field=value line in fields and
+// each "score member" line in scores.
+type fileValue struct {
+	dataType string
+	value    string
+	elements []string
+	fields   map[string]string
+	scores   map[string]float64
+}
+
+// StructuredFileBackend stores each key as a json envelope recording its
+// data type. Legacy files written by the UnstructuredFileBackend may be read
+// as any data type, and are converted when next written.
+type StructuredFileBackend struct {
+	UnstructuredFileBackend
+}
+
+// NewStructuredFileBackend create new instance of StructuredFileBackend
+func NewStructuredFileBackend(namespace string, url *dburl.URL) (StructuredFileBackend, error) {
+	backend, err := NewUnstructuredFileBackend(namespace, url)
+	if err != nil {
+		return StructuredFileBackend{}, err
+	}
+
+	return StructuredFileBackend{backend}, nil
+}
+
+// BackendExport exports every key in every namespace. Legacy files are
+// exported as key_value properties holding the raw file contents.
+func (backend StructuredFileBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	p := PropertyCollection{Properties: []Property{}}
+	entries, err := os.ReadDir(backend.Root)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("Unable to export backend: %s", err.Error())
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		b := backend.withNamespace(entry.Name())
+		keys, err := b.keys()
+		if err != nil {
+			return p, fmt.Errorf("Unable to export backend: %s", err.Error())
+		}
+
+		for _, key := range keys {
+			value, _, err := b.readKey(ctx, key)
+			if err != nil {
+				return p, err
+			}
+
+			property := Property{
+				DataType:  value.dataType,
+				Namespace: b.Namespace,
+				Key:       key,
+				Value:     value.elements,
+			}
+			if value.dataType == "" || value.dataType == DataTypeKeyValue {
+				property.DataType = DataTypeKeyValue
+				property.Value = value.value
+			}
+			if value.dataType == DataTypeHash {
+				property.Value = value.fields
+			}
+			if value.dataType == DataTypeSortedSet {
+				property.Value = value.scores
+			}
+			p.Properties = append(p.Properties, property)
+		}
+	}
+
+	return p, nil
+}
+
+func (backend StructuredFileBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	if clear {
+		if _, err := backend.BackendReset(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	ctx, unlock, err := backend.lockNamespaces(ctx, propertyNamespaces(p)...)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for _, property := range p.Properties {
+		b := backend.withNamespace(property.Namespace)
+		if _, err := b.Del(ctx, property.Key); err != nil {
+			return false, err
+		}
+
+		switch property.DataType {
+		case DataTypeKeyValue:
+			value, err := property.StringValue()
+			if err != nil {
+				return false, err
+			}
+			if _, err := b.Set(ctx, property.Key, value); err != nil {
+				return false, err
+			}
+		case DataTypeList:
+			elements, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeList(ctx, property.Key, elements); err != nil {
+				return false, err
+			}
+		case DataTypeSet:
+			members, err := property.ListValue()
+			if err != nil {
+				return false, err
+			}
+			memberMap := make(map[string]bool)
+			for _, member := range members {
+				memberMap[member] = true
+			}
+			if err := b.writeSet(ctx, property.Key, memberMap); err != nil {
+				return false, err
+			}
+		case DataTypeHash:
+			fields, err := property.HashValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeHash(ctx, property.Key, fields); err != nil {
+				return false, err
+			}
+		case DataTypeSortedSet:
+			scores, err := property.SortedSetValue()
+			if err != nil {
+				return false, err
+			}
+			if err := b.writeSortedSet(ctx, property.Key, scores); err != nil {
+				return false, err
+			}
+		default:
+			return false, fmt.Errorf("Invalid data type for property %s.%s: %s", property.Namespace, property.Key, property.DataType)
+		}
+	}
+
+	return true, nil
+}
+
+func (backend StructuredFileBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	value, exists, err := backend.readKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		if defaultValue != "" {
+			return defaultValue, nil
+		}
+
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if err := backend.checkDataType(key, value, DataTypeKeyValue); err != nil {
+		return "", err
+	}
+
+	return value.value, nil
+}
+
+func (backend StructuredFileBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	value, revision, err := backend.readKeyWithRevision(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	if revision == 0 {
+		return "", 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if err := backend.checkDataType(key, value, DataTypeKeyValue); err != nil {
+		return "", 0, err
+	}
+
+	return value.value, revision, nil
+}
+
+func (backend StructuredFileBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	keyValuePairs := make(map[string]string)
+	keys, err := backend.keys()
+	if err != nil {
+		return keyValuePairs, err
+	}
+
+	for _, key := range keys {
+		value, _, err := backend.readKey(ctx, key)
+		if err != nil {
+			return keyValuePairs, err
+		}
+
+		if value.dataType == "" || value.dataType == DataTypeKeyValue {
+			keyValuePairs[key] = value.value
+		}
+	}
+
+	return keyValuePairs, nil
+}
+
+func (backend StructuredFileBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keyValuePairs, err := backend.GetAll(ctx)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	response := make(map[string]string)
+	for key, value := range keyValuePairs {
+		if strings.HasPrefix(key, prefix) {
+			response[key] = value
+		}
+	}
+
+	return response, nil
+}
+
+func (backend StructuredFileBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return backend.SetWithTTL(ctx, key, value, 0)
+}
+
+func (backend StructuredFileBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	err = backend.writeWithExpiry(key, ttl, func() error {
+		return backend.writeKey(key, DataTypeKeyValue, value)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend StructuredFileBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	exists, err := backend.Exists(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return backend.SetWithTTL(ctx, key, value, ttl)
+}
+
+func (backend StructuredFileBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	previous, err := backend.Get(ctx, key, "")
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+	if err != nil {
+		return false, err
+	}
+	if previous != oldValue {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return backend.SetWithTTL(ctx, key, value, ttl)
+}
+
+func (backend StructuredFileBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	_, current, err := backend.GetWithRevision(ctx, key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return false, err
+	}
+	if current != revision {
+		return false, newKeyError(backend.Namespace, key, ErrConditionFailed)
+	}
+
+	return backend.SetWithTTL(ctx, key, value, ttl)
+}
+
+func (backend StructuredFileBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	previous, err := backend.Get(ctx, key, "")
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return "", err
+	}
+
+	if _, err := backend.Set(ctx, key, value); err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+func (backend StructuredFileBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, 1)
+}
+
+// IncrBy holds the lock on the key while reading and writing its value, and
+// keeps any expiry of the key
+func (backend StructuredFileBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	current, err := backend.Get(ctx, key, "")
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return 0, err
+	}
+
+	value, err := incrementValue(backend.Namespace, key, current, err == nil, increment)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := backend.writeKey(key, DataTypeKeyValue, strconv.FormatInt(value, 10)); err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (backend StructuredFileBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return backend.IncrBy(ctx, key, -1)
+}
+
+func (backend StructuredFileBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, backend.notifyKey, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, false))
+	})
+}
+
+func (backend StructuredFileBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return blockingPop(ctx, backend.Namespace, key, timeout, backend.notifyKey, func() (string, error) {
+		return firstElement(backend.pop(ctx, key, 1, true))
+	})
+}
+
+func (backend StructuredFileBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	elements, exists, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if index < 0 {
+		index = len(elements) + index
+	}
+	if index < 0 || index >= len(elements) {
+		return "", newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+
+	return elements[index], nil
+}
+
+func (backend StructuredFileBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := validateListPosition(backend.Namespace, key, position); err != nil {
+		return 0, err
+	}
+
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, exists, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	elements, err = insertElement(backend.Namespace, key, elements, position, pivot, element)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend StructuredFileBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	elements, _, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range elements {
+		if e == element {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (backend StructuredFileBackend) Llen(ctx context.Context, key string) (int, error) {
+	elements, _, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend StructuredFileBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := validateListDirections(backend.Namespace, source, from, to); err != nil {
+		return "", err
+	}
+
+	ctx, unlock, err := backend.lockKeys(ctx, source, destination)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	sourceElements, exists, err := backend.readElements(ctx, source, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", newKeyError(backend.Namespace, source, ErrKeyNotFound)
+	}
+
+	destinationElements, _, err := backend.readElements(ctx, destination, DataTypeList)
+	if err != nil {
+		return "", err
+	}
+
+	element, sourceElements, destinationElements := moveElement(sourceElements, destinationElements, source == destination, from, to)
+	if source != destination {
+		if err := backend.writeList(ctx, source, sourceElements); err != nil {
+			return "", err
+		}
+	}
+
+	if err := backend.writeList(ctx, destination, destinationElements); err != nil {
+		return "", err
+	}
+
+	return element, nil
+}
+
+func (backend StructuredFileBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, false)
+}
+
+func (backend StructuredFileBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := validateListPositionOptions(backend.Namespace, key, rank, count); err != nil {
+		return []int{}, err
+	}
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return []int{}, err
+	}
+
+	return listPositions(elements, element, rank, count), nil
+}
+
+func (backend StructuredFileBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	elements = prependElements(elements, newElements)
+
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend StructuredFileBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	elements, _, err := backend.readElements(ctx, key, DataTypeList)
+	return elements, err
+}
+
+func (backend StructuredFileBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return backend.Lrangefromto(ctx, key, start, -1)
+}
+
+func (backend StructuredFileBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	offset, limit, ok := normalizeListRange(len(elements), start, stop)
+	if !ok {
+		return []string{}, nil
+	}
+
+	return elements[offset : offset+limit], nil
+}
+
+func (backend StructuredFileBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if countToRemove < 0 {
+		reverse(elements)
+	}
+
+	newElements := []string{}
+	removed := 0
+	for _, e := range elements {
+		if e == element && (countToRemove == 0 || removed < abs(countToRemove)) {
+			removed++
+			continue
+		}
+		newElements = append(newElements, e)
+	}
+
+	if countToRemove < 0 {
+		reverse(newElements)
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeList(ctx, key, newElements); err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func (backend StructuredFileBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	elements, exists, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	if index < 0 {
+		index = len(elements) + index
+	}
+	if index < 0 || index >= len(elements) {
+		return false, newKeyError(backend.Namespace, key, ErrIndexOutOfRange)
+	}
+
+	elements[index] = element
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend StructuredFileBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	offset, limit, ok := normalizeListRange(len(elements), start, stop)
+	if !ok {
+		offset, limit = 0, 0
+	}
+
+	if err = backend.writeList(ctx, key, elements[offset:offset+limit]); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend StructuredFileBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	return backend.pop(ctx, key, count, true)
+}
+
+func (backend StructuredFileBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	elements, err := backend.Lrange(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	elements = append(elements, newElements...)
+
+	if err = backend.writeList(ctx, key, elements); err != nil {
+		return 0, err
+	}
+
+	return len(elements), nil
+}
+
+func (backend StructuredFileBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for _, member := range newMembers {
+		if _, ok := members[member]; !ok {
+			members[member] = true
+			addedCount++
+		}
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend StructuredFileBackend) Scard(ctx context.Context, key string) (int, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(members), nil
+}
+
+func (backend StructuredFileBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, diffMembers)
+}
+
+func (backend StructuredFileBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, diffMembers)
+}
+
+func (backend StructuredFileBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, interMembers)
+}
+
+func (backend StructuredFileBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, interMembers)
+}
+
+func (backend StructuredFileBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := members[member]
+	return ok, nil
+}
+
+func (backend StructuredFileBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	members := make(map[string]bool)
+	elements, _, err := backend.readElements(ctx, key, DataTypeSet)
+	if err != nil {
+		return members, err
+	}
+
+	for _, element := range elements {
+		members[element] = true
+	}
+
+	return members, nil
+}
+
+func (backend StructuredFileBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	ctx, unlock, err := backend.lockKeys(ctx, source, destination)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	sourceMembers, err := backend.Smembers(ctx, source)
+	if err != nil {
+		return false, err
+	}
+
+	destinationMembers, err := backend.Smembers(ctx, destination)
+	if err != nil {
+		return false, err
+	}
+
+	if !sourceMembers[member] {
+		return false, nil
+	}
+	if source == destination {
+		return true, nil
+	}
+
+	delete(sourceMembers, member)
+	if err := backend.writeSet(ctx, source, sourceMembers); err != nil {
+		return false, err
+	}
+
+	destinationMembers[member] = true
+	if err := backend.writeSet(ctx, destination, destinationMembers); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (backend StructuredFileBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+	defer unlock()
+
+	elements, exists, err := backend.readElements(ctx, key, DataTypeSet)
+	if err != nil {
+		return []string{}, err
+	}
+	if !exists {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	members := map[string]bool{}
+	for _, element := range elements {
+		members[element] = true
+	}
+
+	popped := randomMembers(members, count)
+	for _, member := range popped {
+		delete(members, member)
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return []string{}, err
+	}
+
+	return popped, nil
+}
+
+func (backend StructuredFileBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return randomMembers(members, count), nil
+}
+
+func (backend StructuredFileBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.Smembers(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if _, ok := members[member]; ok {
+			delete(members, member)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSet(ctx, key, members); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend StructuredFileBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return backend.combine(ctx, keys, unionMembers)
+}
+
+func (backend StructuredFileBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return backend.combineStore(ctx, destination, keys, unionMembers)
+}
+
+func (backend StructuredFileBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, field := range fieldsToRemove {
+		if _, ok := fields[field]; ok {
+			delete(fields, field)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeHash(ctx, key, fields); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend StructuredFileBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := fields[field]
+	return ok, nil
+}
+
+func (backend StructuredFileBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	fields, exists, err := backend.readFields(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", newKeyError(backend.Namespace, key, ErrFieldNotFound)
+	}
+
+	return value, nil
+}
+
+func (backend StructuredFileBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	fields, _, err := backend.readFields(ctx, key)
+	return fields, err
+}
+
+func (backend StructuredFileBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return sortedFields(fields), nil
+}
+
+func (backend StructuredFileBackend) Hlen(ctx context.Context, key string) (int, error) {
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(fields), nil
+}
+
+func (backend StructuredFileBackend) Hset(ctx context.Context, key string, newFields map[string]string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	fields, err := backend.Hgetall(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for field, value := range newFields {
+		if _, ok := fields[field]; !ok {
+			addedCount++
+		}
+		fields[field] = value
+	}
+
+	if len(newFields) == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeHash(ctx, key, fields); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend StructuredFileBackend) Zadd(ctx context.Context, key string, newScores map[string]float64) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	scores, _, err := backend.readScores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	for member, score := range newScores {
+		if _, ok := scores[member]; !ok {
+			addedCount++
+		}
+		scores[member] = score
+	}
+
+	if len(newScores) == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSortedSet(ctx, key, scores); err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (backend StructuredFileBackend) Zcard(ctx context.Context, key string) (int, error) {
+	scores, _, err := backend.readScores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(scores), nil
+}
+
+func (backend StructuredFileBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	scores, _, err := backend.readScores(ctx, key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByRank(sortedScores(scores), start, stop), nil
+}
+
+func (backend StructuredFileBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	scores, _, err := backend.readScores(ctx, key)
+	if err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return rangeByScore(sortedScores(scores), min, max), nil
+}
+
+func (backend StructuredFileBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	scores, exists, err := backend.readScores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	rank, ok := rankOf(sortedScores(scores), member)
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return rank, nil
+}
+
+func (backend StructuredFileBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	scores, _, err := backend.readScores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removedCount := 0
+	for _, member := range membersToRemove {
+		if _, ok := scores[member]; ok {
+			delete(scores, member)
+			removedCount++
+		}
+	}
+
+	if removedCount == 0 {
+		return 0, nil
+	}
+
+	if err = backend.writeSortedSet(ctx, key, scores); err != nil {
+		return 0, err
+	}
+
+	return removedCount, nil
+}
+
+func (backend StructuredFileBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	scores, exists, err := backend.readScores(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	score, ok := scores[member]
+	if !ok {
+		return 0, newKeyError(backend.Namespace, key, ErrMemberNotFound)
+	}
+
+	return score, nil
+}
+
+// withNamespace returns a copy of the backend bound to another namespace
+func (backend StructuredFileBackend) withNamespace(namespace string) StructuredFileBackend {
+	return StructuredFileBackend{backend.UnstructuredFileBackend.withNamespace(namespace)}
+}
+
+// inNamespace returns a copy of the backend bound to another namespace
+func (backend StructuredFileBackend) inNamespace(namespace string) Backend {
+	return backend.withNamespace(namespace)
+}
+
+// readKey decodes the file holding a key, falling back to reading the file as
+// a legacy value if it does not hold a json envelope
+func (backend StructuredFileBackend) readKey(ctx context.Context, key string) (fileValue, bool, error) {
+	value, revision, err := backend.readKeyWithRevision(ctx, key)
+	return value, revision != 0, err
+}
+
+// readKeyWithRevision decodes a key as readKey does, returning its revision,
+// or 0 if the key does not exist
+func (backend StructuredFileBackend) readKeyWithRevision(ctx context.Context, key string) (fileValue, int64, error) {
+	if exists, _ := backend.Exists(ctx, key); !exists {
+		return fileValue{}, 0, nil
+	}
+
+	b, revision, err := backend.readKeyFile(key)
+	if err != nil {
+		return fileValue{}, 0, err
+	}
+
+	var envelope fileEnvelope
+	if err := json.Unmarshal(b, &envelope); err == nil && envelope.Value != nil {
+		switch envelope.Type {
+		case DataTypeKeyValue:
+			var value string
+			if err := json.Unmarshal(envelope.Value, &value); err == nil {
+				return fileValue{dataType: envelope.Type, value: value}, revision, nil
+			}
+		case DataTypeList, DataTypeSet:
+			elements := []string{}
+			if err := json.Unmarshal(envelope.Value, &elements); err == nil {
+				return fileValue{dataType: envelope.Type, elements: elements}, revision, nil
+			}
+		case DataTypeHash:
+			fields := map[string]string{}
+			if err := json.Unmarshal(envelope.Value, &fields); err == nil {
+				return fileValue{dataType: envelope.Type, fields: fields}, revision, nil
+			}
+		case DataTypeSortedSet:
+			scores := map[string]float64{}
+			if err := json.Unmarshal(envelope.Value, &scores); err == nil {
+				return fileValue{dataType: envelope.Type, scores: scores}, revision, nil
+			}
+		}
+	}
+
+	value := fileValue{value: string(b), elements: []string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		value.elements = append(value.elements, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fileValue{}, 0, fmt.Errorf("Unable to read config value for %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+	value.fields = parseHashLines(value.elements)
+	value.scores = parseSortedSetLines(value.elements)
+
+	return value, revision, nil
+}
+
+// readElements returns the elements of a list or set, which is empty if the
+// key does not exist
+func (backend StructuredFileBackend) readElements(ctx context.Context, key string, dataType string) ([]string, bool, error) {
+	value, exists, err := backend.readKey(ctx, key)
+	if err != nil || !exists {
+		return []string{}, false, err
+	}
+
+	if err := backend.checkDataType(key, value, dataType); err != nil {
+		return []string{}, true, err
+	}
+
+	return value.elements, true, nil
+}
+
+// readFields returns the fields of a hash, which is empty if the key does not
+// exist
+func (backend StructuredFileBackend) readFields(ctx context.Context, key string) (map[string]string, bool, error) {
+	value, exists, err := backend.readKey(ctx, key)
+	if err != nil || !exists {
+		return map[string]string{}, false, err
+	}
+
+	if err := backend.checkDataType(key, value, DataTypeHash); err != nil {
+		return map[string]string{}, true, err
+	}
+
+	return value.fields, true, nil
+}
+
+// readScores returns the scores of a sorted set, which is empty if the key
+// does not exist
+func (backend StructuredFileBackend) readScores(ctx context.Context, key string) (map[string]float64, bool, error) {
+	value, exists, err := backend.readKey(ctx, key)
+	if err != nil || !exists {
+		return map[string]float64{}, false, err
+	}
+
+	if err := backend.checkDataType(key, value, DataTypeSortedSet); err != nil {
+		return map[string]float64{}, true, err
+	}
+
+	return value.scores, true, nil
+}
+
+// checkDataType returns an error if a decoded key holds another data type.
+// Legacy values are compatible with every data type.
+func (backend StructuredFileBackend) checkDataType(key string, value fileValue, dataType string) error {
+	if value.dataType == "" || value.dataType == dataType {
+		return nil
+	}
+
+	return newKeyError(backend.Namespace, key, ErrWrongType)
+}
+
+// writeKey writes a json envelope holding the data type and value of a key
+func (backend StructuredFileBackend) writeKey(key string, dataType string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("Unable to encode config value %s.%s: %s", backend.Namespace, key, err.Error())
+	}
+
+	contents := prettyPrint(fileEnvelope{Type: dataType, Value: raw}) + "\n"
+	return backend.writeKeyFile(key, []byte(contents))
+}
+
+// combine applies op to the members of the sets held by keys, where missing
+// keys are treated as empty sets
+func (backend StructuredFileBackend) combine(ctx context.Context, keys []string, op setOperation) (map[string]bool, error) {
+	sets := []map[string]bool{}
+	for _, key := range keys {
+		members, err := backend.Smembers(ctx, key)
+		if err != nil {
+			return map[string]bool{}, err
+		}
+		sets = append(sets, members)
+	}
+
+	return op(sets), nil
+}
+
+// combineStore replaces the destination key, whatever its data type, with
+// the set resulting from combine, removing it if the set is empty. Every key
+// is locked, so that the sets cannot change while they are combined.
+func (backend StructuredFileBackend) combineStore(ctx context.Context, destination string, keys []string, op setOperation) (int, error) {
+	ctx, unlock, err := backend.lockKeys(ctx, append([]string{destination}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	members, err := backend.combine(ctx, keys, op)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(members) == 0 {
+		_, err := backend.Del(ctx, destination)
+		return 0, err
+	}
+
+	if err := backend.writeSet(ctx, destination, members); err != nil {
+		return 0, err
+	}
+
+	if err := backend.removeExpiry(destination); err != nil {
+		return 0, fmt.Errorf("Unable to write config value %s.%s: %s", backend.Namespace, destination, err.Error())
+	}
+
+	return len(members), nil
+}
+
+// pop removes up to count elements from the head or tail of a list
+func (backend StructuredFileBackend) pop(ctx context.Context, key string, count int, fromTail bool) ([]string, error) {
+	if err := validatePopCount(backend.Namespace, key, count); err != nil {
+		return []string{}, err
+	}
+
+	ctx, unlock, err := backend.lockKey(ctx, key)
+	if err != nil {
+		return []string{}, err
+	}
+	defer unlock()
+
+	elements, exists, err := backend.readElements(ctx, key, DataTypeList)
+	if err != nil {
+		return []string{}, err
+	}
+	if !exists {
+		return []string{}, newKeyError(backend.Namespace, key, ErrKeyNotFound)
+	}
+
+	popped, remaining := popElements(elements, count, fromTail)
+	if err = backend.writeList(ctx, key, remaining); err != nil {
+		return []string{}, err
+	}
+
+	return popped, nil
+}
+
+// writeList writes the elements of a list, removing the key if the list is empty
+func (backend StructuredFileBackend) writeList(ctx context.Context, key string, elements []string) error {
+	if len(elements) == 0 {
+		_, err := backend.Del(ctx, key)
+		return err
+	}
+
+	return backend.writeKey(key, DataTypeList, elements)
+}
+
+// writeSet writes the members of a set in sorted order, removing the key if
+// the set is empty
+func (backend StructuredFileBackend) writeSet(ctx context.Context, key string, members map[string]bool) error {
+	if len(members) == 0 {
+		_, err := backend.Del(ctx, key)
+		return err
+	}
+
+	return backend.writeKey(key, DataTypeSet, sortedMembers(members))
+}
+
+// writeHash writes the fields of a hash, removing the key if the hash is empty
+func (backend StructuredFileBackend) writeHash(ctx context.Context, key string, fields map[string]string) error {
+	if len(fields) == 0 {
+		_, err := backend.Del(ctx, key)
+		return err
+	}
+
+	return backend.writeKey(key, DataTypeHash, fields)
+}
+
+// writeSortedSet writes the scores of a sorted set, removing the key if the
+// sorted set is empty
+func (backend StructuredFileBackend) writeSortedSet(ctx context.Context, key string, scores map[string]float64) error {
+	if len(scores) == 0 {
+		_, err := backend.Del(ctx, key)
+		return err
+	}
+
+	return backend.writeKey(key, DataTypeSortedSet, scores)
+}
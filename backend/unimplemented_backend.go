@@ -1,7 +1,8 @@
 package backend
 
 import (
-	"fmt"
+	"context"
+	"time"
 )
 
 type UnimplementedBackend struct {
@@ -12,100 +13,276 @@ func NewUnimplementedBackend() (UnimplementedBackend, error) {
 	return UnimplementedBackend{}, nil
 }
 
-func (backend UnimplementedBackend) BackendExport() (PropertyCollection, error) {
-	return PropertyCollection{}, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	return PropertyCollection{}, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) BackendImport(p PropertyCollection, clear bool) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) BackendReset() (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) BackendReset(ctx context.Context) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Del(key string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Del(ctx context.Context, key string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Exists(key string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Exists(ctx context.Context, key string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) NamespaceExists(namespace string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) NamespaceClear(namespace string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Get(key string, defaultValue string) (string, error) {
-	return "", fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	return "", ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) GetAll() (map[string]string, error) {
+func (backend UnimplementedBackend) GetAll(ctx context.Context) (map[string]string, error) {
 	keyValuePairs := make(map[string]string)
-	return keyValuePairs, fmt.Errorf("Not implemented")
+	return keyValuePairs, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) GetAllByPrefix(prefix string) (map[string]string, error) {
+func (backend UnimplementedBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
 	keyValuePairs := make(map[string]string)
-	return keyValuePairs, fmt.Errorf("Not implemented")
+	return keyValuePairs, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Set(key string, value string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lindex(key string, index int) (string, error) {
-	return "", fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lismember(key string, element string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Llen(key string) (int, error) {
-	return 0, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lrange(key string) ([]string, error) {
-	return []string{}, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lrangefrom(key string, start int) ([]string, error) {
-	return []string{}, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	return "", ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lrangefromto(key string, start int, stop int) ([]string, error) {
-	return []string{}, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return 0, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lrem(key string, countToRemove int, element string) (int, error) {
-	return 0, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	return 0, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Lset(key string, index int, element string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Decr(ctx context.Context, key string) (int64, error) {
+	return 0, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Rpush(key string, newElements ...string) (int, error) {
-	return 0, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Sadd(key string, newMembers ...string) (int, error) {
-	return 0, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Persist(ctx context.Context, key string) (bool, error) {
+	return false, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Sismember(key string, member string) (bool, error) {
-	return false, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Smembers(key string) (map[string]bool, error) {
-	return map[string]bool{}, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return "", ErrNotImplemented
 }
 
-func (backend UnimplementedBackend) Srem(key string, membersToRemove ...string) (int, error) {
-	return 0, fmt.Errorf("Not implemented")
+func (backend UnimplementedBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return "", ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	return "", ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Llen(ctx context.Context, key string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	return "", ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	return []int{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Scard(ctx context.Context, key string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return map[string]bool{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return map[string]bool{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	return map[string]bool{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	return map[string]bool{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	return false, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	return "", ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	return map[string]string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	return []string{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hlen(ctx context.Context, key string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zcard(ctx context.Context, key string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	return []SortedSetMember{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	return []SortedSetMember{}, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func (backend UnimplementedBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	return 0, ErrNotImplemented
 }
@@ -0,0 +1,723 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"math"
+	"time"
+)
+
+// ValidatingBackend validates the namespaces, keys and values passed to a
+// backend before forwarding each call. ConstructBackend wraps every backend
+// it returns, so backends need not validate their input themselves.
+type ValidatingBackend struct {
+	Backend   Backend
+	Namespace string
+}
+
+// NewValidatingBackend create new instance of ValidatingBackend
+func NewValidatingBackend(namespace string, backend Backend) (ValidatingBackend, error) {
+	if err := ValidateNamespace(namespace); err != nil {
+		return ValidatingBackend{}, err
+	}
+
+	return ValidatingBackend{Backend: backend, Namespace: namespace}, nil
+}
+
+// Close closes the wrapped backend if it holds resources that must be released
+func (backend ValidatingBackend) Close() error {
+	if closer, ok := backend.Backend.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Transaction runs fn within a transaction of the wrapped backend. If the
+// wrapped backend does not support transactions, ErrNotImplemented is
+// returned without calling fn.
+func (backend ValidatingBackend) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	b, ok := backend.Backend.(TransactionalBackend)
+	if !ok {
+		return ErrNotImplemented
+	}
+
+	return b.Transaction(ctx, fn)
+}
+
+// Watch watches the wrapped backend for changes. If the wrapped backend does
+// not support watching, ErrNotImplemented is returned.
+func (backend ValidatingBackend) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	b, ok := backend.Backend.(WatchableBackend)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+
+	return b.Watch(ctx, prefix)
+}
+
+// History returns the changes recorded for a key by the wrapped backend. If
+// the wrapped backend does not record history, ErrNotImplemented is returned.
+func (backend ValidatingBackend) History(ctx context.Context, key string) ([]HistoryEntry, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []HistoryEntry{}, err
+	}
+
+	b, ok := backend.Backend.(HistoricalBackend)
+	if !ok {
+		return []HistoryEntry{}, ErrNotImplemented
+	}
+
+	return b.History(ctx, key)
+}
+
+// Rollback restores a key to a revision recorded by the wrapped backend. If
+// the wrapped backend does not record history, ErrNotImplemented is returned.
+func (backend ValidatingBackend) Rollback(ctx context.Context, key string, revision int) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	b, ok := backend.Backend.(HistoricalBackend)
+	if !ok {
+		return false, ErrNotImplemented
+	}
+
+	return b.Rollback(ctx, key, revision)
+}
+
+// Unwrap returns the wrapped backend
+func (backend ValidatingBackend) Unwrap() Backend {
+	return backend.Backend
+}
+
+func (backend ValidatingBackend) BackendExport(ctx context.Context) (PropertyCollection, error) {
+	return backend.Backend.BackendExport(ctx)
+}
+
+func (backend ValidatingBackend) BackendImport(ctx context.Context, p PropertyCollection, clear bool) (bool, error) {
+	for _, property := range p.Properties {
+		if err := validateProperty(property); err != nil {
+			return false, err
+		}
+	}
+
+	return backend.Backend.BackendImport(ctx, p, clear)
+}
+
+func (backend ValidatingBackend) BackendReset(ctx context.Context) (bool, error) {
+	return backend.Backend.BackendReset(ctx)
+}
+
+func (backend ValidatingBackend) Del(ctx context.Context, key string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Del(ctx, key)
+}
+
+func (backend ValidatingBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Exists(ctx, key)
+}
+
+func (backend ValidatingBackend) NamespaceExists(ctx context.Context, namespace string) (bool, error) {
+	if err := ValidateNamespace(namespace); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.NamespaceExists(ctx, namespace)
+}
+
+func (backend ValidatingBackend) NamespaceClear(ctx context.Context, namespace string) (bool, error) {
+	if err := ValidateNamespace(namespace); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.NamespaceClear(ctx, namespace)
+}
+
+func (backend ValidatingBackend) Get(ctx context.Context, key string, defaultValue string) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Get(ctx, key, defaultValue)
+}
+
+func (backend ValidatingBackend) GetWithRevision(ctx context.Context, key string) (string, int64, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", 0, err
+	}
+
+	return backend.Backend.GetWithRevision(ctx, key)
+}
+
+func (backend ValidatingBackend) GetAll(ctx context.Context) (map[string]string, error) {
+	return backend.Backend.GetAll(ctx)
+}
+
+func (backend ValidatingBackend) GetAllByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	return backend.Backend.GetAllByPrefix(ctx, prefix)
+}
+
+func (backend ValidatingBackend) Set(ctx context.Context, key string, value string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Set(ctx, key, value)
+}
+
+func (backend ValidatingBackend) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.SetWithTTL(ctx, key, value, ttl)
+}
+
+func (backend ValidatingBackend) SetIfNotExists(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.SetIfNotExists(ctx, key, value, ttl)
+}
+
+func (backend ValidatingBackend) SetIfValue(ctx context.Context, key string, oldValue string, value string, ttl time.Duration) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.SetIfValue(ctx, key, oldValue, value, ttl)
+}
+
+func (backend ValidatingBackend) SetIfRevision(ctx context.Context, key string, revision int64, value string, ttl time.Duration) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.SetIfRevision(ctx, key, revision, value, ttl)
+}
+
+func (backend ValidatingBackend) GetSet(ctx context.Context, key string, value string) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, value); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.GetSet(ctx, key, value)
+}
+
+func (backend ValidatingBackend) Incr(ctx context.Context, key string) (int64, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Incr(ctx, key)
+}
+
+func (backend ValidatingBackend) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.IncrBy(ctx, key, increment)
+}
+
+func (backend ValidatingBackend) Decr(ctx context.Context, key string) (int64, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Decr(ctx, key)
+}
+
+func (backend ValidatingBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Expire(ctx, key, ttl)
+}
+
+func (backend ValidatingBackend) Persist(ctx context.Context, key string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Persist(ctx, key)
+}
+
+func (backend ValidatingBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.TTL(ctx, key)
+}
+
+func (backend ValidatingBackend) Blpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Blpop(ctx, key, timeout)
+}
+
+func (backend ValidatingBackend) Brpop(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Brpop(ctx, key, timeout)
+}
+
+func (backend ValidatingBackend) Lindex(ctx context.Context, key string, index int) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Lindex(ctx, key, index)
+}
+
+func (backend ValidatingBackend) Linsert(ctx context.Context, key string, position string, pivot string, element string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, element); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Linsert(ctx, key, position, pivot, element)
+}
+
+func (backend ValidatingBackend) Lismember(ctx context.Context, key string, element string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Lismember(ctx, key, element)
+}
+
+func (backend ValidatingBackend) Llen(ctx context.Context, key string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Llen(ctx, key)
+}
+
+func (backend ValidatingBackend) Lmove(ctx context.Context, source string, destination string, from string, to string) (string, error) {
+	if err := ValidateKey(backend.Namespace, source); err != nil {
+		return "", err
+	}
+	if err := ValidateKey(backend.Namespace, destination); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Lmove(ctx, source, destination, from, to)
+}
+
+func (backend ValidatingBackend) Lpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Lpop(ctx, key, count)
+}
+
+func (backend ValidatingBackend) Lpos(ctx context.Context, key string, element string, rank int, count int) ([]int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []int{}, err
+	}
+
+	return backend.Backend.Lpos(ctx, key, element, rank, count)
+}
+
+func (backend ValidatingBackend) Lpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	if err := validateValues(backend.Namespace, key, newElements); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Lpush(ctx, key, newElements...)
+}
+
+func (backend ValidatingBackend) Lrange(ctx context.Context, key string) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Lrange(ctx, key)
+}
+
+func (backend ValidatingBackend) Lrangefrom(ctx context.Context, key string, start int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Lrangefrom(ctx, key, start)
+}
+
+func (backend ValidatingBackend) Lrangefromto(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Lrangefromto(ctx, key, start, stop)
+}
+
+func (backend ValidatingBackend) Lrem(ctx context.Context, key string, countToRemove int, element string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Lrem(ctx, key, countToRemove, element)
+}
+
+func (backend ValidatingBackend) Lset(ctx context.Context, key string, index int, element string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	if err := ValidateValue(backend.Namespace, key, element); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Lset(ctx, key, index, element)
+}
+
+func (backend ValidatingBackend) Ltrim(ctx context.Context, key string, start int, stop int) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Ltrim(ctx, key, start, stop)
+}
+
+func (backend ValidatingBackend) Rpop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Rpop(ctx, key, count)
+}
+
+func (backend ValidatingBackend) Rpush(ctx context.Context, key string, newElements ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	if err := validateValues(backend.Namespace, key, newElements); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Rpush(ctx, key, newElements...)
+}
+
+func (backend ValidatingBackend) Sadd(ctx context.Context, key string, newMembers ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	if err := validateValues(backend.Namespace, key, newMembers); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Sadd(ctx, key, newMembers...)
+}
+
+func (backend ValidatingBackend) Scard(ctx context.Context, key string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Scard(ctx, key)
+}
+
+func (backend ValidatingBackend) Sdiff(ctx context.Context, keys ...string) (map[string]bool, error) {
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return map[string]bool{}, err
+		}
+	}
+
+	return backend.Backend.Sdiff(ctx, keys...)
+}
+
+func (backend ValidatingBackend) Sdiffstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, destination); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return backend.Backend.Sdiffstore(ctx, destination, keys...)
+}
+
+func (backend ValidatingBackend) Sinter(ctx context.Context, keys ...string) (map[string]bool, error) {
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return map[string]bool{}, err
+		}
+	}
+
+	return backend.Backend.Sinter(ctx, keys...)
+}
+
+func (backend ValidatingBackend) Sinterstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, destination); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return backend.Backend.Sinterstore(ctx, destination, keys...)
+}
+
+func (backend ValidatingBackend) Sismember(ctx context.Context, key string, member string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Sismember(ctx, key, member)
+}
+
+func (backend ValidatingBackend) Smembers(ctx context.Context, key string) (map[string]bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return map[string]bool{}, err
+	}
+
+	return backend.Backend.Smembers(ctx, key)
+}
+
+func (backend ValidatingBackend) Smove(ctx context.Context, source string, destination string, member string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, source); err != nil {
+		return false, err
+	}
+	if err := ValidateKey(backend.Namespace, destination); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Smove(ctx, source, destination, member)
+}
+
+func (backend ValidatingBackend) Spop(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Spop(ctx, key, count)
+}
+
+func (backend ValidatingBackend) Srandmember(ctx context.Context, key string, count int) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Srandmember(ctx, key, count)
+}
+
+func (backend ValidatingBackend) Srem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Srem(ctx, key, membersToRemove...)
+}
+
+func (backend ValidatingBackend) Sunion(ctx context.Context, keys ...string) (map[string]bool, error) {
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return map[string]bool{}, err
+		}
+	}
+
+	return backend.Backend.Sunion(ctx, keys...)
+}
+
+func (backend ValidatingBackend) Sunionstore(ctx context.Context, destination string, keys ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, destination); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if err := ValidateKey(backend.Namespace, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return backend.Backend.Sunionstore(ctx, destination, keys...)
+}
+
+func (backend ValidatingBackend) Hdel(ctx context.Context, key string, fieldsToRemove ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Hdel(ctx, key, fieldsToRemove...)
+}
+
+func (backend ValidatingBackend) Hexists(ctx context.Context, key string, field string) (bool, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return false, err
+	}
+
+	return backend.Backend.Hexists(ctx, key, field)
+}
+
+func (backend ValidatingBackend) Hget(ctx context.Context, key string, field string) (string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return "", err
+	}
+
+	return backend.Backend.Hget(ctx, key, field)
+}
+
+func (backend ValidatingBackend) Hgetall(ctx context.Context, key string) (map[string]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return map[string]string{}, err
+	}
+
+	return backend.Backend.Hgetall(ctx, key)
+}
+
+func (backend ValidatingBackend) Hkeys(ctx context.Context, key string) ([]string, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []string{}, err
+	}
+
+	return backend.Backend.Hkeys(ctx, key)
+}
+
+func (backend ValidatingBackend) Hlen(ctx context.Context, key string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Hlen(ctx, key)
+}
+
+func (backend ValidatingBackend) Hset(ctx context.Context, key string, fields map[string]string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	for field, value := range fields {
+		if err := ValidateValue(backend.Namespace, key, field); err != nil {
+			return 0, err
+		}
+		if err := ValidateValue(backend.Namespace, key, value); err != nil {
+			return 0, err
+		}
+	}
+
+	return backend.Backend.Hset(ctx, key, fields)
+}
+
+func (backend ValidatingBackend) Zadd(ctx context.Context, key string, scores map[string]float64) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	for member, score := range scores {
+		if err := ValidateValue(backend.Namespace, key, member); err != nil {
+			return 0, err
+		}
+		if err := ValidateScore(backend.Namespace, key, score); err != nil {
+			return 0, err
+		}
+	}
+
+	return backend.Backend.Zadd(ctx, key, scores)
+}
+
+func (backend ValidatingBackend) Zcard(ctx context.Context, key string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Zcard(ctx, key)
+}
+
+func (backend ValidatingBackend) Zrange(ctx context.Context, key string, start int, stop int) ([]SortedSetMember, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	return backend.Backend.Zrange(ctx, key, start, stop)
+}
+
+func (backend ValidatingBackend) Zrangebyscore(ctx context.Context, key string, min float64, max float64) ([]SortedSetMember, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return []SortedSetMember{}, err
+	}
+
+	if math.IsNaN(min) || math.IsNaN(max) {
+		return []SortedSetMember{}, newKeyError(backend.Namespace, key, ErrInvalidValue)
+	}
+
+	return backend.Backend.Zrangebyscore(ctx, key, min, max)
+}
+
+func (backend ValidatingBackend) Zrank(ctx context.Context, key string, member string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Zrank(ctx, key, member)
+}
+
+func (backend ValidatingBackend) Zrem(ctx context.Context, key string, membersToRemove ...string) (int, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Zrem(ctx, key, membersToRemove...)
+}
+
+func (backend ValidatingBackend) Zscore(ctx context.Context, key string, member string) (float64, error) {
+	if err := ValidateKey(backend.Namespace, key); err != nil {
+		return 0, err
+	}
+
+	return backend.Backend.Zscore(ctx, key, member)
+}
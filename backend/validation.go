@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxKeyLength is the maximum length of a key
+	MaxKeyLength = 200
+
+	// MaxNamespaceLength is the maximum length of a namespace
+	MaxNamespaceLength = 200
+
+	// MaxValueLength is the maximum number of utf8 characters in a value
+	MaxValueLength = 65535
+)
+
+var (
+	// keyPattern matches keys made of word characters, dashes and slashes
+	keyPattern = regexp.MustCompile(`^[\w\-/]+$`)
+
+	// namespacePattern matches namespaces made of word characters and dashes
+	namespacePattern = regexp.MustCompile(`^[\w\-]+$`)
+)
+
+// ValidateNamespace returns an ErrInvalidNamespace error unless the namespace
+// is made of 1 to 200 word characters or dashes. HistoryNamespace is reserved
+// for the history recorded by HistoryBackend.
+func ValidateNamespace(namespace string) error {
+	if namespace == HistoryNamespace {
+		return newKeyError(namespace, "", ErrInvalidNamespace)
+	}
+
+	return validateNamespaceName(namespace)
+}
+
+// validateNamespaceName validates a namespace without reserving
+// HistoryNamespace, for backends storing history and for importing exports
+// that include it
+func validateNamespaceName(namespace string) error {
+	if len(namespace) > MaxNamespaceLength || !namespacePattern.MatchString(namespace) {
+		return newKeyError(namespace, "", ErrInvalidNamespace)
+	}
+
+	return nil
+}
+
+// ValidateKey returns an ErrInvalidKey error unless the key is made of 1 to
+// 200 word characters, dashes or slashes. As keys may map to file paths,
+// slashes may only separate non-empty segments.
+func ValidateKey(namespace string, key string) error {
+	if len(key) > MaxKeyLength || !keyPattern.MatchString(key) {
+		return newKeyError(namespace, key, ErrInvalidKey)
+	}
+
+	if strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "//") {
+		return newKeyError(namespace, key, ErrInvalidKey)
+	}
+
+	return nil
+}
+
+// ValidateValue returns an ErrInvalidValue error unless the value is valid
+// utf8 of at most 65535 characters. List elements and set members are
+// validated as values.
+func ValidateValue(namespace string, key string, value string) error {
+	if !utf8.ValidString(value) || utf8.RuneCountInString(value) > MaxValueLength {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// ValidateScore returns an ErrInvalidValue error unless the score of a
+// sorted set member is a finite number
+func ValidateScore(namespace string, key string, score float64) error {
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return newKeyError(namespace, key, ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// validateValues validates each of a list of values stored under a key
+func validateValues(namespace string, key string, values []string) error {
+	for _, value := range values {
+		if err := ValidateValue(namespace, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateProperty validates the namespace, key and value of a property
+func validateProperty(property Property) error {
+	if err := validateNamespaceName(property.Namespace); err != nil {
+		return err
+	}
+
+	if err := ValidateKey(property.Namespace, property.Key); err != nil {
+		return err
+	}
+
+	if property.DataType == DataTypeKeyValue {
+		value, err := property.StringValue()
+		if err != nil {
+			return err
+		}
+
+		return ValidateValue(property.Namespace, property.Key, value)
+	}
+
+	if property.DataType == DataTypeHash {
+		fields, err := property.HashValue()
+		if err != nil {
+			return err
+		}
+
+		for field, value := range fields {
+			if err := validateValues(property.Namespace, property.Key, []string{field, value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if property.DataType == DataTypeSortedSet {
+		scores, err := property.SortedSetValue()
+		if err != nil {
+			return err
+		}
+
+		for member, score := range scores {
+			if err := ValidateValue(property.Namespace, property.Key, member); err != nil {
+				return err
+			}
+			if err := ValidateScore(property.Namespace, property.Key, score); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	values, err := property.ListValue()
+	if err != nil {
+		return err
+	}
+
+	return validateValues(property.Namespace, property.Key, values)
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventType describes how a watched key changed
+type EventType string
+
+const (
+	// EventChanged is sent when a key is created or its value is modified
+	EventChanged EventType = "changed"
+
+	// EventDeleted is sent when a key is deleted or expires
+	EventDeleted EventType = "deleted"
+)
+
+// watchPollInterval is how often backends without change notifications are
+// polled for changes
+const watchPollInterval = 500 * time.Millisecond
+
+// Event is a change to a watched key
+type Event struct {
+	Type      EventType `json:"type"`
+	Namespace string    `json:"namespace"`
+	Key       string    `json:"key"`
+}
+
+// WatchableBackend is implemented by backends that can report changes to
+// their keys. Watch sends an event for each change to a key in the namespace
+// of the backend that starts with prefix until ctx is done, after which the
+// channel is closed. Changes to the timeout of a key do not send events.
+type WatchableBackend interface {
+	Backend
+	Watch(ctx context.Context, prefix string) (<-chan Event, error)
+}
+
+// SupportsWatch returns true if b, or the backend wrapped by a
+// ValidatingBackend or HistoryBackend, implements WatchableBackend
+func SupportsWatch(b Backend) bool {
+	_, ok := unwrapBackend(b).(WatchableBackend)
+	return ok
+}
+
+// watchSnapshot returns a fingerprint of every watched key, which changes
+// whenever the value of the key is modified
+type watchSnapshot func(ctx context.Context) (map[string]string, error)
+
+// pollWatch watches for changes by comparing successive snapshots. As
+// snapshots are taken periodically, several changes to a key between two
+// snapshots result in a single event. Snapshots that fail, for instance
+// while a key is locked, are retried at the next interval.
+func pollWatch(ctx context.Context, namespace string, snapshot watchSnapshot) (<-chan Event, error) {
+	previous, err := snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := snapshot(ctx)
+			if err != nil {
+				continue
+			}
+
+			for _, event := range diffSnapshots(namespace, previous, current) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+		}
+	}()
+
+	return events, nil
+}
+
+// diffSnapshots returns the events describing the changes between two
+// snapshots, ordered by key
+func diffSnapshots(namespace string, previous map[string]string, current map[string]string) []Event {
+	events := []Event{}
+	for key, fingerprint := range current {
+		if previousFingerprint, ok := previous[key]; !ok || previousFingerprint != fingerprint {
+			events = append(events, Event{Type: EventChanged, Namespace: namespace, Key: key})
+		}
+	}
+
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			events = append(events, Event{Type: EventDeleted, Namespace: namespace, Key: key})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+	return events
+}
+
+// exportSnapshot returns a snapshot of the keys in a namespace starting with
+// prefix, taken from a full export of the backend
+func exportSnapshot(b Backend, namespace string, prefix string) watchSnapshot {
+	return func(ctx context.Context) (map[string]string, error) {
+		p, err := b.BackendExport(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot := map[string]string{}
+		for _, property := range p.Properties {
+			if property.Namespace != namespace || !strings.HasPrefix(property.Key, prefix) {
+				continue
+			}
+
+			value, err := json.Marshal(property.Value)
+			if err != nil {
+				return nil, err
+			}
+			snapshot[property.Key] = property.DataType + ":" + string(value)
+		}
+
+		return snapshot, nil
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -103,12 +104,16 @@ func parseArguments(args []string, arguments []Argument) (map[string]Argument, e
 		}
 
 		if !arguments[0].Optional {
-			return returnArguments, fmt.Errorf(errorMessage)
+			return returnArguments, errors.New(errorMessage)
 		}
 	}
 
 	if len(args) < minArgs {
-		return returnArguments, fmt.Errorf(errorMessage)
+		return returnArguments, errors.New(errorMessage)
+	}
+
+	if len(args) > maxArgs && (maxArgs == 0 || arguments[maxArgs-1].Type != ArgumentList) {
+		return returnArguments, errors.New(errorMessage)
 	}
 
 	hasListArgument := false
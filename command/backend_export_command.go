@@ -72,6 +72,10 @@ func (c *BackendExportCommand) ParsedArguments(args []string) (map[string]Argume
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *BackendExportCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *BackendExportCommand) Run(args []string) int {
 	flags := c.FlagSet()
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
@@ -86,28 +90,31 @@ func (c *BackendExportCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
-	p, err := b.BackendExport()
+	p, err := b.BackendExport(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	path := arguments["path"].StringValue()
 	success, err := backend.SerializePropertyCollection(p, path)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !success {
 		return 1
 	}
 
-	return 1
+	return 0
 }
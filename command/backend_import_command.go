@@ -93,28 +93,31 @@ func (c *BackendImportCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	path := arguments["path"].StringValue()
 	p, err := backend.DeserializePropertyCollection(path)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
-	imported, err := b.BackendImport(p, c.clearBackend)
+	imported, err := b.BackendImport(ctx, p, c.clearBackend)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !imported {
 		return 1
 	}
 
-	return 1
+	return 0
 }
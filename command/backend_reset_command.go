@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -79,21 +78,24 @@ func (c *BackendResetCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
-	success, err := b.BackendReset()
+	success, err := b.BackendReset(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !success {
 		return 1
 	}
 
-	return 1
+	return 0
 }
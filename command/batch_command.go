@@ -0,0 +1,568 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dokku/prop/backend"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// interactiveCommands run until interrupted, and so may not be part of a
+// batch
+var interactiveCommands = map[string]bool{
+	"shell": true,
+	"watch": true,
+}
+
+// readOnlyCommand is implemented by commands that never modify the backend,
+// which are skipped by a batch dry run
+type readOnlyCommand interface {
+	ReadOnly() bool
+}
+
+// parseableCommand is a command whose flags and arguments may be validated
+// without running it
+type parseableCommand interface {
+	cli.Command
+	NamedCommand
+	FlagSet() *flag.FlagSet
+	ParsedArguments(args []string) (map[string]Argument, error)
+}
+
+// batchLine is a single command read by the batch command
+type batchLine struct {
+	number int
+	text   string
+	name   string
+	args   []string
+}
+
+// errBatchLineFailed is returned when a command within a batch exits non-zero
+type errBatchLineFailed struct {
+	line     batchLine
+	exitCode int
+}
+
+func (e *errBatchLineFailed) Error() string {
+	return fmt.Sprintf("Line %d exited with code %d: %s", e.line.number, e.exitCode, e.line.text)
+}
+
+type BatchCommand struct {
+	Meta
+
+	dryRun bool
+}
+
+func (c *BatchCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *BatchCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "path",
+		Optional: true,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *BatchCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-dry-run": complete.PredictNothing,
+	}
+}
+
+func (c *BatchCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *BatchCommand) Examples() map[string]string {
+	return map[string]string{
+		"Apply the commands in a file":             "prop batch /tmp/commands.txt",
+		"Apply commands read from stdin":           "printf 'set a 1\\nset b 2\\n' | prop batch",
+		"Print the commands that would be applied": "prop batch --dry-run /tmp/commands.txt",
+	}
+}
+
+func (c *BatchCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.dryRun, "dry-run", false, "")
+	return f
+}
+
+func (c *BatchCommand) Name() string {
+	return "batch"
+}
+
+func (c *BatchCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *BatchCommand) Synopsis() string {
+	return `Applies newline-delimited commands from a file or stdin
+
+  Each line holds a command and its arguments as they would be passed to
+  prop, such as "set mykey myvalue". Blank lines and lines starting with #
+  are ignored, and arguments containing whitespace may be quoted. Commands
+  are read from stdin if no path or a path of - is specified. The shell
+  and watch commands may not be used in a batch.
+
+  Every line is validated before any are applied, and all commands share
+  the url and namespace of the batch. On backends that support
+  transactions, the commands are applied atomically and no changes are
+  kept if any command exits non-zero. On other backends, the batch stops
+  at the first failing command, and the commands before it remain applied.
+  The batch exits with the exit code of the failing command.
+
+  The --dry-run flag applies the lines to an in-memory copy of the backend
+  instead, printing each command that would modify the backend along with
+  the old and new value of every key it changes. Changes to the timeout of
+  a key are not printed.`
+}
+
+func (c *BatchCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	path := ""
+	if arguments["path"].HasValue {
+		path = arguments["path"].StringValue()
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	lines, err := c.readLines(r)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+	if closer, ok := b.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if c.dryRun {
+		return c.runDryRun(ctx, b, lines)
+	}
+
+	apply := func(ctx context.Context) error {
+		commands := c.batchCommands(ctx, b, c.Meta.Ui)
+		for _, line := range lines {
+			if err := c.runLine(commands, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	transactional := backend.SupportsTransactions(b)
+	if transactional {
+		err = b.(backend.TransactionalBackend).Transaction(ctx, apply)
+	} else {
+		err = apply(ctx)
+	}
+
+	var lineErr *errBatchLineFailed
+	if errors.As(err, &lineErr) {
+		c.Ui.Error(lineErr.Error())
+		if transactional {
+			c.Ui.Error("No changes were applied")
+		} else if lineErr.line.number > lines[0].number {
+			c.Ui.Error(fmt.Sprintf("The commands before line %d were applied", lineErr.line.number))
+		}
+		return lineErr.exitCode
+	}
+
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+// runDryRun applies the lines of a batch to an in-memory copy of the
+// backend, printing each line that would modify the backend along with the
+// value of every key it changes before and after the line is applied
+func (c *BatchCommand) runDryRun(ctx context.Context, b backend.Backend, lines []batchLine) int {
+	properties, err := b.BackendExport(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	dryRunURL, err := c.dryRunURL()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	copied, err := backend.ConstructBackend(ctx, dryRunURL, c.Meta.Namespace())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+	defer copied.BackendReset(context.Background())
+
+	if _, err := copied.BackendImport(ctx, properties, true); err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	commands := c.batchCommands(ctx, copied, &outputDiscardingUi{Ui: c.Meta.Ui})
+	for _, line := range lines {
+		cmd, err := commands[line.name]()
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		if _, ok := cmd.(readOnlyCommand); ok {
+			continue
+		}
+
+		before, err := copied.BackendExport(ctx)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return exitCode(err)
+		}
+
+		var lineErr *errBatchLineFailed
+		if err := c.runLine(commands, line); errors.As(err, &lineErr) {
+			c.Ui.Error(lineErr.Error())
+			return lineErr.exitCode
+		} else if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		after, err := copied.BackendExport(ctx)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return exitCode(err)
+		}
+
+		c.Ui.Output(line.text)
+		changes := propertyChanges(before, after)
+		if len(changes) == 0 {
+			c.Ui.Output("  (no changes)")
+		}
+		for _, change := range changes {
+			c.Ui.Output("  " + change)
+		}
+	}
+
+	return 0
+}
+
+// dryRunURL returns the url of a private in-memory backend for a dry run,
+// recording history if the batch url does
+func (c *BatchCommand) dryRunURL() (string, error) {
+	u, err := backend.ParseURL(c.Meta.URL())
+	if err != nil {
+		return "", err
+	}
+
+	// a named store is used so that the history of the copy is kept in
+	// the same store as its keys
+	dryRunURL := fmt.Sprintf("mem:prop-dry-run-%d-%d", os.Getpid(), time.Now().UnixNano())
+	if history := u.Query().Get("history"); history != "" {
+		dryRunURL += "?history=" + url.QueryEscape(history)
+	}
+
+	return dryRunURL, nil
+}
+
+// batchCommands returns the commands run by a batch, which share its
+// backend and report to ui
+func (c *BatchCommand) batchCommands(ctx context.Context, b backend.Backend, ui cli.Ui) map[string]cli.CommandFactory {
+	meta := c.Meta
+	meta.Ui = ui
+	meta.ctx = ctx
+	meta.backend = b
+	meta.backendURL = c.Meta.URL()
+	meta.backendNamespace = c.Meta.Namespace()
+	return Commands(&meta, nil)
+}
+
+// runLine runs a single line of a batch
+func (c *BatchCommand) runLine(commands map[string]cli.CommandFactory, line batchLine) error {
+	cmd, err := commands[line.name]()
+	if err != nil {
+		return err
+	}
+
+	if exitCode := cmd.Run(c.lineArgs(line)); exitCode != 0 {
+		return &errBatchLineFailed{line: line, exitCode: exitCode}
+	}
+
+	return nil
+}
+
+// propertyChanges describes each key whose value differs between two
+// exports of a backend, ignoring the history recorded for the keys
+func propertyChanges(before backend.PropertyCollection, after backend.PropertyCollection) []string {
+	values := func(properties backend.PropertyCollection) map[string]string {
+		values := map[string]string{}
+		for _, property := range properties.Properties {
+			if property.Namespace == backend.HistoryNamespace {
+				continue
+			}
+			encoded, _ := json.Marshal(property.Value)
+			values[property.Namespace+"."+property.Key] = string(encoded)
+		}
+		return values
+	}
+
+	oldValues := values(before)
+	newValues := values(after)
+	keys := []string{}
+	for key := range oldValues {
+		keys = append(keys, key)
+	}
+	for key := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []string{}
+	for _, key := range keys {
+		oldValue, hadValue := oldValues[key]
+		newValue, hasValue := newValues[key]
+		if hadValue == hasValue && oldValue == newValue {
+			continue
+		}
+
+		if !hadValue {
+			oldValue = "(missing)"
+		}
+		if !hasValue {
+			newValue = "(missing)"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", key, oldValue, newValue))
+	}
+
+	return changes
+}
+
+// outputDiscardingUi discards the output of commands run by a dry run,
+// while passing through their errors
+type outputDiscardingUi struct {
+	cli.Ui
+}
+
+func (u *outputDiscardingUi) Output(message string) {}
+
+func (u *outputDiscardingUi) Info(message string) {}
+
+// readLines reads and validates every command within a batch
+func (c *BatchCommand) readLines(r io.Reader) ([]batchLine, error) {
+	commands := Commands(&c.Meta, nil)
+	lines := []batchLine{}
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		words, err := splitCommandLine(text)
+		if err != nil {
+			return lines, fmt.Errorf("Invalid command on line %d: %w", number, err)
+		}
+
+		name, args, err := lookupCommand(commands, words)
+		if err != nil {
+			return lines, fmt.Errorf("Invalid command on line %d: %w", number, err)
+		}
+
+		if name == c.Name() {
+			return lines, fmt.Errorf("Invalid command on line %d: %s may not be nested", number, name)
+		}
+
+		if interactiveCommands[name] {
+			return lines, fmt.Errorf("Invalid command on line %d: %s may not be used in a batch", number, name)
+		}
+
+		line := batchLine{
+			number: number,
+			text:   text,
+			name:   name,
+			args:   args,
+		}
+		if err := c.validateLine(commands, line); err != nil {
+			return lines, fmt.Errorf("Invalid command on line %d: %w", number, err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return lines, err
+	}
+
+	return lines, nil
+}
+
+// validateLine parses the flags and arguments of a line without running it
+func (c *BatchCommand) validateLine(commands map[string]cli.CommandFactory, line batchLine) error {
+	cmd, err := commands[line.name]()
+	if err != nil {
+		return err
+	}
+
+	parseable, ok := cmd.(parseableCommand)
+	if !ok {
+		return nil
+	}
+
+	flags := parseable.FlagSet()
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(c.lineArgs(line)); err != nil {
+		return err
+	}
+
+	if _, err := parseable.ParsedArguments(flags.Args()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// lineArgs returns the arguments a line is run with, defaulting its url and
+// namespace to those of the batch
+func (c *BatchCommand) lineArgs(line batchLine) []string {
+	args := []string{"--url", c.Meta.URL(), "--namespace", c.Meta.Namespace()}
+	return append(args, line.args...)
+}
+
+// lookupCommand resolves the command named by the leading words of a line,
+// returning its name and remaining arguments
+func lookupCommand(commands map[string]cli.CommandFactory, words []string) (string, []string, error) {
+	if len(words) >= 2 {
+		name := words[0] + " " + words[1]
+		if _, ok := commands[name]; ok {
+			return name, words[2:], nil
+		}
+	}
+
+	if _, ok := commands[words[0]]; ok {
+		return words[0], words[1:], nil
+	}
+
+	return "", nil, fmt.Errorf("Unknown command %q", words[0])
+}
+
+// splitCommandLine splits a line into words on whitespace. Single quotes
+// preserve their contents literally, while double quotes and backslashes
+// outside of single quotes escape the following character.
+func splitCommandLine(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return words, fmt.Errorf("Trailing backslash")
+	}
+
+	if quote != 0 {
+		return words, fmt.Errorf("Unterminated %c quote", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		words []string
+		err   string
+	}{
+		{name: "words", line: "set mykey myvalue", words: []string{"set", "mykey", "myvalue"}},
+		{name: "repeated whitespace", line: "set \t mykey  myvalue", words: []string{"set", "mykey", "myvalue"}},
+		{name: "double quotes", line: `set mykey "my value"`, words: []string{"set", "mykey", "my value"}},
+		{name: "single quotes", line: `set mykey 'my "value"'`, words: []string{"set", "mykey", `my "value"`}},
+		{name: "backslash in single quotes", line: `set mykey 'a\b'`, words: []string{"set", "mykey", `a\b`}},
+		{name: "escaped quote", line: `set mykey "a \" b"`, words: []string{"set", "mykey", `a " b`}},
+		{name: "escaped space", line: `set mykey a\ b`, words: []string{"set", "mykey", "a b"}},
+		{name: "empty quotes", line: `set mykey ""`, words: []string{"set", "mykey", ""}},
+		{name: "adjacent quotes", line: `set mykey a"b c"'d'`, words: []string{"set", "mykey", "ab cd"}},
+		{name: "trailing backslash", line: `set mykey a\`, err: "Trailing backslash"},
+		{name: "unterminated double quote", line: `set mykey "a`, err: `Unterminated " quote`},
+		{name: "unterminated single quote", line: `set mykey 'a`, err: "Unterminated ' quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := splitCommandLine(tt.line)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(words, tt.words) {
+				t.Errorf("expected %q, got %q", tt.words, words)
+			}
+		})
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	commands := Commands(&Meta{Ui: cli.NewMockUi()}, nil)
+	tests := []struct {
+		name  string
+		words []string
+		want  string
+		args  []string
+		err   bool
+	}{
+		{name: "single word", words: []string{"get", "mykey"}, want: "get", args: []string{"mykey"}},
+		{name: "two words", words: []string{"namespace", "clear", "other"}, want: "namespace clear", args: []string{"other"}},
+		{name: "two words without arguments", words: []string{"backend", "reset"}, want: "backend reset", args: []string{}},
+		{name: "unknown second word", words: []string{"get", "namespace"}, want: "get", args: []string{"namespace"}},
+		{name: "unknown command", words: []string{"unknown", "mykey"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args, err := lookupCommand(commands, tt.words)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got command %q", name)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if name != tt.want || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("expected %q with %q, got %q with %q", tt.want, tt.args, name, args)
+			}
+		})
+	}
+}
+
+func TestBatchCommandRejectsLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines string
+		err   string
+	}{
+		{name: "nested batch", lines: "set a 1\nbatch other.txt\n", err: "Invalid command on line 2: batch may not be nested"},
+		{name: "shell", lines: "shell\n", err: "Invalid command on line 1: shell may not be used in a batch"},
+		{name: "watch", lines: "# comment\n\nwatch mykey\n", err: "Invalid command on line 3: watch may not be used in a batch"},
+		{name: "unknown command", lines: "unknown mykey\n", err: `Invalid command on line 1: Unknown command "unknown"`},
+		{name: "extra arguments", lines: "get a b c\n", err: "Invalid command on line 1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "mem:" + t.Name()
+			ui, code := runBatch(t, url, tt.lines)
+			if code != 1 {
+				t.Errorf("expected exit code 1, got %d", code)
+			}
+			if !strings.HasPrefix(ui.ErrorWriter.String(), tt.err) {
+				t.Errorf("expected error %q, got %q", tt.err, ui.ErrorWriter.String())
+			}
+			if value := getValue(t, url, "a"); value != "" {
+				t.Errorf("expected no lines to be applied, got a=%q", value)
+			}
+		})
+	}
+}
+
+func TestBatchCommandFailingLine(t *testing.T) {
+	lines := "set a 1\nset b 2\nlset missing 0 x\nset c 3\n"
+
+	t.Run("transactional", func(t *testing.T) {
+		url := "sqlite:" + filepath.Join(t.TempDir(), "prop.db")
+		ui, code := runBatch(t, url, lines)
+		if code != ExitCodeKeyNotFound {
+			t.Errorf("expected exit code %d, got %d", ExitCodeKeyNotFound, code)
+		}
+
+		expected := "Line 3 exited with code 2: lset missing 0 x\nNo changes were applied\n"
+		if !strings.HasSuffix(ui.ErrorWriter.String(), expected) {
+			t.Errorf("expected errors to end with %q, got %q", expected, ui.ErrorWriter.String())
+		}
+
+		for _, key := range []string{"a", "b", "c"} {
+			if value := getValue(t, url, key); value != "" {
+				t.Errorf("expected %s to be rolled back, got %q", key, value)
+			}
+		}
+	})
+
+	t.Run("non-transactional", func(t *testing.T) {
+		url := "mem:" + t.Name()
+		ui, code := runBatch(t, url, lines)
+		if code != ExitCodeKeyNotFound {
+			t.Errorf("expected exit code %d, got %d", ExitCodeKeyNotFound, code)
+		}
+
+		expected := "Line 3 exited with code 2: lset missing 0 x\nThe commands before line 3 were applied\n"
+		if !strings.HasSuffix(ui.ErrorWriter.String(), expected) {
+			t.Errorf("expected errors to end with %q, got %q", expected, ui.ErrorWriter.String())
+		}
+
+		for key, want := range map[string]string{"a": "1", "b": "2", "c": ""} {
+			if value := getValue(t, url, key); value != want {
+				t.Errorf("expected %s=%q, got %q", key, want, value)
+			}
+		}
+	})
+}
+
+func TestBatchCommandDryRun(t *testing.T) {
+	url := "mem:" + t.Name()
+	if _, code := runBatch(t, url, "set a 1\nrpush l x\n"); code != 0 {
+		t.Fatalf("seeding the backend exited %d", code)
+	}
+
+	ui := cli.NewMockUi()
+	path := writeBatchFile(t, "set a 2\nget a\ndel missing\nrpush l y\n")
+	batch := &BatchCommand{Meta: Meta{Ui: ui}}
+	if code := batch.Run([]string{"--url", url, "--dry-run", path}); code != 0 {
+		t.Fatalf("dry run exited %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	expected := strings.Join([]string{
+		"set a 2",
+		`  default.a: "1" -> "2"`,
+		"del missing",
+		"  (no changes)",
+		"rpush l y",
+		`  default.l: ["x"] -> ["x","y"]`,
+		"",
+	}, "\n")
+	if ui.OutputWriter.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, ui.OutputWriter.String())
+	}
+
+	if value := getValue(t, url, "a"); value != "1" {
+		t.Errorf("expected the dry run to leave a=1, got %q", value)
+	}
+}
+
+// runBatch runs the lines as a batch against url
+func runBatch(t *testing.T, url string, lines string) (*cli.MockUi, int) {
+	t.Helper()
+	ui := cli.NewMockUi()
+	batch := &BatchCommand{Meta: Meta{Ui: ui}}
+	return ui, batch.Run([]string{"--url", url, writeBatchFile(t, lines)})
+}
+
+// writeBatchFile writes the lines of a batch to a temporary file
+func writeBatchFile(t *testing.T, lines string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("unable to write batch file: %s", err)
+	}
+	return path
+}
+
+// getValue returns the value of a key, or an empty string if it does not exist
+func getValue(t *testing.T, url string, key string) string {
+	t.Helper()
+	ui := cli.NewMockUi()
+	get := &GetCommand{Meta: Meta{Ui: ui}}
+	if code := get.Run([]string{"--url", url, key}); code != 0 && code != ExitCodeKeyNotFound {
+		t.Fatalf("get %s exited %d: %s", key, code, ui.ErrorWriter.String())
+	}
+	return strings.TrimSpace(ui.OutputWriter.String())
+}
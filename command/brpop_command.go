@@ -0,0 +1,125 @@
+package command
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/posener/complete"
+)
+
+type BrpopCommand struct {
+	Meta
+}
+
+func (c *BrpopCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *BrpopCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "timeout",
+		Optional: true,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *BrpopCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *BrpopCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *BrpopCommand) Examples() map[string]string {
+	return map[string]string{
+		"Wait for the last element of a list":            "prop brpop mylist",
+		"Wait up to thirty seconds for the last element": "prop brpop mylist 30",
+		"Wait up to half a second for the last element":  "prop brpop mylist 500ms",
+	}
+}
+
+func (c *BrpopCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *BrpopCommand) Name() string {
+	return "brpop"
+}
+
+func (c *BrpopCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *BrpopCommand) Synopsis() string {
+	return `Remove and get the last element of a list, waiting for one if empty
+
+  Removes and outputs the last element of the list. If the list is empty
+  or missing, waits until an element is pushed to it, for at most timeout,
+  given as a number of seconds or a duration such as 500ms. A timeout of 0,
+  the default, waits indefinitely. Exits with code 2 if the timeout elapses
+  before an element is available.`
+}
+
+func (c *BrpopCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	var timeout time.Duration
+	if arguments["timeout"].HasValue {
+		timeout, err = parseBlockingTimeout(arguments["timeout"].StringValue())
+		if err != nil {
+			c.Ui.Error(err.Error())
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	element, err := b.Brpop(ctx, arguments["key"].StringValue(), timeout)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(element)
+	return 0
+}
@@ -35,10 +35,22 @@ func Commands(metaPtr *Meta, agentUi cli.Ui) map[string]cli.CommandFactory {
 
 	all := map[string]cli.CommandFactory{}
 
+	for k, v := range BatchCommands(meta) {
+		all[k] = v
+	}
+
+	for k, v := range ShellCommands(meta) {
+		all[k] = v
+	}
+
 	for k, v := range BackendCommands(meta) {
 		all[k] = v
 	}
 
+	for k, v := range HistoryCommands(meta) {
+		all[k] = v
+	}
+
 	for k, v := range NamespaceCommands(meta) {
 		all[k] = v
 	}
@@ -55,9 +67,34 @@ func Commands(metaPtr *Meta, agentUi cli.Ui) map[string]cli.CommandFactory {
 		all[k] = v
 	}
 
+	for k, v := range HashCommands(meta) {
+		all[k] = v
+	}
+
+	for k, v := range SortedSetCommands(meta) {
+		all[k] = v
+	}
+
 	return all
 }
 
+func BatchCommands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"batch": func() (cli.Command, error) {
+			// batch path/to/file
+			return &BatchCommand{Meta: meta}, nil
+		},
+	}
+}
+
+func ShellCommands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"shell": func() (cli.Command, error) {
+			return &ShellCommand{Meta: meta}, nil
+		},
+	}
+}
+
 func BackendCommands(meta Meta) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
 		"backend export": func() (cli.Command, error) {
@@ -66,11 +103,22 @@ func BackendCommands(meta Meta) map[string]cli.CommandFactory {
 		},
 		"backend import": func() (cli.Command, error) {
 			// backend import path/to/file
-			return &BackendImportCommand{}, nil
+			return &BackendImportCommand{Meta: meta}, nil
 		},
 		"backend reset": func() (cli.Command, error) {
 			// backend reset
-			return &BackendResetCommand{}, nil
+			return &BackendResetCommand{Meta: meta}, nil
+		},
+	}
+}
+
+func HistoryCommands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"history": func() (cli.Command, error) {
+			return &HistoryCommand{Meta: meta}, nil
+		},
+		"rollback": func() (cli.Command, error) {
+			return &RollbackCommand{Meta: meta}, nil
 		},
 	}
 }
@@ -88,35 +136,80 @@ func NamespaceCommands(meta Meta) map[string]cli.CommandFactory {
 
 func KeyValueCommands(meta Meta) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
+		"decr": func() (cli.Command, error) {
+			return &DecrCommand{Meta: meta}, nil
+		},
 		"del": func() (cli.Command, error) {
 			return &DelCommand{Meta: meta}, nil
 		},
 		"exists": func() (cli.Command, error) {
 			return &ExistsCommand{Meta: meta}, nil
 		},
+		"expire": func() (cli.Command, error) {
+			return &ExpireCommand{Meta: meta}, nil
+		},
 		"get": func() (cli.Command, error) {
 			return &GetCommand{Meta: meta}, nil
 		},
 		"get-all": func() (cli.Command, error) {
 			return &GetAllCommand{Meta: meta}, nil
 		},
+		"getset": func() (cli.Command, error) {
+			return &GetSetCommand{Meta: meta}, nil
+		},
+		"incr": func() (cli.Command, error) {
+			return &IncrCommand{Meta: meta}, nil
+		},
+		"incrby": func() (cli.Command, error) {
+			return &IncrByCommand{Meta: meta}, nil
+		},
+		"persist": func() (cli.Command, error) {
+			return &PersistCommand{Meta: meta}, nil
+		},
 		"set": func() (cli.Command, error) {
 			return &SetCommand{Meta: meta}, nil
 		},
+		"ttl": func() (cli.Command, error) {
+			return &TTLCommand{Meta: meta}, nil
+		},
+		"watch": func() (cli.Command, error) {
+			return &WatchCommand{Meta: meta}, nil
+		},
 	}
 }
 
 func ListCommands(meta Meta) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
+		"blpop": func() (cli.Command, error) {
+			return &BlpopCommand{Meta: meta}, nil
+		},
+		"brpop": func() (cli.Command, error) {
+			return &BrpopCommand{Meta: meta}, nil
+		},
 		"lindex": func() (cli.Command, error) {
 			return &LindexCommand{Meta: meta}, nil
 		},
+		"linsert": func() (cli.Command, error) {
+			return &LinsertCommand{Meta: meta}, nil
+		},
 		"lismember": func() (cli.Command, error) {
 			return &LismemberCommand{Meta: meta}, nil
 		},
 		"llen": func() (cli.Command, error) {
 			return &LlenCommand{Meta: meta}, nil
 		},
+		"lmove": func() (cli.Command, error) {
+			return &LmoveCommand{Meta: meta}, nil
+		},
+		"lpop": func() (cli.Command, error) {
+			return &LpopCommand{Meta: meta}, nil
+		},
+		"lpos": func() (cli.Command, error) {
+			return &LposCommand{Meta: meta}, nil
+		},
+		"lpush": func() (cli.Command, error) {
+			return &LpushCommand{Meta: meta}, nil
+		},
 		"lrange": func() (cli.Command, error) {
 			return &LrangeCommand{Meta: meta}, nil
 		},
@@ -126,6 +219,12 @@ func ListCommands(meta Meta) map[string]cli.CommandFactory {
 		"lset": func() (cli.Command, error) {
 			return &LsetCommand{Meta: meta}, nil
 		},
+		"ltrim": func() (cli.Command, error) {
+			return &LtrimCommand{Meta: meta}, nil
+		},
+		"rpop": func() (cli.Command, error) {
+			return &RpopCommand{Meta: meta}, nil
+		},
 		"rpush": func() (cli.Command, error) {
 			return &RpushCommand{Meta: meta}, nil
 		},
@@ -137,14 +236,96 @@ func SetCommands(meta Meta) map[string]cli.CommandFactory {
 		"sadd": func() (cli.Command, error) {
 			return &SaddCommand{Meta: meta}, nil
 		},
+		"scard": func() (cli.Command, error) {
+			return &ScardCommand{Meta: meta}, nil
+		},
+		"sdiff": func() (cli.Command, error) {
+			return &SdiffCommand{Meta: meta}, nil
+		},
+		"sdiffstore": func() (cli.Command, error) {
+			return &SdiffstoreCommand{Meta: meta}, nil
+		},
+		"sinter": func() (cli.Command, error) {
+			return &SinterCommand{Meta: meta}, nil
+		},
+		"sinterstore": func() (cli.Command, error) {
+			return &SinterstoreCommand{Meta: meta}, nil
+		},
 		"sismember": func() (cli.Command, error) {
 			return &SismemberCommand{Meta: meta}, nil
 		},
 		"smembers": func() (cli.Command, error) {
 			return &SmembersCommand{Meta: meta}, nil
 		},
+		"smove": func() (cli.Command, error) {
+			return &SmoveCommand{Meta: meta}, nil
+		},
+		"spop": func() (cli.Command, error) {
+			return &SpopCommand{Meta: meta}, nil
+		},
+		"srandmember": func() (cli.Command, error) {
+			return &SrandmemberCommand{Meta: meta}, nil
+		},
 		"srem": func() (cli.Command, error) {
 			return &SremCommand{Meta: meta}, nil
 		},
+		"sunion": func() (cli.Command, error) {
+			return &SunionCommand{Meta: meta}, nil
+		},
+		"sunionstore": func() (cli.Command, error) {
+			return &SunionstoreCommand{Meta: meta}, nil
+		},
+	}
+}
+
+func HashCommands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"hdel": func() (cli.Command, error) {
+			return &HdelCommand{Meta: meta}, nil
+		},
+		"hexists": func() (cli.Command, error) {
+			return &HexistsCommand{Meta: meta}, nil
+		},
+		"hget": func() (cli.Command, error) {
+			return &HgetCommand{Meta: meta}, nil
+		},
+		"hgetall": func() (cli.Command, error) {
+			return &HgetallCommand{Meta: meta}, nil
+		},
+		"hkeys": func() (cli.Command, error) {
+			return &HkeysCommand{Meta: meta}, nil
+		},
+		"hlen": func() (cli.Command, error) {
+			return &HlenCommand{Meta: meta}, nil
+		},
+		"hset": func() (cli.Command, error) {
+			return &HsetCommand{Meta: meta}, nil
+		},
+	}
+}
+
+func SortedSetCommands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"zadd": func() (cli.Command, error) {
+			return &ZaddCommand{Meta: meta}, nil
+		},
+		"zcard": func() (cli.Command, error) {
+			return &ZcardCommand{Meta: meta}, nil
+		},
+		"zrange": func() (cli.Command, error) {
+			return &ZrangeCommand{Meta: meta}, nil
+		},
+		"zrangebyscore": func() (cli.Command, error) {
+			return &ZrangebyscoreCommand{Meta: meta}, nil
+		},
+		"zrank": func() (cli.Command, error) {
+			return &ZrankCommand{Meta: meta}, nil
+		},
+		"zrem": func() (cli.Command, error) {
+			return &ZremCommand{Meta: meta}, nil
+		},
+		"zscore": func() (cli.Command, error) {
+			return &ZscoreCommand{Meta: meta}, nil
+		},
 	}
 }
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type DecrCommand struct {
+	Meta
+}
+
+func (c *DecrCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DecrCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *DecrCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *DecrCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *DecrCommand) Examples() map[string]string {
+	return map[string]string{
+		"Decrement the integer value of a key": "prop decr mykey",
+	}
+}
+
+func (c *DecrCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *DecrCommand) Name() string {
+	return "decr"
+}
+
+func (c *DecrCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *DecrCommand) Synopsis() string {
+	return `Decrement the integer value of a key by one and output the new value
+
+  A missing key is treated as holding 0, and any expiry of the key is kept.
+  Exits with code 3 if the value is not an integer.`
+}
+
+func (c *DecrCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	value, err := b.Decr(ctx, key)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(strconv.FormatInt(value, 10))
+	return 0
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -82,17 +81,20 @@ func (c *DelCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
-	ok, err := b.Del(key)
+	ok, err := b.Del(ctx, key)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !ok {
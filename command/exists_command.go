@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -64,6 +63,10 @@ func (c *ExistsCommand) ParsedArguments(args []string) (map[string]Argument, err
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *ExistsCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *ExistsCommand) Synopsis() string {
 	return "Check if a key exists"
 }
@@ -82,17 +85,20 @@ func (c *ExistsCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
-	ok, err := b.Exists(key)
+	ok, err := b.Exists(ctx, key)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !ok {
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dokku/prop/backend"
+)
+
+const (
+	// ExitCodeOK is returned when a command succeeds
+	ExitCodeOK = 0
+
+	// ExitCodeError is returned for usage and unclassified errors, as well as
+	// by commands such as exists that answer a question in the negative
+	ExitCodeError = 1
+
+	// ExitCodeKeyNotFound is returned when reading a key, a field of a hash, a
+	// member of a sorted set or a pivot element of a list that does not exist
+	ExitCodeKeyNotFound = 2
+
+	// ExitCodeWrongType is returned when operating on a key holding another
+	// data type, or incrementing a value that is not an integer
+	ExitCodeWrongType = 3
+
+	// ExitCodeIndexOutOfRange is returned when accessing a list element past
+	// either end of the list, or a revision missing from the history of a key
+	ExitCodeIndexOutOfRange = 4
+
+	// ExitCodeNotImplemented is returned for operations the backend does not support
+	ExitCodeNotImplemented = 5
+
+	// ExitCodeInvalidKey is returned for keys, namespaces and values that do
+	// not match the documented specification
+	ExitCodeInvalidKey = 6
+
+	// ExitCodeTimeout is returned when the backend does not respond within
+	// the --timeout duration, or a lock is not released within the lock timeout
+	ExitCodeTimeout = 7
+
+	// ExitCodeConditionFailed is returned when the condition of a conditional
+	// write does not hold
+	ExitCodeConditionFailed = 8
+)
+
+// exitCode returns the exit code documented for an error returned by a backend
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrKeyNotFound),
+		errors.Is(err, backend.ErrFieldNotFound),
+		errors.Is(err, backend.ErrMemberNotFound),
+		errors.Is(err, backend.ErrElementNotFound):
+		return ExitCodeKeyNotFound
+	case errors.Is(err, backend.ErrWrongType),
+		errors.Is(err, backend.ErrNotInteger):
+		return ExitCodeWrongType
+	case errors.Is(err, backend.ErrIndexOutOfRange),
+		errors.Is(err, backend.ErrRevisionNotFound):
+		return ExitCodeIndexOutOfRange
+	case errors.Is(err, backend.ErrNotImplemented):
+		return ExitCodeNotImplemented
+	case errors.Is(err, backend.ErrInvalidKey),
+		errors.Is(err, backend.ErrInvalidNamespace),
+		errors.Is(err, backend.ErrInvalidValue):
+		return ExitCodeInvalidKey
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, backend.ErrLockTimeout):
+		return ExitCodeTimeout
+	case errors.Is(err, backend.ErrConditionFailed):
+		return ExitCodeConditionFailed
+	}
+
+	return ExitCodeError
+}
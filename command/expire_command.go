@@ -0,0 +1,121 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type ExpireCommand struct {
+	Meta
+}
+
+func (c *ExpireCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ExpireCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "ttl",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *ExpireCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *ExpireCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ExpireCommand) Examples() map[string]string {
+	return map[string]string{
+		"Expire a key in 30 seconds": "prop expire mykey 30",
+		"Expire a key in an hour":    "prop expire mykey 1h",
+	}
+}
+
+func (c *ExpireCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *ExpireCommand) Name() string {
+	return "expire"
+}
+
+func (c *ExpireCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *ExpireCommand) Synopsis() string {
+	return `Set a timeout on a key
+
+  The ttl is either a number of seconds or a duration such as 90s or 1h.
+  The key is deleted once the ttl elapses, and exits 1 if it does not exist.`
+}
+
+func (c *ExpireCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ttl, err := parseTTL(arguments["ttl"].StringValue())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	ok, err := b.Expire(ctx, key, ttl)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"errors"
 	"flag"
+	"strconv"
 	"strings"
 
 	"github.com/dokku/prop/backend"
@@ -10,6 +12,8 @@ import (
 
 type GetCommand struct {
 	Meta
+
+	revision bool
 }
 
 func (c *GetCommand) Help() string {
@@ -44,7 +48,9 @@ func (c *GetCommand) Arguments() []Argument {
 }
 
 func (c *GetCommand) AutocompleteFlags() complete.Flags {
-	return complete.Flags{}
+	return complete.Flags{
+		"-revision": complete.PredictNothing,
+	}
 }
 
 func (c *GetCommand) AutocompleteArgs() complete.Predictor {
@@ -53,11 +59,14 @@ func (c *GetCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *GetCommand) Examples() map[string]string {
 	return map[string]string{
-		"Get a key": "prop get mykey",
+		"Get a key":                  "prop get mykey",
+		"Get a key and its revision": "prop get --revision mykey",
 	}
 }
 func (c *GetCommand) FlagSet() *flag.FlagSet {
-	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.revision, "revision", false, "")
+	return f
 }
 
 func (c *GetCommand) Name() string {
@@ -68,8 +77,18 @@ func (c *GetCommand) ParsedArguments(args []string) (map[string]Argument, error)
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *GetCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *GetCommand) Synopsis() string {
-	return "Get the value of a key"
+	return `Get the value of a key
+
+  With the --revision flag, the revision of the key is output on the line
+  before its value. The revision changes whenever the key is written, and
+  may be passed to set --if-revision to only write the key if it has not
+  changed since it was read. A missing key with a default value has
+  revision 0.`
 }
 
 func (c *GetCommand) Run(args []string) int {
@@ -86,20 +105,38 @@ func (c *GetCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
 	defaultValue := arguments["default-value"].StringValue()
-	value, err := b.Get(key, defaultValue)
+	if !c.revision {
+		value, err := b.Get(ctx, key, defaultValue)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return exitCode(err)
+		}
+
+		c.Ui.Output(value)
+		return 0
+	}
+
+	value, revision, err := b.GetWithRevision(ctx, key)
+	if errors.Is(err, backend.ErrKeyNotFound) && defaultValue != "" {
+		value, revision, err = defaultValue, 0, nil
+	}
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
+	c.Ui.Output(strconv.FormatInt(revision, 10))
 	c.Ui.Output(value)
 	return 0
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -65,6 +64,10 @@ func (c *GetAllCommand) ParsedArguments(args []string) (map[string]Argument, err
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *GetAllCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *GetAllCommand) Synopsis() string {
 	return "Get all values in a namespace"
 }
@@ -83,22 +86,25 @@ func (c *GetAllCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	var keyValuePairs map[string]string
 	if arguments["prefix"].HasValue {
-		keyValuePairs, err = b.GetAllByPrefix(arguments["prefix"].StringValue())
+		keyValuePairs, err = b.GetAllByPrefix(ctx, arguments["prefix"].StringValue())
 	} else {
-		keyValuePairs, err = b.GetAll()
+		keyValuePairs, err = b.GetAll(ctx)
 	}
 
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	var kv []string
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type GetSetCommand struct {
+	Meta
+}
+
+func (c *GetSetCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *GetSetCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "value",
+		Optional: true,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *GetSetCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *GetSetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *GetSetCommand) Examples() map[string]string {
+	return map[string]string{
+		"Set a key and get the previous value": "prop getset mykey myvalue",
+	}
+}
+
+func (c *GetSetCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *GetSetCommand) Name() string {
+	return "getset"
+}
+
+func (c *GetSetCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *GetSetCommand) Synopsis() string {
+	return `Set the value of a key and output the previous value
+
+  An empty line is output if the key did not exist.`
+}
+
+func (c *GetSetCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	value := arguments["value"].StringValue()
+	previous, err := b.GetSet(ctx, key, value)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(previous)
+	return 0
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HdelCommand struct {
+	Meta
+}
+
+func (c *HdelCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HdelCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "fields",
+		Optional: false,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *HdelCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HdelCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HdelCommand) Examples() map[string]string {
+	return map[string]string{
+		"Remove a field from the hash": "prop hdel myhash myfield",
+	}
+}
+
+func (c *HdelCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HdelCommand) Name() string {
+	return "hdel"
+}
+
+func (c *HdelCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HdelCommand) Synopsis() string {
+	return "Remove one or more fields from a hash"
+}
+
+func (c *HdelCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	fields := arguments["fields"].ListValue()
+	removedCount, err := b.Hdel(ctx, key, fields...)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", removedCount))
+
+	return 0
+}
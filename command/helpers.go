@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dokku/prop/backend"
 	"github.com/kr/text"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
@@ -408,3 +411,95 @@ func prettyPrint(i interface{}) string {
 	s, _ := json.MarshalIndent(i, "", "\t")
 	return string(s)
 }
+
+// parseInterspersedFlags parses args with flags, including any flags that
+// follow the positional arguments, and returns the positional arguments. It
+// may only be used by commands whose arguments never start with a dash.
+func parseInterspersedFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	positional := []string{}
+	for flags.NArg() > 0 {
+		positional = append(positional, flags.Arg(0))
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return positional, nil
+}
+
+// parseTTL parses a ttl given either as a number of seconds or as a duration
+// such as 90s or 1h
+func parseTTL(value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, serr := strconv.ParseInt(value, 10, 64)
+		if serr != nil {
+			return 0, fmt.Errorf("Invalid ttl %s, expected a number of seconds or a duration such as 90s", value)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("Invalid ttl %s, expected a positive duration", value)
+	}
+
+	return ttl, nil
+}
+
+// parseBlockingTimeout parses the time a blocking command waits for, given
+// either as a number of seconds or as a duration such as 500ms. A timeout
+// of 0 waits indefinitely.
+func parseBlockingTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, serr := strconv.ParseFloat(value, 64)
+		if serr != nil {
+			return 0, fmt.Errorf("Invalid timeout %s, expected a number of seconds or a duration such as 500ms", value)
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	if timeout < 0 {
+		return 0, fmt.Errorf("Invalid timeout %s, expected a duration of zero or more", value)
+	}
+
+	return timeout, nil
+}
+
+// parseScore parses the score of a sorted set member, or a bound on scores
+// such as -inf or +inf
+func parseScore(value string) (float64, error) {
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid score %s, expected a number", value)
+	}
+
+	return score, nil
+}
+
+// formatScore formats the score of a sorted set member without an exponent
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// formatSortedSetMembers formats the members of a sorted set range, one per
+// line, followed by their scores if withScores is true
+func formatSortedSetMembers(members []backend.SortedSetMember, withScores bool) string {
+	lines := []string{}
+	for _, member := range members {
+		if withScores {
+			lines = append(lines, fmt.Sprintf("%v | %v", member.Member, formatScore(member.Score)))
+		} else {
+			lines = append(lines, member.Member)
+		}
+	}
+
+	if withScores {
+		return formatKV(lines)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,115 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HexistsCommand struct {
+	Meta
+}
+
+func (c *HexistsCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HexistsCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "field",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *HexistsCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HexistsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HexistsCommand) Examples() map[string]string {
+	return map[string]string{
+		"Determine if a field exists in the hash": "prop hexists myhash myfield",
+	}
+}
+
+func (c *HexistsCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HexistsCommand) Name() string {
+	return "hexists"
+}
+
+func (c *HexistsCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HexistsCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *HexistsCommand) Synopsis() string {
+	return "Determine if a given field exists in a hash"
+}
+
+func (c *HexistsCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	field := arguments["field"].StringValue()
+	ok, err := b.Hexists(ctx, key, field)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HgetCommand struct {
+	Meta
+}
+
+func (c *HgetCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HgetCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "field",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *HgetCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HgetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HgetCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get the value of a field in the hash": "prop hget myhash myfield",
+	}
+}
+
+func (c *HgetCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HgetCommand) Name() string {
+	return "hget"
+}
+
+func (c *HgetCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HgetCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *HgetCommand) Synopsis() string {
+	return "Get the value of a field in a hash"
+}
+
+func (c *HgetCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	field := arguments["field"].StringValue()
+	value, err := b.Hget(ctx, key, field)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(value)
+	return 0
+}
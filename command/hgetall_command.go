@@ -0,0 +1,119 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HgetallCommand struct {
+	Meta
+}
+
+func (c *HgetallCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HgetallCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *HgetallCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HgetallCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HgetallCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get all the fields and values in a hash": "prop hgetall myhash",
+	}
+}
+
+func (c *HgetallCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HgetallCommand) Name() string {
+	return "hgetall"
+}
+
+func (c *HgetallCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HgetallCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *HgetallCommand) Synopsis() string {
+	return "Get all the fields and values in a hash"
+}
+
+func (c *HgetallCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	fields, err := b.Hgetall(ctx, key)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if len(fields) == 0 {
+		return 0
+	}
+
+	var kv []string
+	for field, value := range fields {
+		kv = append(kv, fmt.Sprintf("%v | %v", field, value))
+	}
+	sort.Strings(kv)
+
+	c.Ui.Output(formatKV(kv))
+
+	return 0
+}
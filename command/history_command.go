@@ -0,0 +1,212 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dokku/prop/backend"
+	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
+)
+
+// errHistoryNotEnabled is printed when the backend does not record history
+var errHistoryNotEnabled = fmt.Errorf("History is not enabled, add history=true to the backend url: %w", backend.ErrNotImplemented)
+
+type HistoryCommand struct {
+	Meta
+
+	json bool
+}
+
+func (c *HistoryCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *HistoryCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-json": complete.PredictNothing,
+	}
+}
+
+func (c *HistoryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HistoryCommand) Examples() map[string]string {
+	return map[string]string{
+		"Show the changes made to a key":          "prop history mykey",
+		"Show the changes made to a key, as json": "prop history --json mykey",
+	}
+}
+
+func (c *HistoryCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.json, "json", false, "")
+	return f
+}
+
+func (c *HistoryCommand) Name() string {
+	return "history"
+}
+
+func (c *HistoryCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HistoryCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *HistoryCommand) Synopsis() string {
+	return `Show the changes made to a key
+
+  Outputs the revision, time, user, operation and the value before and
+  after each change to the key, oldest first, or a json array of the
+  changes if the --json flag is specified. History is only recorded when
+  the backend url includes history=true.`
+}
+
+func (c *HistoryCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	h, ok := b.(backend.HistoricalBackend)
+	if !ok {
+		c.Ui.Error(errHistoryNotEnabled.Error())
+		return exitCode(errHistoryNotEnabled)
+	}
+
+	key := arguments["key"].StringValue()
+	entries, err := h.History(ctx, key)
+	if errors.Is(err, backend.ErrNotImplemented) {
+		err = errHistoryNotEnabled
+	}
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if c.json {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(string(data))
+		return 0
+	}
+
+	if len(entries) == 0 {
+		return 0
+	}
+
+	rows := []string{"Revision\tTime\tUser\tOperation\tOld\tNew"}
+	for _, entry := range entries {
+		rows = append(rows, strings.Join([]string{
+			strconv.Itoa(entry.Revision),
+			formatTime(entry.Time.Local()),
+			entry.User,
+			entry.Operation,
+			formatHistoryValue(entry.OldValue),
+			formatHistoryValue(entry.NewValue),
+		}, "\t"))
+	}
+
+	columnConf := columnize.DefaultConfig()
+	columnConf.Delim = "\t"
+	columnConf.Empty = "<none>"
+	c.Ui.Output(columnize.Format(rows, columnConf))
+	return 0
+}
+
+// formatHistoryValue formats a recorded value on a single line, quoting
+// values and elements so that whitespace within them is visible
+func formatHistoryValue(value *backend.HistoryValue) string {
+	if value == nil {
+		return ""
+	}
+
+	if value.DataType == backend.DataTypeKeyValue {
+		return limit(strconv.Quote(value.Value), 40)
+	}
+
+	if value.DataType == backend.DataTypeHash {
+		fields := []string{}
+		for field, fieldValue := range value.Fields {
+			fields = append(fields, strconv.Quote(field)+":"+strconv.Quote(fieldValue))
+		}
+		sort.Strings(fields)
+		return limit(fmt.Sprintf("%s {%s}", value.DataType, strings.Join(fields, " ")), 40)
+	}
+
+	if value.DataType == backend.DataTypeSortedSet {
+		members := []string{}
+		for member := range value.Scores {
+			members = append(members, member)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			if value.Scores[members[i]] != value.Scores[members[j]] {
+				return value.Scores[members[i]] < value.Scores[members[j]]
+			}
+			return members[i] < members[j]
+		})
+
+		scores := make([]string, len(members))
+		for i, member := range members {
+			scores[i] = strconv.Quote(member) + ":" + strconv.FormatFloat(value.Scores[member], 'g', -1, 64)
+		}
+		return limit(fmt.Sprintf("%s {%s}", value.DataType, strings.Join(scores, " ")), 40)
+	}
+
+	elements := make([]string, len(value.Elements))
+	for i, element := range value.Elements {
+		elements[i] = strconv.Quote(element)
+	}
+	return limit(fmt.Sprintf("%s [%s]", value.DataType, strings.Join(elements, " ")), 40)
+}
@@ -0,0 +1,109 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HkeysCommand struct {
+	Meta
+}
+
+func (c *HkeysCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HkeysCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *HkeysCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HkeysCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HkeysCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get all the fields in a hash": "prop hkeys myhash",
+	}
+}
+
+func (c *HkeysCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HkeysCommand) Name() string {
+	return "hkeys"
+}
+
+func (c *HkeysCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *HkeysCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *HkeysCommand) Synopsis() string {
+	return "Get all the fields in a hash"
+}
+
+func (c *HkeysCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	fields, err := b.Hkeys(ctx, key)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	for _, field := range fields {
+		c.Ui.Output(field)
+	}
+
+	return 0
+}
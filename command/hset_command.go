@@ -0,0 +1,125 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type HsetCommand struct {
+	Meta
+}
+
+func (c *HsetCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HsetCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "fields",
+		Optional: false,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *HsetCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *HsetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *HsetCommand) Examples() map[string]string {
+	return map[string]string{
+		"Set a field in the hash": "prop hset myhash myfield myvalue",
+	}
+}
+
+func (c *HsetCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *HsetCommand) Name() string {
+	return "hset"
+}
+
+func (c *HsetCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	arguments, err := parseArguments(args, c.Arguments())
+	if err != nil {
+		return arguments, err
+	}
+
+	if len(arguments["fields"].ListValue())%2 != 0 {
+		return arguments, errors.New("Every field requires a value")
+	}
+
+	return arguments, nil
+}
+
+func (c *HsetCommand) Synopsis() string {
+	return "Set one or more fields in a hash"
+}
+
+func (c *HsetCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	pairs := arguments["fields"].ListValue()
+	fields := map[string]string{}
+	for i := 0; i < len(pairs); i += 2 {
+		fields[pairs[i]] = pairs[i+1]
+	}
+
+	addedCount, err := b.Hset(ctx, key, fields)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", addedCount))
+
+	return 0
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type IncrCommand struct {
+	Meta
+}
+
+func (c *IncrCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IncrCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *IncrCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *IncrCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *IncrCommand) Examples() map[string]string {
+	return map[string]string{
+		"Increment the integer value of a key": "prop incr mykey",
+	}
+}
+
+func (c *IncrCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *IncrCommand) Name() string {
+	return "incr"
+}
+
+func (c *IncrCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *IncrCommand) Synopsis() string {
+	return `Increment the integer value of a key by one and output the new value
+
+  A missing key is treated as holding 0, and any expiry of the key is kept.
+  Exits with code 3 if the value is not an integer.`
+}
+
+func (c *IncrCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	value, err := b.Incr(ctx, key)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(strconv.FormatInt(value, 10))
+	return 0
+}
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type IncrByCommand struct {
+	Meta
+}
+
+func (c *IncrByCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IncrByCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "increment",
+		Optional: false,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *IncrByCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *IncrByCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *IncrByCommand) Examples() map[string]string {
+	return map[string]string{
+		"Increment the integer value of a key by 10": "prop incrby mykey 10",
+		"Decrement the integer value of a key by 10": "prop incrby mykey -10",
+	}
+}
+
+func (c *IncrByCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *IncrByCommand) Name() string {
+	return "incrby"
+}
+
+func (c *IncrByCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *IncrByCommand) Synopsis() string {
+	return `Increment the integer value of a key by the given amount and output the new value
+
+  A negative increment decrements the value. A missing key is treated as
+  holding 0, and any expiry of the key is kept. Exits with code 3 if the
+  value is not an integer.`
+}
+
+func (c *IncrByCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	increment := arguments["increment"].IntValue()
+	value, err := b.IncrBy(ctx, key, int64(increment))
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(strconv.FormatInt(value, 10))
+	return 0
+}
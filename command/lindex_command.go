@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -69,6 +68,10 @@ func (c *LindexCommand) ParsedArguments(args []string) (map[string]Argument, err
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *LindexCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *LindexCommand) Synopsis() string {
 	return "Get an element from a list by its index"
 }
@@ -87,18 +90,21 @@ func (c *LindexCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
 	index := arguments["index"].IntValue()
-	value, err := b.Lindex(key, index)
+	value, err := b.Lindex(ctx, key, index)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	c.Ui.Output(value)
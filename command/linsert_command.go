@@ -0,0 +1,127 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type LinsertCommand struct {
+	Meta
+}
+
+func (c *LinsertCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LinsertCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "position",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "pivot",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "element",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *LinsertCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *LinsertCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LinsertCommand) Examples() map[string]string {
+	return map[string]string{
+		"Insert an element before another element in a list": "prop linsert mylist before pivot myelement",
+		"Insert an element after another element in a list":  "prop linsert mylist after pivot myelement",
+	}
+}
+
+func (c *LinsertCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *LinsertCommand) Name() string {
+	return "linsert"
+}
+
+func (c *LinsertCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *LinsertCommand) Synopsis() string {
+	return `Insert an element before or after another element in a list
+
+  The position must be either before or after, and the element is inserted
+  relative to the first occurrence of pivot. Outputs the length of the list,
+  or exits with code 2 if the list or pivot does not exist.`
+}
+
+func (c *LinsertCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	position := strings.ToLower(arguments["position"].StringValue())
+	pivot := arguments["pivot"].StringValue()
+	element := arguments["element"].StringValue()
+	length, err := b.Linsert(ctx, key, position, pivot, element)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", length))
+
+	return 0
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -69,6 +68,10 @@ func (c *LismemberCommand) ParsedArguments(args []string) (map[string]Argument,
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *LismemberCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *LismemberCommand) Synopsis() string {
 	return "Determine if a given value is an element in the list"
 }
@@ -87,18 +90,21 @@ func (c *LismemberCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
 	element := arguments["element"].StringValue()
-	ok, err := b.Lismember(key, element)
+	ok, err := b.Lismember(ctx, key, element)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !ok {
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -65,6 +64,10 @@ func (c *LlenCommand) ParsedArguments(args []string) (map[string]Argument, error
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *LlenCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *LlenCommand) Synopsis() string {
 	return "Get the length of a list"
 }
@@ -83,17 +86,20 @@ func (c *LlenCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
-	length, err := b.Llen(key)
+	length, err := b.Llen(ctx, key)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	c.Ui.Output(fmt.Sprintf("%d", length))
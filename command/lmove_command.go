@@ -0,0 +1,124 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/dokku/prop/backend"
+	"github.com/posener/complete"
+)
+
+type LmoveCommand struct {
+	Meta
+
+	from string
+	to   string
+}
+
+func (c *LmoveCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LmoveCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "source",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "destination",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *LmoveCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-from": complete.PredictNothing,
+		"-to":   complete.PredictNothing,
+	}
+}
+
+func (c *LmoveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LmoveCommand) Examples() map[string]string {
+	return map[string]string{
+		"Move the first element of a list to the end of another":  "prop lmove pending processing",
+		"Move the last element of a list to the start of another": "prop lmove --from right --to left pending processing",
+		"Rotate a list by moving its first element to the end":    "prop lmove mylist mylist",
+	}
+}
+
+func (c *LmoveCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.StringVar(&c.from, "from", backend.ListDirectionLeft, "")
+	f.StringVar(&c.to, "to", backend.ListDirectionRight, "")
+	return f
+}
+
+func (c *LmoveCommand) Name() string {
+	return "lmove"
+}
+
+func (c *LmoveCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *LmoveCommand) Synopsis() string {
+	return `Atomically move an element from one list to another
+
+  Removes an element from the source list and adds it to the destination
+  list, outputting the moved element. The element is taken from the head of
+  the source unless --from is right, and added to the tail of the
+  destination unless --to is left. Moving an element between ends of the
+  same list rotates it. Exits with code 2 if the source list is empty.`
+}
+
+func (c *LmoveCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	element, err := b.Lmove(ctx, arguments["source"].StringValue(), arguments["destination"].StringValue(), strings.ToLower(c.from), strings.ToLower(c.to))
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(element)
+	return 0
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type LposCommand struct {
+	Meta
+
+	count int
+	rank  int
+}
+
+func (c *LposCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LposCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "element",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *LposCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-count": complete.PredictNothing,
+		"-rank":  complete.PredictNothing,
+	}
+}
+
+func (c *LposCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LposCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get the index of an element in a list":      "prop lpos mylist myelement",
+		"Get the indexes of every matching element":  "prop lpos --count 0 mylist myelement",
+		"Get the index of the last matching element": "prop lpos --rank -1 mylist myelement",
+	}
+}
+
+func (c *LposCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.IntVar(&c.count, "count", 1, "")
+	f.IntVar(&c.rank, "rank", 1, "")
+	return f
+}
+
+func (c *LposCommand) Name() string {
+	return "lpos"
+}
+
+func (c *LposCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *LposCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *LposCommand) Synopsis() string {
+	return `Get the indexes of matching elements in a list
+
+  Outputs the index of the first matching element, or of up to --count
+  matching elements one per line, where a count of 0 outputs every match.
+  With --rank, matching starts at the given match, and a negative rank
+  searches from the end of the list. Exits with code 1 if the element is
+  not in the list.`
+}
+
+func (c *LposCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	element := arguments["element"].StringValue()
+	positions, err := b.Lpos(ctx, key, element, c.rank, c.count)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if len(positions) == 0 {
+		return 1
+	}
+
+	for _, position := range positions {
+		c.Ui.Output(fmt.Sprintf("%d", position))
+	}
+
+	return 0
+}
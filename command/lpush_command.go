@@ -0,0 +1,114 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type LpushCommand struct {
+	Meta
+}
+
+func (c *LpushCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LpushCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "elements",
+		Optional: false,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *LpushCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *LpushCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LpushCommand) Examples() map[string]string {
+	return map[string]string{
+		"Prepend elements to a list": "prop lpush mykey myelement mysecondelement",
+	}
+}
+
+func (c *LpushCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *LpushCommand) Name() string {
+	return "lpush"
+}
+
+func (c *LpushCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *LpushCommand) Synopsis() string {
+	return `Prepend one or more elements to a list
+
+  Elements are inserted at the head of the list one after the other, so the
+  last element specified becomes the first element of the list. Outputs the
+  length of the list.`
+}
+
+func (c *LpushCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	elements := arguments["elements"].ListValue()
+	length, err := b.Lpush(ctx, key, elements...)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", length))
+
+	return 0
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -74,6 +73,10 @@ func (c *LrangeCommand) ParsedArguments(args []string) (map[string]Argument, err
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *LrangeCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *LrangeCommand) Synopsis() string {
 	return "Get a range of elements from a list"
 }
@@ -92,10 +95,13 @@ func (c *LrangeCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	var values []string
@@ -103,16 +109,16 @@ func (c *LrangeCommand) Run(args []string) int {
 	start := arguments["start"].IntValue()
 	stop := arguments["stop"].IntValue()
 	if !arguments["start"].HasValue {
-		values, err = b.Lrange(key)
+		values, err = b.Lrange(ctx, key)
 	} else if !arguments["stop"].HasValue {
-		values, err = b.Lrangefrom(key, start)
+		values, err = b.Lrangefrom(ctx, key, start)
 	} else {
-		values, err = b.Lrangefromto(key, start, stop)
+		values, err = b.Lrangefromto(ctx, key, start, stop)
 	}
 
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	for _, value := range values {
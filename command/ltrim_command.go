@@ -0,0 +1,121 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type LtrimCommand struct {
+	Meta
+}
+
+func (c *LtrimCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LtrimCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "start",
+		Optional: false,
+		Type:     ArgumentInt,
+	})
+	args = append(args, Argument{
+		Name:     "stop",
+		Optional: false,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *LtrimCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *LtrimCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LtrimCommand) Examples() map[string]string {
+	return map[string]string{
+		"Keep the first 100 elements of a list": "prop ltrim mylist 0 99",
+		"Keep the last 10 elements of a list":   "prop ltrim mylist -10 -1",
+	}
+}
+
+func (c *LtrimCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *LtrimCommand) Name() string {
+	return "ltrim"
+}
+
+func (c *LtrimCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *LtrimCommand) Synopsis() string {
+	return `Trim a list to the specified range of elements
+
+  The start and stop indexes are inclusive, and negative indexes count from
+  the end of the list. The key is removed if the range is empty.`
+}
+
+func (c *LtrimCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	start := arguments["start"].IntValue()
+	stop := arguments["stop"].IntValue()
+	ok, err := b.Ltrim(ctx, key, start, stop)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
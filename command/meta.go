@@ -1,11 +1,15 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/dokku/prop/backend"
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/colorstring"
 	"github.com/posener/complete"
@@ -41,6 +45,18 @@ type Meta struct {
 
 	// URL to read/write data to
 	url string
+
+	// Maximum time a command may spend talking to the backend
+	timeout time.Duration
+
+	// Backend shared by every command run within a batch, along with the
+	// url and namespace it was constructed for
+	backend          backend.Backend
+	backendURL       string
+	backendNamespace string
+
+	// Parent of the context returned by Context, if set
+	ctx context.Context
 }
 
 func (m *Meta) Namespace() string {
@@ -51,6 +67,47 @@ func (m *Meta) URL() string {
 	return m.url
 }
 
+func (m *Meta) Timeout() time.Duration {
+	return m.timeout
+}
+
+// Context returns the context a command passes to its backend. It is
+// cancelled on interrupt, and once the timeout elapses if one was specified.
+func (m *Meta) Context() (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var stop context.CancelFunc
+	if m.ctx != nil {
+		ctx, stop = context.WithCancel(m.ctx)
+	} else {
+		ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt)
+	}
+
+	if m.timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// Backend returns the backend a command operates on. Commands run within a
+// batch share the backend of the batch, and so may not specify a different
+// url or namespace.
+func (m *Meta) Backend(ctx context.Context) (backend.Backend, error) {
+	if m.backend == nil {
+		return backend.ConstructBackend(ctx, m.url, m.namespace)
+	}
+
+	if m.url != m.backendURL || m.namespace != m.backendNamespace {
+		return nil, fmt.Errorf("Commands within a batch or shell may not change the url or namespace")
+	}
+
+	return m.backend, nil
+}
+
 // FlagSet returns a FlagSet with the common flags that every
 // command implements. The exact behavior of FlagSet can be configured
 // using the flags as the second parameter, for example to disable
@@ -64,6 +121,7 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 		f.BoolVar(&m.noColor, "no-color", false, "")
 		f.StringVar(&m.namespace, "namespace", "default", "Namespace to use")
 		f.StringVar(&m.url, "url", "file:/var/lib/prop/data?system-user=root&system-group=root", "URL to read/write data to")
+		f.DurationVar(&m.timeout, "timeout", 0, "Maximum time to spend talking to the backend")
 	}
 
 	f.SetOutput(&uiErrorWriter{ui: m.Ui})
@@ -81,6 +139,7 @@ func (m *Meta) AutocompleteFlags(fs FlagSetFlags) complete.Flags {
 		"-no-color":  complete.PredictNothing,
 		"-namespace": complete.PredictNothing,
 		"-url":       complete.PredictNothing,
+		"-timeout":   complete.PredictNothing,
 	}
 }
 
@@ -102,6 +161,9 @@ func generalOptionsUsage() string {
     The namespace to interact with.
   --url <url>
     The url to use for the backend.
+  --timeout <duration>
+    The maximum time to spend talking to the backend, such as 5s. Commands
+    that exceed it exit with code 7. Defaults to no timeout.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -68,6 +67,10 @@ func (c *NamespaceExistsCommand) ParsedArguments(args []string) (map[string]Argu
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *NamespaceExistsCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *NamespaceExistsCommand) Run(args []string) int {
 	flags := c.FlagSet()
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
@@ -82,17 +85,20 @@ func (c *NamespaceExistsCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	namespace := arguments["namespace"].StringValue()
-	exists, err := b.NamespaceExists(namespace)
+	exists, err := b.NamespaceExists(ctx, namespace)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !exists {
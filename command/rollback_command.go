@@ -0,0 +1,133 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/dokku/prop/backend"
+	"github.com/posener/complete"
+)
+
+type RollbackCommand struct {
+	Meta
+
+	to int
+}
+
+func (c *RollbackCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RollbackCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *RollbackCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-to": complete.PredictNothing,
+	}
+}
+
+func (c *RollbackCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RollbackCommand) Examples() map[string]string {
+	return map[string]string{
+		"Undo the latest change to a key":           "prop rollback mykey",
+		"Restore the value of a key after revision": "prop rollback mykey --to 3",
+	}
+}
+
+func (c *RollbackCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.IntVar(&c.to, "to", 0, "")
+	return f
+}
+
+func (c *RollbackCommand) Name() string {
+	return "rollback"
+}
+
+func (c *RollbackCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *RollbackCommand) Synopsis() string {
+	return `Restore a previous value of a key
+
+  Restores the value the key held after the revision specified by the --to
+  flag, as listed by the history command, or undoes the latest change to
+  the key if no revision is specified. Key-values, lists and sets may be
+  restored, and a key is deleted if it did not exist at that revision. The
+  rollback is recorded as a new revision, and the timeout of the key is
+  not restored. Exits 4 if the revision does not exist.`
+}
+
+func (c *RollbackCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	positional, err := parseInterspersedFlags(flags, args)
+	if err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(positional)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if c.to < 0 {
+		c.Ui.Error("The --to revision must be a positive number")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	h, ok := b.(backend.HistoricalBackend)
+	if !ok {
+		c.Ui.Error(errHistoryNotEnabled.Error())
+		return exitCode(errHistoryNotEnabled)
+	}
+
+	key := arguments["key"].StringValue()
+	_, err = h.Rollback(ctx, key, c.to)
+	if errors.Is(err, backend.ErrNotImplemented) {
+		err = errHistoryNotEnabled
+	}
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	return 0
+}
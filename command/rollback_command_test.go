@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestRollbackCommand(t *testing.T) {
+	url := "file+json:" + t.TempDir() + "?history=true"
+	ui := cli.NewMockUi()
+	meta := Meta{Ui: ui}
+
+	for _, value := range []string{"one", "two", "three"} {
+		set := &SetCommand{Meta: meta}
+		if code := set.Run([]string{"--url", url, "mykey", value}); code != 0 {
+			t.Fatalf("set %s exited %d: %s", value, code, ui.ErrorWriter.String())
+		}
+	}
+
+	rollback := &RollbackCommand{Meta: meta}
+	if code := rollback.Run([]string{"--url", url, "mykey", "--to", "1"}); code != 0 {
+		t.Fatalf("rollback exited %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	get := &GetCommand{Meta: meta}
+	if code := get.Run([]string{"--url", url, "mykey"}); code != 0 {
+		t.Fatalf("get exited %d: %s", code, ui.ErrorWriter.String())
+	}
+	if value := strings.TrimSpace(ui.OutputWriter.String()); value != "one" {
+		t.Errorf("expected mykey to be rolled back to one, got %q", value)
+	}
+
+	rollback = &RollbackCommand{Meta: meta}
+	if code := rollback.Run([]string{"--url", url, "mykey", "--to", "10"}); code != ExitCodeIndexOutOfRange {
+		t.Errorf("expected rollback to a missing revision to exit %d, got %d", ExitCodeIndexOutOfRange, code)
+	}
+}
@@ -0,0 +1,120 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type RpopCommand struct {
+	Meta
+}
+
+func (c *RpopCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RpopCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "count",
+		Optional: true,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *RpopCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *RpopCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RpopCommand) Examples() map[string]string {
+	return map[string]string{
+		"Remove and get the last element of a list":        "prop rpop mylist",
+		"Remove and get the last three elements of a list": "prop rpop mylist 3",
+	}
+}
+
+func (c *RpopCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *RpopCommand) Name() string {
+	return "rpop"
+}
+
+func (c *RpopCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *RpopCommand) Synopsis() string {
+	return `Remove and get the last element of a list
+
+  Removes and outputs the last element of the list, or up to count elements
+  when count is specified, one per line. The key is removed once the list
+  is empty.`
+}
+
+func (c *RpopCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	count := 1
+	if arguments["count"].HasValue {
+		count = arguments["count"].IntValue()
+	}
+
+	elements, err := b.Rpop(ctx, key, count)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	for _, element := range elements {
+		c.Ui.Output(element)
+	}
+
+	return 0
+}
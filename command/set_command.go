@@ -2,14 +2,21 @@ package command
 
 import (
 	"flag"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
 type SetCommand struct {
 	Meta
+
+	ifNotExists bool
+	ifValue     *string
+	ifRevision  *int64
+	ttl         string
 }
 
 func (c *SetCommand) Help() string {
@@ -44,7 +51,12 @@ func (c *SetCommand) Arguments() []Argument {
 }
 
 func (c *SetCommand) AutocompleteFlags() complete.Flags {
-	return complete.Flags{}
+	return complete.Flags{
+		"-if-not-exists": complete.PredictNothing,
+		"-if-value":      complete.PredictNothing,
+		"-if-revision":   complete.PredictNothing,
+		"-ttl":           complete.PredictNothing,
+	}
 }
 
 func (c *SetCommand) AutocompleteArgs() complete.Predictor {
@@ -53,12 +65,31 @@ func (c *SetCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *SetCommand) Examples() map[string]string {
 	return map[string]string{
-		"Set a key": "prop set mykey myvalue",
+		"Set a key":                             "prop set mykey myvalue",
+		"Set a key that expires in an hour":     "prop set --ttl 1h mykey myvalue",
+		"Set a key only if it does not exist":   "prop set --if-not-exists mykey myvalue",
+		"Set a key only if it holds old-value":  "prop set --if-value old-value mykey myvalue",
+		"Set a key only if it is at revision 3": "prop set --if-revision 3 mykey myvalue",
 	}
 }
 
 func (c *SetCommand) FlagSet() *flag.FlagSet {
-	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.ifNotExists, "if-not-exists", false, "")
+	f.Func("if-value", "", func(value string) error {
+		c.ifValue = &value
+		return nil
+	})
+	f.Func("if-revision", "", func(value string) error {
+		revision, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || revision < 0 {
+			return fmt.Errorf("The --if-revision flag must be a revision output by get --revision, or 0")
+		}
+		c.ifRevision = &revision
+		return nil
+	})
+	f.StringVar(&c.ttl, "ttl", "", "")
+	return f
 }
 
 func (c *SetCommand) Name() string {
@@ -70,7 +101,17 @@ func (c *SetCommand) ParsedArguments(args []string) (map[string]Argument, error)
 }
 
 func (c *SetCommand) Synopsis() string {
-	return "Set the value of a key"
+	return `Set the value of a key
+
+  Setting a key removes any timeout on it, unless the --ttl flag is
+  specified with a number of seconds or a duration such as 1h.
+
+  The --if-not-exists flag only sets the key if it does not exist, while
+  the --if-value flag only sets the key if it currently holds the given
+  value, and the --if-revision flag only sets the key if it is still at
+  the revision output by get --revision, or does not exist if the revision
+  is 0. If the condition does not hold, the key is left unchanged and the
+  command exits with code 8.`
 }
 
 func (c *SetCommand) Run(args []string) int {
@@ -87,18 +128,55 @@ func (c *SetCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	conditions := 0
+	for _, set := range []bool{c.ifNotExists, c.ifValue != nil, c.ifRevision != nil} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions > 1 {
+		c.Ui.Error("Only one of the --if-not-exists, --if-value and --if-revision flags may be specified")
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	var ttl time.Duration
+	if c.ttl != "" {
+		ttl, err = parseTTL(c.ttl)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			c.Ui.Error(commandErrorText(c))
+			return 1
+		}
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
 	value := arguments["value"].StringValue()
-	ok, err := b.Set(key, value)
+	var ok bool
+	switch {
+	case c.ifNotExists:
+		ok, err = b.SetIfNotExists(ctx, key, value, ttl)
+	case c.ifValue != nil:
+		ok, err = b.SetIfValue(ctx, key, *c.ifValue, value, ttl)
+	case c.ifRevision != nil:
+		ok, err = b.SetIfRevision(ctx, key, *c.ifRevision, value, ttl)
+	case ttl > 0:
+		ok, err = b.SetWithTTL(ctx, key, value, ttl)
+	default:
+		ok, err = b.Set(ctx, key, value)
+	}
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !ok {
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestSetCommandIfRevision(t *testing.T) {
+	url := "file+json:" + t.TempDir()
+	meta := Meta{Ui: cli.NewMockUi()}
+
+	getRevision := func() string {
+		ui := cli.NewMockUi()
+		get := &GetCommand{Meta: Meta{Ui: ui}}
+		if code := get.Run([]string{"--url", url, "--revision", "mykey", "default"}); code != 0 {
+			t.Fatalf("get --revision exited %d: %s", code, ui.ErrorWriter.String())
+		}
+		return strings.SplitN(ui.OutputWriter.String(), "\n", 2)[0]
+	}
+
+	if revision := getRevision(); revision != "0" {
+		t.Fatalf("expected a missing key to have revision 0, got %q", revision)
+	}
+
+	set := &SetCommand{Meta: meta}
+	if code := set.Run([]string{"--url", url, "--if-revision", "0", "mykey", "one"}); code != 0 {
+		t.Fatalf("set --if-revision 0 exited %d", code)
+	}
+
+	revision := getRevision()
+	set = &SetCommand{Meta: meta}
+	if code := set.Run([]string{"--url", url, "--if-revision", revision, "mykey", "two"}); code != 0 {
+		t.Fatalf("set --if-revision %s exited %d", revision, code)
+	}
+
+	set = &SetCommand{Meta: meta}
+	if code := set.Run([]string{"--url", url, "--if-revision", revision, "mykey", "three"}); code != ExitCodeConditionFailed {
+		t.Errorf("expected set with a stale revision to exit %d, got %d", ExitCodeConditionFailed, code)
+	}
+
+	set = &SetCommand{Meta: meta}
+	if code := set.Run([]string{"--url", url, "--if-revision", revision, "--if-value", "two", "mykey", "three"}); code != ExitCodeError {
+		t.Errorf("expected set with two conditions to exit %d, got %d", ExitCodeError, code)
+	}
+}
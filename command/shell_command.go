@@ -0,0 +1,581 @@
+package command
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dokku/prop/backend"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// shellHistoryLimit is the maximum number of lines kept in the history file
+const shellHistoryLimit = 1000
+
+// shellBuiltins are the commands handled by the shell itself
+var shellBuiltins = []string{"exit", "help", "history", "quit", "use"}
+
+// lineReader reads the lines entered into the shell
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+// terminalLineReader reads lines from a terminal, which is only placed in raw
+// mode while a line is being edited so commands run with the usual terminal
+// settings and may be interrupted
+type terminalLineReader struct {
+	fd       int
+	terminal *terminal.Terminal
+}
+
+func (r *terminalLineReader) ReadLine() (string, error) {
+	state, err := terminal.MakeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer terminal.Restore(r.fd, state)
+
+	return r.terminal.ReadLine()
+}
+
+// scannerLineReader reads lines from a non-interactive input such as a pipe
+type scannerLineReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *scannerLineReader) ReadLine() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	return r.scanner.Text(), nil
+}
+
+type ShellCommand struct {
+	Meta
+
+	backend   backend.Backend
+	namespace string
+	history   []string
+}
+
+func (c *ShellCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShellCommand) Arguments() []Argument {
+	return []Argument{}
+}
+
+func (c *ShellCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *ShellCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ShellCommand) Examples() map[string]string {
+	return map[string]string{
+		"Open a shell":                      "prop shell",
+		"Open a shell on a sqlite backend":  "prop shell --url sqlite:/var/lib/prop/prop.db",
+		"Open a shell in a given namespace": "prop shell --namespace myapp",
+		"Run commands from a pipe":          "printf 'get mykey\\n' | prop shell",
+	}
+}
+
+func (c *ShellCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *ShellCommand) Name() string {
+	return "shell"
+}
+
+func (c *ShellCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *ShellCommand) Synopsis() string {
+	return `Opens an interactive shell bound to a backend
+
+  Each line holds a command and its arguments as they would be passed to
+  prop, such as "get mykey", and is run against the backend of the shell.
+  Arguments containing whitespace may be quoted.
+
+  The shell also accepts the following commands:
+
+    use <namespace>   Switch the namespace commands operate on
+    help [command]    List the available commands, or show help for one
+    history           Print the lines entered into the shell, or the
+                      changes to a key if a key is specified
+    exit, quit        Close the shell
+
+  Command names and keys are completed with the tab key, and previous
+  lines are recalled with the arrow keys. Lines are saved to
+  ~/.prop_history when the shell is interactive.`
+}
+
+func (c *ShellCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if _, err := c.ParsedArguments(flags.Args()); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	if err := c.use(c.Meta.Namespace()); err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+	defer c.close()
+
+	reader := c.lineReader()
+	for {
+		line, err := reader.ReadLine()
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		c.addHistory(line)
+		if exit := c.runLine(line); exit {
+			return 0
+		}
+	}
+}
+
+// runLine runs a single line entered into the shell, returning true if the
+// shell should be closed
+func (c *ShellCommand) runLine(line string) bool {
+	words, err := splitCommandLine(line)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return false
+	}
+
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+	case "help":
+		c.help(words[1:])
+		return false
+	case "history":
+		// history with a key shows the changes recorded for the key
+		if len(words) > 1 {
+			break
+		}
+		for i, entry := range c.history {
+			c.Ui.Output(fmt.Sprintf("%5d  %s", i+1, entry))
+		}
+		return false
+	case "use":
+		if len(words) != 2 {
+			c.Ui.Error("Usage: use <namespace>")
+			return false
+		}
+		if err := c.use(words[1]); err != nil {
+			c.Ui.Error(err.Error())
+		}
+		return false
+	}
+
+	commands := c.commands()
+	name, args, err := lookupCommand(commands, words)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return false
+	}
+
+	if name == c.Name() {
+		c.Ui.Error(fmt.Sprintf("%s may not be nested", name))
+		return false
+	}
+
+	cmd, err := commands[name]()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return false
+	}
+
+	if exitCode := c.runCommand(cmd, c.lineArgs(args)); exitCode != 0 {
+		c.Ui.Warn(fmt.Sprintf("(exit code %d)", exitCode))
+	}
+
+	return false
+}
+
+// runCommand runs a command within the shell. A command that panics is
+// reported as failing rather than ending the session.
+func (c *ShellCommand) runCommand(cmd cli.Command, args []string) (exitCode int) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.Ui.Error(fmt.Sprintf("Command failed: %v", r))
+			exitCode = 1
+		}
+	}()
+
+	return cmd.Run(args)
+}
+
+// use binds the shell to a namespace, constructing a backend for it
+func (c *ShellCommand) use(namespace string) error {
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := backend.ConstructBackend(ctx, c.Meta.URL(), namespace)
+	if err != nil {
+		return err
+	}
+
+	c.close()
+	c.backend = b
+	c.namespace = namespace
+	return nil
+}
+
+// close releases the backend the shell is bound to
+func (c *ShellCommand) close() {
+	if closer, ok := c.backend.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// commands returns the commands that may be run within the shell, each
+// sharing the backend of the shell
+func (c *ShellCommand) commands() map[string]cli.CommandFactory {
+	meta := c.Meta
+	meta.backend = c.backend
+	meta.backendURL = c.Meta.URL()
+	meta.backendNamespace = c.namespace
+	return Commands(&meta, nil)
+}
+
+// lineArgs returns the arguments a command is run with, defaulting its url,
+// namespace and timeout to those of the shell
+func (c *ShellCommand) lineArgs(args []string) []string {
+	lineArgs := []string{"--url", c.Meta.URL(), "--namespace", c.namespace}
+	if c.Meta.Timeout() > 0 {
+		lineArgs = append(lineArgs, "--timeout", c.Meta.Timeout().String())
+	}
+	return append(lineArgs, args...)
+}
+
+// help lists the commands available within the shell, or prints the help of
+// a single command
+func (c *ShellCommand) help(words []string) {
+	commands := c.commands()
+	if len(words) > 0 {
+		name, _, err := lookupCommand(commands, words)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return
+		}
+
+		cmd, err := commands[name]()
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return
+		}
+
+		c.Ui.Output(cmd.Help())
+		return
+	}
+
+	names := []string{}
+	for name := range commands {
+		if name != c.Name() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	rows := []string{}
+	for _, name := range names {
+		cmd, err := commands[name]()
+		if err != nil {
+			continue
+		}
+
+		synopsis := strings.SplitN(cmd.Synopsis(), "\n", 2)[0]
+		rows = append(rows, fmt.Sprintf("%s | %s", name, synopsis))
+	}
+	c.Ui.Output(formatListWithSpaces(rows))
+}
+
+// lineReader returns a reader for the lines entered into the shell, with
+// line editing, history and completion when stdin is a terminal
+func (c *ShellCommand) lineReader() lineReader {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return &scannerLineReader{scanner: bufio.NewScanner(os.Stdin)}
+	}
+
+	t := terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	if width, height, err := terminal.GetSize(fd); err == nil && width > 0 {
+		t.SetSize(width, height)
+	}
+
+	for _, entry := range c.loadHistory() {
+		t.History.Add(entry)
+		c.history = append(c.history, entry)
+	}
+
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return c.complete(t, line, pos)
+	}
+
+	t.SetPrompt(c.prompt())
+	return &promptingLineReader{
+		terminalLineReader: terminalLineReader{fd: fd, terminal: t},
+		prompt:             c.prompt,
+	}
+}
+
+// promptingLineReader refreshes the prompt before each line, so it reflects
+// the current namespace
+type promptingLineReader struct {
+	terminalLineReader
+	prompt func() string
+}
+
+func (r *promptingLineReader) ReadLine() (string, error) {
+	r.terminal.SetPrompt(r.prompt())
+	return r.terminalLineReader.ReadLine()
+}
+
+// prompt returns the prompt shown before each line
+func (c *ShellCommand) prompt() string {
+	return fmt.Sprintf("prop:%s> ", c.namespace)
+}
+
+// complete completes the word before the cursor with a command name, or with
+// a key within the current namespace for later words
+func (c *ShellCommand) complete(t *terminal.Terminal, line string, pos int) (string, int, bool) {
+	before := line[:pos]
+	words := strings.Fields(before)
+	if len(words) == 0 || strings.HasSuffix(before, " ") {
+		words = append(words, "")
+	}
+	word := words[len(words)-1]
+
+	var candidates []string
+	switch {
+	case len(words) == 1:
+		candidates = c.commandCompletions("")
+	case len(words) == 2 && len(c.commandCompletions(words[0]+" ")) > 0:
+		candidates = c.commandCompletions(words[0] + " ")
+	case words[0] == "use":
+		candidates = c.namespaceCompletions()
+	case words[0] == "help":
+		candidates = c.commandCompletions("")
+	default:
+		candidates = c.keyCompletions(word)
+	}
+
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := matches[0]
+	if len(matches) == 1 {
+		completion += " "
+	} else {
+		completion = commonPrefix(matches)
+		if completion == word {
+			fmt.Fprintf(t, "%s\n", strings.Join(matches, "  "))
+			return "", 0, false
+		}
+	}
+
+	newBefore := before[:len(before)-len(word)] + completion
+	return newBefore + line[pos:], len(newBefore), true
+}
+
+// commandCompletions returns the words that follow prefix within the names
+// of the available commands
+func (c *ShellCommand) commandCompletions(prefix string) []string {
+	seen := map[string]bool{}
+	if prefix == "" {
+		for _, name := range shellBuiltins {
+			seen[name] = true
+		}
+	}
+
+	for name := range c.commands() {
+		if name == c.Name() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		seen[strings.Fields(strings.TrimPrefix(name, prefix))[0]] = true
+	}
+
+	words := []string{}
+	for word := range seen {
+		words = append(words, word)
+	}
+	return words
+}
+
+// keyCompletions returns the keys within the current namespace starting with
+// prefix. Only key-value pairs are read, so that completing a key does not
+// export the whole backend.
+func (c *ShellCommand) keyCompletions(prefix string) []string {
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	keyValuePairs, err := c.backend.GetAllByPrefix(ctx, prefix)
+	if err != nil {
+		return nil
+	}
+
+	keys := []string{}
+	for key := range keyValuePairs {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// namespaceCompletions returns the namespaces holding at least one key
+func (c *ShellCommand) namespaceCompletions() []string {
+	seen := map[string]bool{}
+	for _, p := range c.export() {
+		seen[p.Namespace] = true
+	}
+
+	namespaces := []string{}
+	for namespace := range seen {
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// export returns every property within the backend, or none if the backend
+// cannot be exported
+func (c *ShellCommand) export() []backend.Property {
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	p, err := c.backend.BackendExport(ctx)
+	if err != nil {
+		return nil
+	}
+	return p.Properties
+}
+
+// historyPath returns the path of the file lines are saved to
+func (c *ShellCommand) historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".prop_history")
+}
+
+// loadHistory returns the lines saved by previous interactive shells
+func (c *ShellCommand) loadHistory() []string {
+	path := c.historyPath()
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	contents := strings.TrimSpace(string(data))
+	if contents == "" {
+		return nil
+	}
+
+	lines := strings.Split(contents, "\n")
+	if len(lines) > shellHistoryLimit {
+		lines = lines[len(lines)-shellHistoryLimit:]
+	}
+	return lines
+}
+
+// addHistory records a line, saving it when the shell is interactive
+func (c *ShellCommand) addHistory(line string) {
+	c.history = append(c.history, line)
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return
+	}
+
+	path := c.historyPath()
+	if path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, line)
+}
+
+// commonPrefix returns the longest prefix shared by every string
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type SinterstoreCommand struct {
+	Meta
+}
+
+func (c *SinterstoreCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SinterstoreCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "destination",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "keys",
+		Optional: true,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *SinterstoreCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *SinterstoreCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SinterstoreCommand) Examples() map[string]string {
+	return map[string]string{
+		"Store the apps enabled on both hosts": "prop sinterstore shared-apps host-1-apps host-2-apps",
+	}
+}
+
+func (c *SinterstoreCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *SinterstoreCommand) Name() string {
+	return "sinterstore"
+}
+
+func (c *SinterstoreCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *SinterstoreCommand) Synopsis() string {
+	return `Store the members found in every one of the given sets in a destination set
+
+  Replaces the destination key, whatever it held, with the resulting set
+  and outputs the number of members in it. The destination is removed if
+  the resulting set is empty.`
+}
+
+func (c *SinterstoreCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	destination := arguments["destination"].StringValue()
+	keys := append([]string{arguments["key"].StringValue()}, arguments["keys"].ListValue()...)
+	count, err := b.Sinterstore(ctx, destination, keys...)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", count))
+	return 0
+}
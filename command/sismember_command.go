@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -69,6 +68,10 @@ func (c *SismemberCommand) ParsedArguments(args []string) (map[string]Argument,
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *SismemberCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *SismemberCommand) Synopsis() string {
 	return "Determine if a given value is a member of a set"
 }
@@ -87,18 +90,21 @@ func (c *SismemberCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
 	member := arguments["member"].StringValue()
-	ok, err := b.Sismember(key, member)
+	ok, err := b.Sismember(ctx, key, member)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	if !ok {
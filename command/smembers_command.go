@@ -4,7 +4,6 @@ import (
 	"flag"
 	"strings"
 
-	"github.com/dokku/prop/backend"
 	"github.com/posener/complete"
 )
 
@@ -64,6 +63,10 @@ func (c *SmembersCommand) ParsedArguments(args []string) (map[string]Argument, e
 	return parseArguments(args, c.Arguments())
 }
 
+func (c *SmembersCommand) ReadOnly() bool {
+	return true
+}
+
 func (c *SmembersCommand) Synopsis() string {
 	return "Get all the members in a set"
 }
@@ -82,17 +85,20 @@ func (c *SmembersCommand) Run(args []string) int {
 		return 1
 	}
 
-	b, err := backend.ConstructBackend(c.Meta.URL(), c.Meta.Namespace())
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	key := arguments["key"].StringValue()
-	members, err := b.Smembers(key)
+	members, err := b.Smembers(ctx, key)
 	if err != nil {
 		c.Ui.Error(err.Error())
-		return 1
+		return exitCode(err)
 	}
 
 	for member := range members {
@@ -0,0 +1,120 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type SmoveCommand struct {
+	Meta
+}
+
+func (c *SmoveCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SmoveCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "source",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "destination",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "member",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *SmoveCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *SmoveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SmoveCommand) Examples() map[string]string {
+	return map[string]string{
+		"Move a member from one set to another": "prop smove pending-apps enabled-apps myapp",
+	}
+}
+
+func (c *SmoveCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *SmoveCommand) Name() string {
+	return "smove"
+}
+
+func (c *SmoveCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *SmoveCommand) Synopsis() string {
+	return `Atomically move a member from one set to another
+
+  Removes the member from the source set and adds it to the destination
+  set. Exits with code 1 if the member is not in the source set, in which
+  case neither set is modified.`
+}
+
+func (c *SmoveCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	source := arguments["source"].StringValue()
+	destination := arguments["destination"].StringValue()
+	moved, err := b.Smove(ctx, source, destination, arguments["member"].StringValue())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if !moved {
+		return 1
+	}
+
+	return 0
+}
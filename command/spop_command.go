@@ -0,0 +1,120 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type SpopCommand struct {
+	Meta
+}
+
+func (c *SpopCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SpopCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "count",
+		Optional: true,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *SpopCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *SpopCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SpopCommand) Examples() map[string]string {
+	return map[string]string{
+		"Remove and get a random member of a set":      "prop spop myset",
+		"Remove and get three random members of a set": "prop spop myset 3",
+	}
+}
+
+func (c *SpopCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *SpopCommand) Name() string {
+	return "spop"
+}
+
+func (c *SpopCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *SpopCommand) Synopsis() string {
+	return `Remove and get random members of a set
+
+  Removes and outputs a random member of the set, or up to count distinct
+  members when count is specified, one per line. The key is removed once
+  the set is empty.`
+}
+
+func (c *SpopCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	count := 1
+	if arguments["count"].HasValue {
+		count = arguments["count"].IntValue()
+	}
+
+	members, err := b.Spop(ctx, key, count)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	for _, member := range members {
+		c.Ui.Output(member)
+	}
+
+	return 0
+}
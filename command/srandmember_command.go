@@ -0,0 +1,125 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type SrandmemberCommand struct {
+	Meta
+}
+
+func (c *SrandmemberCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SrandmemberCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "count",
+		Optional: true,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *SrandmemberCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *SrandmemberCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SrandmemberCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get a random member of a set":                        "prop srandmember myset",
+		"Get three distinct random members of a set":          "prop srandmember myset 3",
+		"Get three random members of a set, allowing repeats": "prop srandmember myset -3",
+	}
+}
+
+func (c *SrandmemberCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *SrandmemberCommand) Name() string {
+	return "srandmember"
+}
+
+func (c *SrandmemberCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *SrandmemberCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *SrandmemberCommand) Synopsis() string {
+	return `Get random members of a set without removing them
+
+  Outputs a random member of the set, or up to count distinct members when
+  count is specified, one per line. A negative count outputs exactly that
+  many members, which may repeat.`
+}
+
+func (c *SrandmemberCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	count := 1
+	if arguments["count"].HasValue {
+		count = arguments["count"].IntValue()
+	}
+
+	members, err := b.Srandmember(ctx, key, count)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	for _, member := range members {
+		c.Ui.Output(member)
+	}
+
+	return 0
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"flag"
+	"sort"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type SunionCommand struct {
+	Meta
+}
+
+func (c *SunionCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SunionCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "keys",
+		Optional: true,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *SunionCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *SunionCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SunionCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get the apps enabled on either host": "prop sunion host-1-apps host-2-apps",
+	}
+}
+
+func (c *SunionCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *SunionCommand) Name() string {
+	return "sunion"
+}
+
+func (c *SunionCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *SunionCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *SunionCommand) Synopsis() string {
+	return `Get the members found in any of the given sets
+
+  Outputs the resulting members in sorted order, one per line. Missing keys
+  are treated as empty sets.`
+}
+
+func (c *SunionCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	keys := append([]string{arguments["key"].StringValue()}, arguments["keys"].ListValue()...)
+	members, err := b.Sunion(ctx, keys...)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	sorted := []string{}
+	for member := range members {
+		sorted = append(sorted, member)
+	}
+	sort.Strings(sorted)
+
+	for _, member := range sorted {
+		c.Ui.Output(member)
+	}
+
+	return 0
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dokku/prop/backend"
+	"github.com/posener/complete"
+)
+
+type TTLCommand struct {
+	Meta
+}
+
+func (c *TTLCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TTLCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *TTLCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *TTLCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TTLCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get the remaining time to live of a key": "prop ttl mykey",
+	}
+}
+
+func (c *TTLCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *TTLCommand) Name() string {
+	return "ttl"
+}
+
+func (c *TTLCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *TTLCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *TTLCommand) Synopsis() string {
+	return `Get the remaining time to live of a key
+
+  Outputs the number of seconds until the key expires, or -1 if the key
+  does not expire.`
+}
+
+func (c *TTLCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	ttl, err := b.TTL(ctx, key)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if ttl == backend.NoExpiration {
+		c.Ui.Output("-1")
+		return 0
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", int64(ttl.Round(time.Second)/time.Second)))
+	return 0
+}
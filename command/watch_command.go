@@ -0,0 +1,157 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/dokku/prop/backend"
+	"github.com/posener/complete"
+)
+
+type WatchCommand struct {
+	Meta
+
+	json bool
+}
+
+func (c *WatchCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *WatchCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: true,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *WatchCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-json": complete.PredictNothing,
+	}
+}
+
+func (c *WatchCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *WatchCommand) Examples() map[string]string {
+	return map[string]string{
+		"Watch every key in a namespace":      "prop watch",
+		"Watch a single key":                  "prop watch mykey",
+		"Watch keys starting with a prefix":   "prop watch 'config/*'",
+		"Watch a namespace, printing as json": "prop watch --json",
+	}
+}
+
+func (c *WatchCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.json, "json", false, "")
+	return f
+}
+
+func (c *WatchCommand) Name() string {
+	return "watch"
+}
+
+func (c *WatchCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *WatchCommand) Synopsis() string {
+	return `Stream changes to keys until interrupted
+
+  Watches a single key, the keys starting with a prefix followed by *, or
+  every key in the namespace if no key is specified. A line holding the
+  type of change, either changed or deleted, and the key is printed for
+  each change, or a json object with the type, namespace and key if the
+  --json flag is specified. Changes to the timeout of a key are not
+  printed.`
+}
+
+func (c *WatchCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	key := ""
+	if arguments["key"].HasValue {
+		key = arguments["key"].StringValue()
+	}
+	prefix := strings.TrimSuffix(key, "*")
+	exact := key != "" && prefix == key
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	w, ok := b.(backend.WatchableBackend)
+	if !ok {
+		c.Ui.Error(backend.ErrNotImplemented.Error())
+		return exitCode(backend.ErrNotImplemented)
+	}
+
+	events, err := w.Watch(ctx, prefix)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	for event := range events {
+		if exact && event.Key != key {
+			continue
+		}
+
+		if !c.json {
+			c.Ui.Output(fmt.Sprintf("%s %s", event.Type, event.Key))
+			continue
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		c.Ui.Output(string(data))
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		c.Ui.Error(ctx.Err().Error())
+		return exitCode(ctx.Err())
+	}
+
+	return 0
+}
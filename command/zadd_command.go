@@ -0,0 +1,143 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type ZaddCommand struct {
+	Meta
+}
+
+func (c *ZaddCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ZaddCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "scores",
+		Optional: false,
+		Type:     ArgumentList,
+	})
+	return args
+}
+
+func (c *ZaddCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *ZaddCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ZaddCommand) Examples() map[string]string {
+	return map[string]string{
+		"Add a member with a score to the sorted set": "prop zadd mysortedset 1.5 mymember",
+	}
+}
+
+func (c *ZaddCommand) FlagSet() *flag.FlagSet {
+	return c.Meta.FlagSet(c.Name(), FlagSetClient)
+}
+
+func (c *ZaddCommand) Name() string {
+	return "zadd"
+}
+
+func (c *ZaddCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	arguments, err := parseArguments(args, c.Arguments())
+	if err != nil {
+		return arguments, err
+	}
+
+	if _, err := parseScores(arguments["scores"].ListValue()); err != nil {
+		return arguments, err
+	}
+
+	return arguments, nil
+}
+
+func (c *ZaddCommand) Synopsis() string {
+	return "Add one or more members to a sorted set, or update their scores"
+}
+
+func (c *ZaddCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	scores, err := parseScores(arguments["scores"].ListValue())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	addedCount, err := b.Zadd(ctx, key, scores)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	c.Ui.Output(fmt.Sprintf("%d", addedCount))
+
+	return 0
+}
+
+// parseScores parses alternating scores and members
+func parseScores(pairs []string) (map[string]float64, error) {
+	if len(pairs)%2 != 0 {
+		return map[string]float64{}, errors.New("Every score requires a member")
+	}
+
+	scores := map[string]float64{}
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := parseScore(pairs[i])
+		if err != nil {
+			return map[string]float64{}, err
+		}
+		scores[pairs[i+1]] = score
+	}
+
+	return scores, nil
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type ZrangeCommand struct {
+	Meta
+
+	withScores bool
+}
+
+func (c *ZrangeCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ZrangeCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "start",
+		Optional: true,
+		Type:     ArgumentInt,
+	})
+	args = append(args, Argument{
+		Name:     "stop",
+		Optional: true,
+		Type:     ArgumentInt,
+	})
+	return args
+}
+
+func (c *ZrangeCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-with-scores": complete.PredictNothing,
+	}
+}
+
+func (c *ZrangeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ZrangeCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get all the members in a sorted set":             "prop zrange mysortedset",
+		"Get the three members with the highest scores":   "prop zrange mysortedset -3 -1",
+		"Get all the members in a sorted set with scores": "prop zrange --with-scores mysortedset",
+	}
+}
+
+func (c *ZrangeCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.withScores, "with-scores", false, "")
+	return f
+}
+
+func (c *ZrangeCommand) Name() string {
+	return "zrange"
+}
+
+func (c *ZrangeCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	return parseArguments(args, c.Arguments())
+}
+
+func (c *ZrangeCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *ZrangeCommand) Synopsis() string {
+	return "Get a range of members in a sorted set by rank"
+}
+
+func (c *ZrangeCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	start := 0
+	if arguments["start"].HasValue {
+		start = arguments["start"].IntValue()
+	}
+	stop := -1
+	if arguments["stop"].HasValue {
+		stop = arguments["stop"].IntValue()
+	}
+
+	members, err := b.Zrange(ctx, key, start, stop)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if len(members) == 0 {
+		return 0
+	}
+
+	c.Ui.Output(formatSortedSetMembers(members, c.withScores))
+
+	return 0
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type ZrangebyscoreCommand struct {
+	Meta
+
+	withScores bool
+}
+
+func (c *ZrangebyscoreCommand) Help() string {
+	helpText := `
+Usage: prop ` + c.Name() + ` ` + flagString(c.FlagSet()) + ` ` + argumentString(c.Arguments()) + `
+
+  ` + c.Synopsis() + `
+
+General Options:
+  ` + generalOptionsUsage() + `
+
+Example:
+
+` + exampleString(c.Examples())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ZrangebyscoreCommand) Arguments() []Argument {
+	args := []Argument{}
+	args = append(args, Argument{
+		Name:     "key",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "min",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	args = append(args, Argument{
+		Name:     "max",
+		Optional: false,
+		Type:     ArgumentString,
+	})
+	return args
+}
+
+func (c *ZrangebyscoreCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-with-scores": complete.PredictNothing,
+	}
+}
+
+func (c *ZrangebyscoreCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ZrangebyscoreCommand) Examples() map[string]string {
+	return map[string]string{
+		"Get the members in a sorted set with a score from 1 to 10":  "prop zrangebyscore mysortedset 1 10",
+		"Get the members in a sorted set with a score of at least 1": "prop zrangebyscore mysortedset 1 +inf",
+	}
+}
+
+func (c *ZrangebyscoreCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), FlagSetClient)
+	f.BoolVar(&c.withScores, "with-scores", false, "")
+	return f
+}
+
+func (c *ZrangebyscoreCommand) Name() string {
+	return "zrangebyscore"
+}
+
+func (c *ZrangebyscoreCommand) ParsedArguments(args []string) (map[string]Argument, error) {
+	arguments, err := parseArguments(args, c.Arguments())
+	if err != nil {
+		return arguments, err
+	}
+
+	for _, name := range []string{"min", "max"} {
+		if _, err := parseScore(arguments[name].StringValue()); err != nil {
+			return arguments, err
+		}
+	}
+
+	return arguments, nil
+}
+
+func (c *ZrangebyscoreCommand) ReadOnly() bool {
+	return true
+}
+
+func (c *ZrangebyscoreCommand) Synopsis() string {
+	return "Get the members in a sorted set with a score within a range"
+}
+
+func (c *ZrangebyscoreCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(commandErrorText(c))
+		return 1
+	}
+
+	ctx, cancel := c.Meta.Context()
+	defer cancel()
+
+	b, err := c.Meta.Backend(ctx)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	key := arguments["key"].StringValue()
+	min, _ := parseScore(arguments["min"].StringValue())
+	max, _ := parseScore(arguments["max"].StringValue())
+	members, err := b.Zrangebyscore(ctx, key, min, max)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitCode(err)
+	}
+
+	if len(members) == 0 {
+		return 0
+	}
+
+	c.Ui.Output(formatSortedSetMembers(members, c.withScores))
+
+	return 0
+}
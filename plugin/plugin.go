@@ -0,0 +1,327 @@
+// Package plugin serves a backend.Backend implementation as an out-of-process
+// prop plugin. A plugin is an executable named prop-plugin-NAME on the PATH,
+// selected with a plugin+NAME:// backend url:
+//
+//	func main() {
+//		err := plugin.Serve(func(ctx context.Context, namespace string, u *dburl.URL) (backend.Backend, error) {
+//			return NewCustomBackend(namespace, u)
+//		})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/dokku/prop/backend"
+)
+
+// Serve speaks the plugin protocol over stdin and stdout until stdin is
+// closed, constructing the backend with factory during the handshake. As
+// stdout carries the protocol, os.Stdout is redirected to stderr while serving.
+func Serve(factory backend.Factory) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	return ServeConn(context.Background(), os.Stdin, stdout, factory)
+}
+
+// ServeConn speaks the plugin protocol over a reader and writer until the
+// reader returns io.EOF. Each backend call receives a context derived from
+// ctx, bounded by the deadline sent with the request.
+func ServeConn(ctx context.Context, r io.Reader, w io.Writer, factory backend.Factory) error {
+	s := server{factory: factory}
+	decoder := json.NewDecoder(r)
+	encoder := json.NewEncoder(w)
+
+	for {
+		var request backend.PluginRequest
+		if err := decoder.Decode(&request); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			response := errorResponse(0, backend.PluginErrorCodeParse, err.Error())
+			encoder.Encode(response)
+			return fmt.Errorf("Unable to decode plugin request: %s", err.Error())
+		}
+
+		if err := encoder.Encode(s.handle(ctx, request)); err != nil {
+			return fmt.Errorf("Unable to encode plugin response: %s", err.Error())
+		}
+	}
+}
+
+type server struct {
+	factory backend.Factory
+	backend backend.Backend
+}
+
+// handle responds to a single request
+func (s *server) handle(ctx context.Context, request backend.PluginRequest) backend.PluginResponse {
+	if request.Deadline != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, *request.Deadline)
+		defer cancel()
+	}
+
+	if request.Method == backend.PluginMethodHandshake {
+		return s.handshake(ctx, request)
+	}
+
+	if s.backend == nil {
+		return errorResponse(request.ID, backend.PluginErrorCodeInvalidRequest, "Handshake required")
+	}
+
+	var args backend.PluginArgs
+	if len(request.Params) > 0 {
+		if err := json.Unmarshal(request.Params, &args); err != nil {
+			return errorResponse(request.ID, backend.PluginErrorCodeInvalidParams, err.Error())
+		}
+	}
+
+	result, ok, err := s.dispatch(ctx, request.Method, args)
+	if !ok {
+		return errorResponse(request.ID, backend.PluginErrorCodeMethodNotFound, fmt.Sprintf("Unknown method %s", request.Method))
+	}
+	if err != nil {
+		return backend.PluginResponse{JSONRPC: "2.0", ID: request.ID, Error: backend.NewPluginError(err)}
+	}
+
+	return resultResponse(request.ID, result)
+}
+
+// handshake verifies the protocol version and constructs the backend
+func (s *server) handshake(ctx context.Context, request backend.PluginRequest) backend.PluginResponse {
+	var params backend.PluginHandshake
+	if err := json.Unmarshal(request.Params, &params); err != nil {
+		return errorResponse(request.ID, backend.PluginErrorCodeInvalidParams, err.Error())
+	}
+
+	if params.ProtocolVersion != backend.PluginProtocolVersion {
+		message := fmt.Sprintf("Unsupported protocol version %d, expected %d", params.ProtocolVersion, backend.PluginProtocolVersion)
+		return errorResponse(request.ID, backend.PluginErrorCodeInvalidRequest, message)
+	}
+
+	u, err := backend.ParseURL(params.URL)
+	if err != nil {
+		return errorResponse(request.ID, backend.PluginErrorCodeInvalidParams, err.Error())
+	}
+
+	b, err := s.factory(ctx, params.Namespace, u)
+	if err != nil {
+		return backend.PluginResponse{JSONRPC: "2.0", ID: request.ID, Error: backend.NewPluginError(err)}
+	}
+
+	s.backend = b
+	return resultResponse(request.ID, backend.PluginHandshake{ProtocolVersion: backend.PluginProtocolVersion})
+}
+
+// dispatch calls the Backend method named by method, returning false if there
+// is no such method
+func (s *server) dispatch(ctx context.Context, method string, args backend.PluginArgs) (interface{}, bool, error) {
+	var result interface{}
+	var err error
+
+	b := s.backend
+	switch method {
+	case "BackendExport":
+		result, err = b.BackendExport(ctx)
+	case "BackendImport":
+		p := backend.PropertyCollection{}
+		if args.Properties != nil {
+			p = *args.Properties
+		}
+		result, err = b.BackendImport(ctx, p, args.Clear)
+	case "BackendReset":
+		result, err = b.BackendReset(ctx)
+	case "Del":
+		result, err = b.Del(ctx, args.Key)
+	case "Exists":
+		result, err = b.Exists(ctx, args.Key)
+	case "NamespaceExists":
+		result, err = b.NamespaceExists(ctx, args.Namespace)
+	case "NamespaceClear":
+		result, err = b.NamespaceClear(ctx, args.Namespace)
+	case "Get":
+		result, err = b.Get(ctx, args.Key, args.DefaultValue)
+	case "GetWithRevision":
+		var value backend.PluginValueWithRevision
+		value.Value, value.Revision, err = b.GetWithRevision(ctx, args.Key)
+		result = value
+	case "GetAll":
+		result, err = b.GetAll(ctx)
+	case "GetAllByPrefix":
+		result, err = b.GetAllByPrefix(ctx, args.Prefix)
+	case "Set":
+		result, err = b.Set(ctx, args.Key, args.Value)
+	case "SetWithTTL":
+		result, err = b.SetWithTTL(ctx, args.Key, args.Value, args.TTL)
+	case "SetIfNotExists":
+		result, err = b.SetIfNotExists(ctx, args.Key, args.Value, args.TTL)
+	case "SetIfValue":
+		result, err = b.SetIfValue(ctx, args.Key, args.OldValue, args.Value, args.TTL)
+	case "SetIfRevision":
+		result, err = b.SetIfRevision(ctx, args.Key, args.Revision, args.Value, args.TTL)
+	case "GetSet":
+		result, err = b.GetSet(ctx, args.Key, args.Value)
+	case "Incr":
+		result, err = b.Incr(ctx, args.Key)
+	case "IncrBy":
+		result, err = b.IncrBy(ctx, args.Key, args.Increment)
+	case "Decr":
+		result, err = b.Decr(ctx, args.Key)
+	case "Expire":
+		result, err = b.Expire(ctx, args.Key, args.TTL)
+	case "Persist":
+		result, err = b.Persist(ctx, args.Key)
+	case "TTL":
+		result, err = b.TTL(ctx, args.Key)
+	case "Blpop":
+		result, err = b.Blpop(ctx, args.Key, args.Timeout)
+	case "Brpop":
+		result, err = b.Brpop(ctx, args.Key, args.Timeout)
+	case "Lindex":
+		result, err = b.Lindex(ctx, args.Key, args.Index)
+	case "Linsert":
+		result, err = b.Linsert(ctx, args.Key, args.Position, args.Pivot, args.Element)
+	case "Lismember":
+		result, err = b.Lismember(ctx, args.Key, args.Element)
+	case "Llen":
+		result, err = b.Llen(ctx, args.Key)
+	case "Lmove":
+		result, err = b.Lmove(ctx, args.Source, args.Destination, args.From, args.To)
+	case "Lrange":
+		result, err = b.Lrange(ctx, args.Key)
+	case "Lrangefrom":
+		result, err = b.Lrangefrom(ctx, args.Key, args.Start)
+	case "Lrangefromto":
+		result, err = b.Lrangefromto(ctx, args.Key, args.Start, args.Stop)
+	case "Lrem":
+		result, err = b.Lrem(ctx, args.Key, args.CountToRemove, args.Element)
+	case "Lpop":
+		result, err = b.Lpop(ctx, args.Key, args.Count)
+	case "Lpos":
+		result, err = b.Lpos(ctx, args.Key, args.Element, args.Rank, args.Count)
+	case "Lpush":
+		result, err = b.Lpush(ctx, args.Key, args.NewElements...)
+	case "Lset":
+		result, err = b.Lset(ctx, args.Key, args.Index, args.Element)
+	case "Ltrim":
+		result, err = b.Ltrim(ctx, args.Key, args.Start, args.Stop)
+	case "Rpop":
+		result, err = b.Rpop(ctx, args.Key, args.Count)
+	case "Rpush":
+		result, err = b.Rpush(ctx, args.Key, args.NewElements...)
+	case "Sadd":
+		result, err = b.Sadd(ctx, args.Key, args.NewMembers...)
+	case "Scard":
+		result, err = b.Scard(ctx, args.Key)
+	case "Sdiff":
+		result, err = b.Sdiff(ctx, args.Keys...)
+	case "Sdiffstore":
+		result, err = b.Sdiffstore(ctx, args.Destination, args.Keys...)
+	case "Sinter":
+		result, err = b.Sinter(ctx, args.Keys...)
+	case "Sinterstore":
+		result, err = b.Sinterstore(ctx, args.Destination, args.Keys...)
+	case "Sismember":
+		result, err = b.Sismember(ctx, args.Key, args.Member)
+	case "Smembers":
+		result, err = b.Smembers(ctx, args.Key)
+	case "Smove":
+		result, err = b.Smove(ctx, args.Source, args.Destination, args.Member)
+	case "Spop":
+		result, err = b.Spop(ctx, args.Key, args.Count)
+	case "Srandmember":
+		result, err = b.Srandmember(ctx, args.Key, args.Count)
+	case "Srem":
+		result, err = b.Srem(ctx, args.Key, args.MembersToRemove...)
+	case "Sunion":
+		result, err = b.Sunion(ctx, args.Keys...)
+	case "Sunionstore":
+		result, err = b.Sunionstore(ctx, args.Destination, args.Keys...)
+	case "Hdel":
+		result, err = b.Hdel(ctx, args.Key, args.FieldsToRemove...)
+	case "Hexists":
+		result, err = b.Hexists(ctx, args.Key, args.Field)
+	case "Hget":
+		result, err = b.Hget(ctx, args.Key, args.Field)
+	case "Hgetall":
+		result, err = b.Hgetall(ctx, args.Key)
+	case "Hkeys":
+		result, err = b.Hkeys(ctx, args.Key)
+	case "Hlen":
+		result, err = b.Hlen(ctx, args.Key)
+	case "Hset":
+		result, err = b.Hset(ctx, args.Key, args.Fields)
+	case "Zadd":
+		result, err = b.Zadd(ctx, args.Key, args.Scores)
+	case "Zcard":
+		result, err = b.Zcard(ctx, args.Key)
+	case "Zrange":
+		result, err = b.Zrange(ctx, args.Key, args.Start, args.Stop)
+	case "Zrangebyscore":
+		var min, max float64
+		if min, err = parseScore(args.Min); err != nil {
+			break
+		}
+		if max, err = parseScore(args.Max); err != nil {
+			break
+		}
+		result, err = b.Zrangebyscore(ctx, args.Key, min, max)
+	case "Zrank":
+		result, err = b.Zrank(ctx, args.Key, args.Member)
+	case "Zrem":
+		result, err = b.Zrem(ctx, args.Key, args.MembersToRemove...)
+	case "Zscore":
+		result, err = b.Zscore(ctx, args.Key, args.Member)
+	default:
+		return nil, false, nil
+	}
+
+	return result, true, err
+}
+
+func resultResponse(id uint64, result interface{}) backend.PluginResponse {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(id, backend.PluginErrorCodeBackend, err.Error())
+	}
+
+	return backend.PluginResponse{JSONRPC: "2.0", ID: id, Result: raw}
+}
+
+func errorResponse(id uint64, code int, message string) backend.PluginResponse {
+	return backend.PluginResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &backend.PluginError{Code: code, Message: message},
+	}
+}
+
+// parseScore parses a score bound, which is sent as a string so that
+// infinite bounds may be represented. An empty bound is zero.
+func parseScore(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid score %s", s)
+	}
+
+	return score, nil
+}